@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/cache"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of the downloaded connector packages",
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:          "prune",
+	Short:        "Evict the least recently used connector packages until the cache fits in its size cap",
+	RunE:         runCachePrune,
+	SilenceUsage: true,
+}
+
+var cacheArgs struct {
+	Dir       string
+	MaxSizeMB int64
+	Disabled  bool
+}
+
+var cachePruneArgs struct {
+	All bool
+}
+
+func init() {
+	RootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+
+	// Folder of the package cache, shared by every command that downloads the connector packages
+	var cacheDirEnv = os.Getenv("NDC_HUB_CACHE_DIR")
+	if cacheDirEnv == "" {
+		cacheDirEnv = cache.DefaultDir()
+	}
+	RootCmd.PersistentFlags().StringVar(&cacheArgs.Dir, "cache-dir", cacheDirEnv, "folder of the cache of the downloaded connector packages (env: NDC_HUB_CACHE_DIR)")
+	RootCmd.PersistentFlags().Int64Var(&cacheArgs.MaxSizeMB, "cache-max-size-mb", cache.DefaultMaxSize>>20, "size cap of the cache of the downloaded connector packages, in MiB. 0 disables the size cap")
+	RootCmd.PersistentFlags().BoolVar(&cacheArgs.Disabled, "no-cache", false, "download the connector packages every time instead of reading them from the cache")
+
+	cachePruneCmd.Flags().BoolVar(&cachePruneArgs.All, "all", false, "evict every connector package from the cache")
+}
+
+// configurePackageCache sets the package cache shared by the downloads of the connector packages. The commands
+// still work if the cache can't be created, the packages are then downloaded every time.
+func configurePackageCache(cmd *cobra.Command, args []string) error {
+	if cacheArgs.Disabled || cacheArgs.Dir == "" {
+		pkg.SetPackageCache(nil)
+		return nil
+	}
+	if cacheArgs.MaxSizeMB < 0 {
+		return fmt.Errorf("invalid cache size cap: %d", cacheArgs.MaxSizeMB)
+	}
+
+	packageCache, err := cache.New(cacheArgs.Dir, cacheArgs.MaxSizeMB<<20)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Disabling the package cache: %v\n", err)
+		pkg.SetPackageCache(nil)
+		return nil
+	}
+	pkg.SetPackageCache(packageCache)
+	return nil
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	if cacheArgs.Disabled || cacheArgs.Dir == "" {
+		return fmt.Errorf("the package cache is disabled")
+	}
+	packageCache, err := cache.New(cacheArgs.Dir, cacheArgs.MaxSizeMB<<20)
+	if err != nil {
+		return err
+	}
+
+	maxSize := cacheArgs.MaxSizeMB << 20
+	if cachePruneArgs.All {
+		maxSize = 0
+	} else if maxSize == 0 {
+		return fmt.Errorf("the package cache has no size cap, pass --all to evict every connector package")
+	}
+	result, err := packageCache.Prune(maxSize)
+	if err != nil {
+		return fmt.Errorf("Failed to prune the package cache: %w", err)
+	}
+	fmt.Printf("Evicted %d connector package(s) from %s, freeing %d MiB\n", result.Removed, packageCache.Dir(), result.FreedBytes>>20)
+	return nil
+}
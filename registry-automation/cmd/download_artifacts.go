@@ -3,12 +3,12 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"path/filepath"
 
 	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/publish"
 	"github.com/spf13/cobra"
 )
 
@@ -72,34 +72,9 @@ func DownloadArtifacts(opts ...ArtifactOption) ([]*ndchub.ConnectorArtifacts, er
 	}
 }
 
-func getChangedFiles(filepath string) (*ChangedFiles, error) {
-	changedFilesContent, err := os.Open(filepath)
-	if err != nil {
-		// log.Fatalf("Failed to open the file: %v, err: %v", ciCmdArgs.ChangedFilesPath, err)
-		return nil, fmt.Errorf("failed to open the file: %v, err: %w", downloadArtifactsCmdArgs.ChangedFilesPath, err)
-	}
-	defer changedFilesContent.Close()
-
-	// Read the changed file's contents. This file contains all the changed files in the PR
-	changedFilesByteValue, err := io.ReadAll(changedFilesContent)
-	if err != nil {
-		// log.Fatalf("Failed to read the changed files JSON file: %v", err)
-		return nil, fmt.Errorf("failed to read the changed files JSON file: %w", err)
-	}
-
-	var changedFiles *ChangedFiles = &ChangedFiles{}
-	err = json.Unmarshal(changedFilesByteValue, changedFiles)
-	if err != nil {
-		// log.Fatalf("Failed to unmarshal the changed files content: %v", err)
-		return nil, fmt.Errorf("failed to unmarshal the changed files content: %w", err)
-	}
-
-	return changedFiles, nil
-}
-
 // for now, since we're only adding support for newly added connectors, we will only check for the added files
 // in the added files, we only need files that end with connector-packaging.json
-func filterConnectorPackagingFiles(changedFiles *ChangedFiles) []string {
+func filterConnectorPackagingFiles(changedFiles publish.ChangedFiles) []string {
 	// Filter the changed files to only include the ones that are in the connector registry
 	var filteredChangedFiles []string = make([]string, 0)
 	for _, file := range changedFiles.Added {
@@ -129,7 +104,7 @@ func getConnectorPackaging(filePath string) (*ndchub.ConnectorPackaging, error)
 
 func getConnectorPackagingFilesFromChangedFiles(changedFilesPath string) ([]string, error) {
 	// Get the changed files from the PR
-	changedFiles, err := getChangedFiles(changedFilesPath)
+	changedFiles, err := publish.ReadChangedFiles(changedFilesPath)
 	if err != nil {
 		return nil, err
 	}
@@ -160,6 +135,7 @@ func downloadArtifactsUtil(connectorPackagingFilePath string) (*ndchub.Connector
 	}
 
 	connectorMetadata, _, extractedTgzPath, err := ndchub.GetPackagingSpec(connectorPackaging.URI, 
+		connectorPackaging.Checksum,
 		connectorPackaging.Namespace,
 		connectorPackaging.Name, 
 		connectorPackaging.Version,
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/publish"
+	"github.com/machinebox/graphql"
+	"github.com/spf13/cobra"
+)
+
+var driftCmd = &cobra.Command{
+	Use:          "drift",
+	Short:        "Compare the registry folder against the live hub registry",
+	PreRunE:      preRunCheck,
+	RunE:         runDrift,
+	SilenceUsage: true,
+}
+
+var driftCmdArgs struct {
+	Fix               bool
+	DeprecationColumn bool
+}
+
+func init() {
+	RootCmd.AddCommand(driftCmd)
+
+	driftCmd.PersistentFlags().BoolVar(&driftCmdArgs.Fix, "fix", false, "print the GraphQL mutation that reconciles the registry with the registry folder")
+	addDeprecationColumnFlag(driftCmd, &driftCmdArgs.DeprecationColumn)
+}
+
+func runDrift(cmd *cobra.Command, args []string) error {
+	for _, envVar := range []string{"NDC_HUB_GIT_REPO_FILE_PATH", "CONNECTOR_REGISTRY_GQL_URL", "CONNECTOR_PUBLICATION_KEY", "GCP_BUCKET_NAME"} {
+		if os.Getenv(envVar) == "" {
+			return fmt.Errorf("%s is not set", envVar)
+		}
+	}
+
+	detector, err := publish.NewDriftDetector(graphql.NewClient(os.Getenv("CONNECTOR_REGISTRY_GQL_URL")),
+		os.Getenv("CONNECTOR_PUBLICATION_KEY"), os.Getenv("GCP_BUCKET_NAME"), driftCmdArgs.DeprecationColumn)
+	if err != nil {
+		return err
+	}
+
+	// Unlike the ci and sync commands, which read the registry folder from the parent directory, the registry folder
+	// is read from the repo root of NDC_HUB_GIT_REPO_FILE_PATH, so that the command can be run from anywhere
+	report, err := detector.Detect(filepath.Join(getRepoRoot(), "registry"))
+	if err != nil {
+		return fmt.Errorf("Failed to detect the registry drift: %w", err)
+	}
+
+	if !report.HasDrift() {
+		fmt.Fprintln(os.Stderr, "The registry is in sync with the registry folder")
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Found %d difference(s) between the registry folder and the registry:\n", len(report.Drifts))
+	for _, drift := range report.Drifts {
+		fmt.Fprintf(os.Stderr, "  - %s\n", drift)
+	}
+
+	// The command fails when the registry drifted, so that the CI can alert on it
+	driftErr := fmt.Errorf("the registry drifted from the registry folder: %d difference(s)", len(report.Drifts))
+	if !driftCmdArgs.Fix {
+		return driftErr
+	}
+
+	if unfixable := report.Unfixable(); len(unfixable) > 0 {
+		fmt.Fprintf(os.Stderr, "%d missing connector(s) or version(s) are not reconciled by the mutation, they are published by the ci command\n", len(unfixable))
+	}
+
+	mutation, err := json.MarshalIndent(report.ReconcilingMutation(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to marshal the reconciling mutation: %w", err)
+	}
+	fmt.Println(string(mutation))
+	return driftErr
+}
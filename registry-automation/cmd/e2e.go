@@ -3,12 +3,12 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"path/filepath"
 
 	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/publish"
 
 	"github.com/spf13/cobra"
 )
@@ -54,31 +54,21 @@ func init() {
 }
 
 func e2eChanged(cmd *cobra.Command, args []string) {
-	changedFilesContent, err := os.Open(e2eChangedCmdArgs.ChangedFilesPath)
+	changedFiles, err := publish.ReadChangedFiles(e2eChangedCmdArgs.ChangedFilesPath)
 	if err != nil {
-		log.Fatalf("Failed to open the file: %v, err: %v", e2eChangedCmdArgs.ChangedFilesPath, err)
-	}
-	defer changedFilesContent.Close()
-
-	// Read the changed file's contents. This file contains all the changed files in the PR
-	changedFilesByteValue, err := io.ReadAll(changedFilesContent)
-	if err != nil {
-		log.Fatalf("Failed to read the changed files JSON file: %v", err)
-	}
-
-	var changedFiles ChangedFiles
-	err = json.Unmarshal(changedFilesByteValue, &changedFiles)
-	if err != nil {
-		log.Fatalf("Failed to unmarshal the changed files content: %v", err)
+		log.Fatalf("Failed to read the changed files: %v", err)
 	}
 
 	// Collect the added or modified connectors
-	processed := processChangedFiles(changedFiles)
+	processed := publish.ProcessChangedFiles(changedFiles)
 	out := make([]E2EOutput, 0)
 	for connector, versions := range processed.NewConnectorVersions {
 		for version, connectorPackagingPath := range versions {
 
-			testConfigPath := getTestConfigPath(connectorPackagingPath, getRepoRoot())
+			testConfigPath, err := getTestConfigPath(connectorPackagingPath, getRepoRoot())
+			if err != nil {
+				log.Fatalf("Failed to get the test config path: %v", err)
+			}
 			if testConfigPath == "" {
 				// TODO: improve error to point to readme/rfc to add tests
 				log.Fatalf("test config must be provided for all new connector releases. No test config found for %q",
@@ -106,7 +96,10 @@ func e2eAllFunc(cmd *cobra.Command, args []string) {
 			return nil
 		}
 		if filepath.Base(path) == ndchub.ConnectorPackagingJSON {
-			e2eOutput := getE2EOutput(path, getRepoRoot())
+			e2eOutput, err := getE2EOutput(path, getRepoRoot())
+			if err != nil {
+				return err
+			}
 			if e2eOutput != nil {
 				out = append(out, *e2eOutput)
 			}
@@ -150,7 +143,10 @@ func e2eLatestFunc(cmd *cobra.Command, args []string) {
 			}
 			latestVersion := cm.Overview.LatestVersion
 			latestVersionCPPath := filepath.Join(connectorDir, "releases", latestVersion, ndchub.ConnectorPackagingJSON)
-			e2eOutput := getE2EOutput(latestVersionCPPath, getRepoRoot())
+			e2eOutput, err := getE2EOutput(latestVersionCPPath, getRepoRoot())
+			if err != nil {
+				log.Fatalf("Failed to get the e2e output: %v", err)
+			}
 			if e2eOutput != nil {
 				out = append(out, *e2eOutput)
 			}
@@ -168,7 +164,9 @@ func printE2EOutput(out []E2EOutput) {
 	fmt.Fprintln(os.Stdout, string(outBytes))
 }
 
-func getTestConfigPath(connectorPackagingPath string, repoRoot string) string {
+// getTestConfigPath returns the path of the test config of the connector version relative to the repo root,
+// or an empty string if the connector version has no test config
+func getTestConfigPath(connectorPackagingPath string, repoRoot string) (string, error) {
 	// connectorPackagingPath is relative to the repo. Use the repo root to construct Abs path
 	fqConnectorPackagingPath := connectorPackagingPath
 	if !filepath.IsAbs(fqConnectorPackagingPath) {
@@ -176,29 +174,32 @@ func getTestConfigPath(connectorPackagingPath string, repoRoot string) string {
 	}
 	cp, err := ndchub.GetConnectorPackaging(fqConnectorPackagingPath)
 	if err != nil {
-		log.Fatalf("Failed to get connector packaging: %v", err)
+		return "", fmt.Errorf("failed to get connector packaging: %w", err)
 	}
 	if cp == nil {
 		log.Printf("connector packaging is nil for %v, ignoring", fqConnectorPackagingPath)
-		return ""
+		return "", nil
 	}
 	testConfigPath := cp.GetTestConfigPath()
 	if testConfigPath == "" {
-		return ""
+		return "", nil
 	}
 	// test config path looks like this: /some/folder/ndc-hub/registry/hasura/turso/releases/v0.1.0/test-config.json. Make it relative to the repo root
 	out, err := filepath.Rel(repoRoot, testConfigPath)
 	if err != nil {
-		log.Fatalf("Failed to get relative path for test config: %v", err)
+		return "", fmt.Errorf("failed to get relative path for test config: %w", err)
 	}
-	return out
+	return out, nil
 }
 
-func getE2EOutput(connectorPackagingPath string, repoRoot string) *E2EOutput {
-	testConfigPath := getTestConfigPath(connectorPackagingPath, repoRoot)
+func getE2EOutput(connectorPackagingPath string, repoRoot string) (*E2EOutput, error) {
+	testConfigPath, err := getTestConfigPath(connectorPackagingPath, repoRoot)
+	if err != nil {
+		return nil, err
+	}
 	if testConfigPath == "" {
 		log.Printf("test config path is empty for %v, ignoring", connectorPackagingPath)
-		return nil
+		return nil, nil
 	}
 	// path looks like this: /some/folder/ndc-hub/registry/hasura/turso/releases/v0.1.0/connector-packaging.json
 	versionFolder := filepath.Dir(connectorPackagingPath)
@@ -211,7 +212,7 @@ func getE2EOutput(connectorPackagingPath string, repoRoot string) *E2EOutput {
 		ConnectorName:      filepath.Base(connectorFolder),
 		ConnectorVersion:   filepath.Base(versionFolder),
 		TestConfigFilePath: testConfigPath,
-	}
+	}, nil
 }
 
 func preRunCheck(cmd *cobra.Command, args []string) error {
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/oci"
+	"github.com/spf13/cobra"
+)
+
+// imageRegistryArgs are the flags of the commands that resolve the docker images of the packaging specs to digests
+type imageRegistryArgs struct {
+	ResolveImageDigests bool
+	ImageRegistry       string
+}
+
+func addImageRegistryFlags(cmd *cobra.Command, args *imageRegistryArgs) {
+	cmd.PersistentFlags().BoolVar(&args.ResolveImageDigests, "resolve-image-digests", false, "resolve the docker images of the packaging specs to the digests of their manifests, and check that they are available for linux/amd64 and linux/arm64")
+	cmd.PersistentFlags().StringVar(&args.ImageRegistry, "image-registry", os.Getenv("NDC_HUB_IMAGE_REGISTRY"),
+		"base URL of the registry that the docker images are resolved against, e.g. http://localhost:5000 for a local registry:2. "+
+			"Default: the registry of each image (env: NDC_HUB_IMAGE_REGISTRY)")
+}
+
+// imageResolver returns the resolver of the docker images, nil unless --resolve-image-digests is set
+func (args imageRegistryArgs) imageResolver() *oci.Resolver {
+	if !args.ResolveImageDigests {
+		return nil
+	}
+	return oci.NewResolver(args.ImageRegistry)
+}
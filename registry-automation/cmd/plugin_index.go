@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/spf13/cobra"
+)
+
+var pluginIndexArgs struct {
+	Source string
+}
+
+func init() {
+	// Source of the manifests of the CLI plugins, shared by every command that resolves them
+	RootCmd.PersistentFlags().StringVar(&pluginIndexArgs.Source, "cli-plugins-index", os.Getenv("NDC_HUB_CLI_PLUGINS_INDEX"),
+		"CLI plugins index that the plugin manifests are resolved from: an http(s) URL of its root, github:owner/name@commit or a local checkout. "+
+			"Default: the master branch of hasura/cli-plugins-index (env: NDC_HUB_CLI_PLUGINS_INDEX)")
+}
+
+// configurePluginIndex sets the index that the manifests of the CLI plugins are resolved from
+func configurePluginIndex(cmd *cobra.Command, args []string) error {
+	index, err := ndchub.ParsePluginIndex(pluginIndexArgs.Source)
+	if err != nil {
+		return fmt.Errorf("invalid --cli-plugins-index: %w", err)
+	}
+	ndchub.SetPluginIndex(index)
+	return nil
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// registrySchemaArgs are the flags of the commands that publish to the registry, they enable the columns that the
+// registry database doesn't have until its schema is migrated
+type registrySchemaArgs struct {
+	PackagingSpecColumns bool
+	DeprecationColumn    bool
+}
+
+func addRegistrySchemaFlags(cmd *cobra.Command, args *registrySchemaArgs) {
+	cmd.PersistentFlags().BoolVar(&args.PackagingSpecColumns, "packaging-spec-columns", false,
+		"record the ndc_spec_generation and cli_plugin_kind columns of the connector versions, once the registry has them")
+	addDeprecationColumnFlag(cmd, &args.DeprecationColumn)
+}
+
+// addDeprecationColumnFlag adds the flag that enables the is_deprecated column, which the drift command also reads
+func addDeprecationColumnFlag(cmd *cobra.Command, deprecationColumn *bool) {
+	cmd.PersistentFlags().BoolVar(deprecationColumn, "deprecation-column", false,
+		"withdraw the deleted connector versions with the is_deprecated column of the connector versions, once the registry has it")
+}
@@ -10,6 +10,14 @@ import (
 var RootCmd = &cobra.Command{
 	Use:   "registry-automation",
 	Short: "Commands associated with automation for the hub registry",
+	// The connector packages downloaded by every command are shared through the package cache, and the manifests of
+	// the CLI plugins are resolved from the same index
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := configurePackageCache(cmd, args); err != nil {
+			return err
+		}
+		return configurePluginIndex(cmd, args)
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -0,0 +1,90 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hasura/ndc-hub/registry-automation/cmd"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/vulnerabilityscan"
+	"github.com/spf13/cobra"
+)
+
+var artifactsCmd = &cobra.Command{
+	Use: "artifacts",
+	// The trivy command predates the choice of the scanner
+	Aliases: []string{"trivy"},
+	Short:   "Scan the artifacts of the connector versions",
+	Long: `Scan the docker images and the CLI plugin binaries of the connector versions added by the changed files for
+vulnerabilities and compliance issues, with Trivy or Grype.`,
+	Run: runArtifactsCmd,
+}
+
+var artifactsCmdArgs = struct {
+	ChangedFilesPath string
+	Scanner          string
+	Severities       string
+}{}
+
+func init() {
+	// Add the artifacts command to the scan command
+	scanCmd.AddCommand(artifactsCmd)
+
+	var changedFilesPathEnv = os.Getenv("CHANGED_FILES_PATH") // this file contains the list of changed files
+	artifactsCmd.PersistentFlags().StringVar(&artifactsCmdArgs.ChangedFilesPath, "changed-files-path", changedFilesPathEnv, "path to a line-separated list of changed files in the PR")
+	if changedFilesPathEnv == "" {
+		artifactsCmd.MarkPersistentFlagRequired("changed-files-path")
+	}
+	artifactsCmd.PersistentFlags().StringVar(&artifactsCmdArgs.Scanner, "scanner", vulnerabilityscan.DockerTrivyScannerName,
+		fmt.Sprintf("scanner of the artifacts (%s)", strings.Join(vulnerabilityscan.ScannerNames, "/")))
+	artifactsCmd.PersistentFlags().StringVar(&artifactsCmdArgs.Severities, "severity", "CRITICAL,HIGH", "comma separated severities of the reported findings")
+}
+
+func downloadArtifacts(changedFilesPath string) ([]*ndchub.ConnectorArtifacts, error) {
+	artifacts, err := cmd.DownloadArtifacts(cmd.WithChangedFilesPath(changedFilesPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download artifacts: %w", err)
+	}
+	return artifacts, nil
+}
+
+func runArtifactsCmd(cmd *cobra.Command, args []string) {
+	changedFilesPath := artifactsCmdArgs.ChangedFilesPath
+	if changedFilesPath == "" {
+		fmt.Println("No changed files path provided. Please set the CHANGED_FILES_PATH environment variable or use the --changed-files-path flag.")
+		os.Exit(1)
+	}
+
+	severities, err := vulnerabilityscan.ParseSeverities(artifactsCmdArgs.Severities)
+	if err != nil {
+		fmt.Printf("Invalid --severity: %v\n", err)
+		os.Exit(1)
+	}
+	scanner, err := vulnerabilityscan.NewScanner(artifactsCmdArgs.Scanner, severities)
+	if err != nil {
+		fmt.Printf("Invalid --scanner: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Download artifacts based on changed files
+	artifacts, err := downloadArtifacts(changedFilesPath)
+	if err != nil {
+		fmt.Printf("Error downloading artifacts: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Every connector is scanned before the findings are reported together
+	report := vulnerabilityscan.ScanArtifacts(context.Background(), scanner, artifacts)
+	if err := vulnerabilityscan.WriteReport(os.Stdout, report); err != nil {
+		fmt.Printf("Error writing the scan report: %v\n", err)
+		os.Exit(1)
+	}
+	if report.Failed() {
+		fmt.Println("Exiting with a non-zero error code due to the findings or the failures of the scan")
+		os.Exit(1)
+	}
+
+	fmt.Println("All connector artifacts scanned successfully.")
+}
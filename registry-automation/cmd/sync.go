@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/publish"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:          "sync",
+	Short:        "Republish the connectors of the registry folder, e.g. to backfill a new environment",
+	RunE:         runSync,
+	SilenceUsage: true,
+}
+
+var syncCmdArgs ConnectorRegistryArgs
+
+var syncFilterArgs struct {
+	All       bool
+	Namespace string
+	Connector string
+}
+
+func init() {
+	RootCmd.AddCommand(syncCmd)
+
+	// Publication environment
+	var publicationEnv = os.Getenv("PUBLICATION_ENV")
+	syncCmd.PersistentFlags().StringVar(&syncCmdArgs.PublicationEnv, "publication-env", publicationEnv, "publication environment (staging/production). Default: staging")
+	if publicationEnv == "" {
+		syncCmd.PersistentFlags().Set("publication-env", publish.StagingEnv)
+	}
+
+	syncCmd.PersistentFlags().BoolVar(&syncFilterArgs.All, "all", false, "republish every connector and every release of the registry folder")
+	syncCmd.PersistentFlags().StringVar(&syncFilterArgs.Namespace, "namespace", "", "only republish the connectors of the namespace")
+	syncCmd.PersistentFlags().StringVar(&syncFilterArgs.Connector, "connector", "", "only republish the connectors with this name")
+
+	syncCmd.PersistentFlags().IntVar(&syncCmdArgs.Concurrency, "concurrency", publish.DefaultConcurrency, "maximum number of connector versions processed concurrently")
+	syncCmd.PersistentFlags().BoolVar(&syncCmdArgs.DryRun, "dry-run", false, "compute the publication plan without uploading anything or updating the registry")
+	syncCmd.PersistentFlags().StringVar(&syncCmdArgs.PlanFormat, "plan-format", string(publish.JSONPlanFormat), "format of the publication plan printed in dry-run mode (json/markdown)")
+	syncCmd.PersistentFlags().StringVar(&syncCmdArgs.PlanOutputPath, "plan-output", "", "path of the file to write the publication plan to in dry-run mode. Default: stdout")
+	addImageRegistryFlags(syncCmd, &syncCmdArgs.ImageRegistry)
+	addRegistrySchemaFlags(syncCmd, &syncCmdArgs.RegistrySchema)
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	if !syncFilterArgs.All && syncFilterArgs.Namespace == "" && syncFilterArgs.Connector == "" {
+		return errors.New("pass --all to republish the whole registry, or filter the connectors with --namespace or --connector")
+	}
+	if syncCmdArgs.DryRun && !publish.PlanFormat(syncCmdArgs.PlanFormat).IsValid() {
+		return fmt.Errorf("Unexpected: invalid plan format: %s", syncCmdArgs.PlanFormat)
+	}
+
+	publisher, clients, err := buildPublisher(&syncCmdArgs)
+	if err != nil {
+		return err
+	}
+	defer clients.Close()
+
+	// The files are read relative to the parent directory, like the changed files of the ci command
+	registryFiles, err := publish.ListRegistryFiles("..", publish.SyncFilter{Namespace: syncFilterArgs.Namespace, Connector: syncFilterArgs.Connector})
+	if err != nil {
+		return err
+	}
+	if len(registryFiles.Added) == 0 {
+		return errors.New("no connectors of the registry folder match the filters")
+	}
+
+	plan, err := publisher.PlanSync(registryFiles)
+	if err != nil {
+		return fmt.Errorf("Failed to build the publication plan: %w", err)
+	}
+
+	if syncCmdArgs.DryRun {
+		if err := outputPublicationPlan(plan, publish.PlanFormat(syncCmdArgs.PlanFormat), syncCmdArgs.PlanOutputPath); err != nil {
+			return fmt.Errorf("Failed to write the publication plan: %w", err)
+		}
+		return nil
+	}
+
+	if err := publisher.Apply(&plan); err != nil {
+		return fmt.Errorf("Failed to apply the publication plan: %w", err)
+	}
+	fmt.Println("Successfully synced the registry folder")
+	return publish.WritePublicationSummary(os.Stdout, plan)
+}
@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/publish"
 	"github.com/hasura/ndc-hub/registry-automation/pkg/validate"
 	"github.com/spf13/cobra"
 )
@@ -17,11 +21,33 @@ var validateCmd = &cobra.Command{
 	Run:   executeValidateCmd,
 }
 
+var validateCmdArgs struct {
+	Format           string
+	OutputPath       string
+	ChangedFilesPath string
+	Connectors       []string
+	CliPlugins       bool
+	ImageRegistry    imageRegistryArgs
+}
+
 func init() {
+	validateCmd.PersistentFlags().StringVar(&validateCmdArgs.ChangedFilesPath, "changed-files-path", "", "path to the changed files of the PR, only the connectors touched by the changes are validated")
+	validateCmd.PersistentFlags().StringSliceVar(&validateCmdArgs.Connectors, "connector", nil, "only validate the connector, as namespace/name or namespace/name@version. Can be repeated")
+	validateCmd.PersistentFlags().BoolVar(&validateCmdArgs.CliPlugins, "cli-plugins", false, "also download and verify the CLI plugin manifests and binaries of the packaging specs")
+	addImageRegistryFlags(validateCmd, &validateCmdArgs.ImageRegistry)
+	validateCmd.PersistentFlags().StringVar(&validateCmdArgs.Format, "format", string(validate.TextReportFormat), "format of the validation report (text/json/junit/sarif)")
+	validateCmd.PersistentFlags().StringVar(&validateCmdArgs.OutputPath, "output", "", "path of the file to write the validation report to. Default: stdout")
 	RootCmd.AddCommand(validateCmd)
 }
 
 func executeValidateCmd(cmd *cobra.Command, args []string) {
+	reportFormat := validate.ReportFormat(validateCmdArgs.Format)
+	if !reportFormat.IsValid() {
+		fmt.Println("invalid report format:", validateCmdArgs.Format)
+		os.Exit(1)
+		return
+	}
+
 	ndcHubGitRepoFilePath := os.Getenv("NDC_HUB_GIT_REPO_FILE_PATH")
 	if ndcHubGitRepoFilePath == "" {
 		fmt.Println("please set a value for NDC_HUB_GIT_REPO_FILE_PATH env var")
@@ -29,8 +55,15 @@ func executeValidateCmd(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	scope, err := buildValidationScope(validateCmdArgs.ChangedFilesPath, validateCmdArgs.Connectors)
+	if err != nil {
+		fmt.Println("error while building the validation scope", err)
+		os.Exit(1)
+		return
+	}
+
 	registryFolder := filepath.Join(ndcHubGitRepoFilePath, "registry")
-	_, err := os.Stat(registryFolder)
+	_, err = os.Stat(registryFolder)
 	if err != nil {
 		fmt.Println("error while finding the registry folder", err)
 		os.Exit(1)
@@ -54,15 +87,46 @@ func executeValidateCmd(cmd *cobra.Command, args []string) {
 	}
 	var connectorPkgs []connectorPackaging
 
+	var aliasedConnectors []*ndchub.AliasedConnector
+
+	validator := validate.NewValidator(validate.DefaultRuleRegistry())
+	// The hub files are checked against their JSON schemas once the overrides of every connector are loaded
+	var hubFiles []string
+
 	err = filepath.WalkDir(registryFolder, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
+		// The files of an aliased connector are in the folder of its parent, and in scope with either of them
+		connector, _ := registryFileConnector(path)
+		inScope := scope.IncludesConnector(connector) || scope.IncludesConnector(registryConnectorFolder(registryFolder, path))
+		// The files outside of the scope are only read for the checks across connectors, e.g. the unique titles, so a
+		// broken file of another connector doesn't fail the validation
+		skipOutOfScope := func(err error) error {
+			if inScope {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "skipping", path, "outside of the validation scope:", err)
+			return nil
+		}
+
+		switch filepath.Base(path) {
+		case validate.ConnectorConfigFile, ndchub.MetadataJSON, ndchub.ConnectorPackagingJSON:
+			if !inScope {
+				break
+			}
+			if filepath.Base(path) == validate.ConnectorConfigFile {
+				validator.LoadConnectorConfig(connector, path)
+			} else {
+				hubFiles = append(hubFiles, path)
+			}
+		}
+
 		if filepath.Base(path) == ndchub.ConnectorPackagingJSON {
 			cp, err := ndchub.GetConnectorPackaging(path)
 			if err != nil {
-				return err
+				return skipOutOfScope(err)
 			}
 			if cp != nil {
 				connectorPkgs = append(connectorPkgs, connectorPackaging{filePath: path, connectorPackage: cp})
@@ -73,11 +137,19 @@ func executeValidateCmd(cmd *cobra.Command, args []string) {
 		if filepath.Base(path) == ndchub.MetadataJSON {
 			cm, err := ndchub.GetConnectorMetadata(path)
 			if err != nil {
-				return err
+				return skipOutOfScope(err)
 			}
 			if cm != nil {
 				allConnectorMetadata = append(allConnectorMetadata, connectorMetadata{filepath: path, metadata: cm})
 			}
+
+			if ndchub.IsAliasedConnectorPath(path) {
+				ac, err := ndchub.GetAliasedConnector(path)
+				if err != nil {
+					return skipOutOfScope(err)
+				}
+				aliasedConnectors = append(aliasedConnectors, ac)
+			}
 		}
 
 		return nil
@@ -88,21 +160,37 @@ func executeValidateCmd(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	hasError := false
+	// The progress is logged on stderr, so that the report can be written to stdout
+	fmt.Fprintln(os.Stderr, "Validating the hub files against their JSON schemas")
+	for _, path := range hubFiles {
+		if err := validateHubFileSchema(validator, path); err != nil {
+			fmt.Println("error while reading", path, err)
+			os.Exit(1)
+			return
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Completed validating the hub files against their JSON schemas")
 
-	fmt.Println("Validating `connector-packaging.json` contents")
+	fmt.Fprintln(os.Stderr, "Validating `connector-packaging.json` contents")
 	for _, cp := range connectorPkgs {
-		println("validating connector packaging for", cp.connectorPackage.Namespace, cp.connectorPackage.Name, "with version", cp.connectorPackage.Version)
-		err := validate.ConnectorPackaging(cp.connectorPackage, true)
-		if err != nil {
-			fmt.Println("error validating connector packaging", cp.filePath, err)
-			hasError = true
+		connector := cp.connectorPackage.Namespace + "/" + cp.connectorPackage.Name
+		if !scope.IncludesConnector(connector) {
+			continue
 		}
+		fmt.Fprintln(os.Stderr, "validating connector packaging for", cp.connectorPackage.Namespace, cp.connectorPackage.Name, "with version", cp.connectorPackage.Version)
+		validator.AddConnector(connector)
+		// Every release of a connector in scope is checked, but only the releases in scope are downloaded
+		validator.CheckConnectorPackaging(cp.connectorPackage, scope.IncludesVersion(connector, cp.connectorPackage.Version))
 	}
-	fmt.Println("Completed validating `connector-packaging.json` contents")
+	fmt.Fprintln(os.Stderr, "Completed validating `connector-packaging.json` contents")
 
-	fmt.Println("Validating latest versions in metadata.json")
+	fmt.Fprintln(os.Stderr, "Validating latest versions and source code versions in metadata.json")
 	for _, cm := range allConnectorMetadata {
+		connector := cm.metadata.Overview.Namespace + "/" + cm.metadata.Overview.Name
+		if !scope.IncludesConnector(connector) {
+			continue
+		}
+
 		var respectiveConnectorPkgs []ndchub.ConnectorPackaging
 		for _, cp := range connectorPkgs {
 			if cp.connectorPackage.Namespace == cm.metadata.Overview.Namespace && cp.connectorPackage.Name == cm.metadata.Overview.Name {
@@ -110,32 +198,194 @@ func executeValidateCmd(cmd *cobra.Command, args []string) {
 			}
 		}
 
-		err := validate.Metadata(cm.metadata, respectiveConnectorPkgs)
-		if err != nil {
-			fmt.Println("error validating connector packaging", cm.filepath, err)
-			hasError = true
+		validator.AddConnector(connector)
+		validator.CheckMetadata(cm.filepath, cm.metadata, respectiveConnectorPkgs)
+	}
+	fmt.Fprintln(os.Stderr, "Completed validating latest versions and source code versions")
+
+	fmt.Fprintln(os.Stderr, "Validating aliased connectors")
+	for _, ac := range aliasedConnectors {
+		connector := ac.Namespace + "/" + ac.Name
+		parentConnector := ac.Namespace + "/" + ac.ParentName
+		if !scope.IncludesConnector(connector) && !scope.IncludesConnector(parentConnector) {
+			continue
 		}
+
+		var parent *ndchub.ConnectorMetadata
+		for _, cm := range allConnectorMetadata {
+			if cm.metadata.Overview.Namespace+"/"+cm.metadata.Overview.Name == parentConnector {
+				parent = cm.metadata
+			}
+		}
+
+		validator.AddConnector(connector)
+		validator.CheckAliasedConnector(ac, parent)
 	}
-	fmt.Println("Completed validating latest versions")
+	fmt.Fprintln(os.Stderr, "Completed validating aliased connectors")
 
-	fmt.Println("Validating Packaging spec contents")
+	// The titles are unique across the hub, so the titles of every connector are compared, in scope or not
+	fmt.Fprintln(os.Stderr, "Validating the titles of the connectors")
+	var titles []validate.ConnectorTitle
+	for _, cm := range allConnectorMetadata {
+		titles = append(titles, validate.ConnectorTitle{Connector: cm.metadata.Overview.Namespace + "/" + cm.metadata.Overview.Name, Title: cm.metadata.Overview.Title})
+	}
+	for _, ac := range aliasedConnectors {
+		titles = append(titles, validate.ConnectorTitle{Connector: ac.Namespace + "/" + ac.Name, Title: ac.Metadata.Overview.Title})
+	}
+	for _, cm := range allConnectorMetadata {
+		connector := cm.metadata.Overview.Namespace + "/" + cm.metadata.Overview.Name
+		if scope.IncludesConnector(connector) {
+			validator.CheckTitle(cm.filepath, connector, cm.metadata.Overview.Title, titles)
+		}
+	}
+	for _, ac := range aliasedConnectors {
+		connector := ac.Namespace + "/" + ac.Name
+		if scope.IncludesConnector(connector) || scope.IncludesConnector(ac.Namespace+"/"+ac.ParentName) {
+			validator.CheckTitle(ac.Path, connector, ac.Metadata.Overview.Title, titles)
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Completed validating the titles of the connectors")
+
+	fmt.Fprintln(os.Stderr, "Validating Packaging spec contents")
+	packagingSpecOptions := validate.PackagingSpecOptions{CliPlugin: validateCmdArgs.CliPlugins}
+	if resolver := validateCmdArgs.ImageRegistry.imageResolver(); resolver != nil {
+		packagingSpecOptions.ImageResolver = resolver
+	}
 	for _, cp := range connectorPkgs {
-		packagingSpec, _, _, err := ndchub.GetPackagingSpec(cp.connectorPackage.URI, cp.connectorPackage.Namespace, cp.connectorPackage.Name, cp.connectorPackage.Version)
-		if err != nil {
-			fmt.Println("error getting packaging spec for", cp.connectorPackage.URI, err)
-			hasError = true
+		if !scope.IncludesVersion(cp.connectorPackage.Namespace+"/"+cp.connectorPackage.Name, cp.connectorPackage.Version) {
 			continue
 		}
-		if err := packagingSpec.Validate(); err != nil {
-			fmt.Println("error validating packaging spec for", cp.connectorPackage.Namespace,
-				cp.connectorPackage.Name, cp.connectorPackage.Version, err)
-			hasError = true
-		}
+		validator.CheckPackagingSpec(cp.connectorPackage, packagingSpecOptions)
+	}
+	fmt.Fprintln(os.Stderr, "Completed validating Packaging spec contents")
+
+	report := validator.Report()
+	report.RelativizePaths(ndcHubGitRepoFilePath)
+	if err := outputValidationReport(report, reportFormat, validateCmdArgs.OutputPath); err != nil {
+		fmt.Println("error writing the validation report", err)
+		os.Exit(1)
+		return
 	}
-	fmt.Println("Completed validating Packaging spec contents")
 
-	if hasError {
-		fmt.Println("Exiting with a non-zero error code due to the error(s) in validation")
+	if report.HasErrors() {
+		fmt.Fprintln(os.Stderr, "Exiting with a non-zero error code due to the error(s) in validation")
 		os.Exit(1)
 	}
 }
+
+// buildValidationScope returns the connectors to validate, from the changed files and the --connector flags. A nil
+// scope, without any of them, validates the whole registry folder.
+func buildValidationScope(changedFilesPath string, connectorRefs []string) (*validate.Scope, error) {
+	if changedFilesPath == "" && len(connectorRefs) == 0 {
+		return nil, nil
+	}
+
+	scope := validate.NewScope()
+	if changedFilesPath != "" {
+		changedFiles, err := publish.ReadChangedFiles(changedFilesPath)
+		if err != nil {
+			return nil, err
+		}
+		includeChangedFiles(scope, changedFiles)
+	}
+	for _, connectorRef := range connectorRefs {
+		if err := scope.IncludeConnectorRef(connectorRef); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Validating", scope.Connectors(), "connector(s)")
+	return scope, nil
+}
+
+var (
+	connectorFileRegex          = regexp.MustCompile(`^registry/([^/]+)/([^/]+)/`)
+	connectorPackagingFileRegex = regexp.MustCompile(`^registry/([^/]+)/([^/]+)/releases/([^/]+)/connector-packaging\.json$`)
+)
+
+// includeChangedFiles includes the connectors touched by the changed files, and their new releases. Every changed
+// file of the folder of a connector includes the connector, e.g. its test config or its aliased connectors.
+func includeChangedFiles(scope *validate.Scope, changedFiles publish.ChangedFiles) {
+	processed := publish.ProcessChangedFiles(changedFiles)
+	for connector, versions := range processed.NewConnectorVersions {
+		for version := range versions {
+			scope.IncludeVersion(connector.Namespace+"/"+connector.Name, version)
+		}
+	}
+
+	var files []string
+	files = append(files, changedFiles.Added...)
+	files = append(files, changedFiles.Modified...)
+	files = append(files, changedFiles.Deleted...)
+	for _, file := range files {
+		if matches := connectorFileRegex.FindStringSubmatch(file); matches != nil {
+			scope.IncludeConnector(matches[1] + "/" + matches[2])
+		}
+	}
+	// The connector packaging files are immutable, but a modified one is still validated
+	for _, file := range changedFiles.Modified {
+		if matches := connectorPackagingFileRegex.FindStringSubmatch(file); matches != nil {
+			scope.IncludeVersion(matches[1]+"/"+matches[2], matches[3])
+		}
+	}
+}
+
+// registryConnectorFolder returns the namespace/name of the connector folder of a file of the registry folder
+func registryConnectorFolder(registryFolder, path string) string {
+	relPath, err := filepath.Rel(registryFolder, path)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+func outputValidationReport(report validate.Report, format validate.ReportFormat, outputPath string) error {
+	var w io.Writer = os.Stdout
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to create the report output file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	return validate.WriteReport(w, report, format)
+}
+
+// validateHubFileSchema validates the metadata.json and connector-packaging.json files against their JSON schemas,
+// the test-config.json files are validated along with the connector-packaging.json files that reference them
+func validateHubFileSchema(validator *validate.Validator, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	connector, version := registryFileConnector(path)
+	switch filepath.Base(path) {
+	case ndchub.MetadataJSON:
+		validator.CheckMetadataFile(path, content, connector)
+	case ndchub.ConnectorPackagingJSON:
+		validator.CheckConnectorPackagingFile(path, content, connector, version)
+	}
+	return nil
+}
+
+// registryFileConnector returns the connector and the release of a file of the registry folder, from its path, e.g.
+// /some/folder/ndc-hub/registry/hasura/turso/releases/v0.1.0/connector-packaging.json
+func registryFileConnector(path string) (connector string, version string) {
+	folder := filepath.Dir(path)
+	if filepath.Base(path) == ndchub.ConnectorPackagingJSON {
+		releasesFolder := filepath.Dir(folder)
+		connectorFolder := filepath.Dir(releasesFolder)
+		namespaceFolder := filepath.Dir(connectorFolder)
+		return filepath.Base(namespaceFolder) + "/" + filepath.Base(connectorFolder), filepath.Base(folder)
+	}
+	// The metadata.json of an aliased connector is in the aliased_connectors folder of the connector it aliases
+	namespaceFolder := filepath.Dir(folder)
+	if filepath.Base(namespaceFolder) == "aliased_connectors" {
+		namespaceFolder = filepath.Dir(filepath.Dir(namespaceFolder))
+	}
+	return filepath.Base(namespaceFolder) + "/" + filepath.Base(folder), ""
+}
@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrNotAnArchive is returned by ListArchiveEntries for the files that are neither tar.gz nor zip archives
+var ErrNotAnArchive = errors.New("not a tar.gz or zip archive")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// ListArchiveEntries returns the cleaned names of the files and symlinks of the tar.gz or zip archive at src, the
+// folders are not listed. The archive is read with the extract limits, and its unsafe entries are rejected with an
+// *UnsafeEntryError like ExtractTarGz does.
+func ListArchiveEntries(src string, limits ExtractLimits) ([]string, error) {
+	file, err := os.Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("error opening the archive: %w", err)
+	}
+	defer file.Close()
+
+	magic := make([]byte, len(zipMagic))
+	n, err := io.ReadFull(file, magic)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error reading the archive: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("error reading the archive: %w", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(magic[:n], gzipMagic):
+		return listTarGzEntries(file, limits)
+	case bytes.HasPrefix(magic[:n], zipMagic):
+		return listZipEntries(file, limits)
+	}
+	return nil, ErrNotAnArchive
+}
+
+func listTarGzEntries(r io.Reader, limits ExtractLimits) ([]string, error) {
+	reader, closer, err := newTarGzReader(r, limits)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	var entries []string
+	for {
+		header, name, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+		case tar.TypeReg, tar.TypeSymlink:
+			entries = append(entries, name)
+		default:
+			return nil, &UnsafeEntryError{Name: header.Name, Reason: fmt.Sprintf("unsupported entry type %q", string(header.Typeflag))}
+		}
+	}
+}
+
+func listZipEntries(file *os.File, limits ExtractLimits) ([]string, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error reading the archive: %w", err)
+	}
+	reader, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("error reading the zip archive: %w", err)
+	}
+	if len(reader.File) > limits.MaxFiles {
+		return nil, fmt.Errorf("%w: more than %d entries", ErrArchiveLimitExceeded, limits.MaxFiles)
+	}
+
+	var entries []string
+	var size int64
+	for _, zipFile := range reader.File {
+		size += int64(zipFile.UncompressedSize64)
+		if zipFile.UncompressedSize64 > uint64(limits.MaxUncompressedSize) || size > limits.MaxUncompressedSize {
+			return nil, fmt.Errorf("%w: more than %d bytes", ErrArchiveLimitExceeded, limits.MaxUncompressedSize)
+		}
+		name, err := cleanEntryName(zipFile.Name)
+		if err != nil {
+			return nil, err
+		}
+		if zipFile.FileInfo().IsDir() {
+			continue
+		}
+		entries = append(entries, name)
+	}
+	return entries, nil
+}
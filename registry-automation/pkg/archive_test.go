@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListArchiveEntries(t *testing.T) {
+	tarGzPath := writeTestArchive(t,
+		testEntry{header: tar.Header{Name: "./bin/", Typeflag: tar.TypeDir, Mode: 0755}},
+		tarFile("./bin/ndc-test-cli", "binary"),
+		tarSymlink("./ndc-test-cli", "bin/ndc-test-cli"),
+	)
+	entries, err := ListArchiveEntries(tarGzPath, DefaultExtractLimits)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bin/ndc-test-cli", "ndc-test-cli"}, entries)
+
+	zipPath := filepath.Join(t.TempDir(), "plugin.zip")
+	zipFile, err := os.Create(zipPath)
+	assert.NoError(t, err)
+	zipWriter := zip.NewWriter(zipFile)
+	_, err = zipWriter.Create("bin/")
+	assert.NoError(t, err)
+	writer, err := zipWriter.Create("bin/ndc-test-cli.exe")
+	assert.NoError(t, err)
+	_, err = writer.Write([]byte("binary"))
+	assert.NoError(t, err)
+	assert.NoError(t, zipWriter.Close())
+	assert.NoError(t, zipFile.Close())
+
+	entries, err = ListArchiveEntries(zipPath, DefaultExtractLimits)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bin/ndc-test-cli.exe"}, entries)
+
+	binaryPath := filepath.Join(t.TempDir(), "ndc-test-cli")
+	assert.NoError(t, os.WriteFile(binaryPath, []byte("\x7fELF binary"), 0755))
+	_, err = ListArchiveEntries(binaryPath, DefaultExtractLimits)
+	assert.ErrorIs(t, err, ErrNotAnArchive)
+
+	unsafeArchivePath := writeTestArchive(t, tarFile("../evil.sh", "evil"))
+	_, err = ListArchiveEntries(unsafeArchivePath, DefaultExtractLimits)
+	var unsafeEntryErr *UnsafeEntryError
+	assert.ErrorAs(t, err, &unsafeEntryErr)
+
+	_, err = ListArchiveEntries(tarGzPath, ExtractLimits{MaxUncompressedSize: 1 << 20, MaxFiles: 1})
+	assert.ErrorIs(t, err, ErrArchiveLimitExceeded)
+}
@@ -0,0 +1,256 @@
+// Package cache implements a content-addressed cache of the downloaded connector packages, keyed by the sha256
+// digest of the packages that is defined in connector-packaging.json.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSize is the default size cap of the cache, in bytes
+const DefaultMaxSize int64 = 4 << 30
+
+const entryExtension = ".tar.gz"
+
+var digestRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// DefaultDir returns the default folder of the cache, in the cache folder of the user
+func DefaultDir() string {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(userCacheDir, "ndc-hub", "connector-packages")
+}
+
+// Cache stores the connector packages in a folder, the least recently used packages are evicted once the
+// total size of the folder exceeds the size cap. The pinned packages are never evicted by the cache, they are still
+// used by the run, e.g. a package that is only uploaded once the whole publication plan is computed.
+type Cache struct {
+	dir     string
+	maxSize int64
+	mu      sync.Mutex
+	pinned  map[string]bool
+}
+
+// New returns the cache stored in dir, creating the folder if needed. A maxSize of 0 disables the size cap.
+func New(dir string, maxSize int64) (*Cache, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("the cache folder is not defined")
+	}
+	if maxSize < 0 {
+		return nil, fmt.Errorf("invalid cache size cap: %d", maxSize)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the cache folder: %w", err)
+	}
+	return &Cache{dir: dir, maxSize: maxSize, pinned: make(map[string]bool)}, nil
+}
+
+// Dir returns the folder of the cache
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func (c *Cache) entryPath(digest string) string {
+	return filepath.Join(c.dir, digest+entryExtension)
+}
+
+// Lookup returns the path of the cached package with the given sha256 digest. The content of the package is
+// verified, a corrupted package is removed from the cache and reported as missing.
+func (c *Cache) Lookup(digest string) (string, bool) {
+	digest = strings.ToLower(digest)
+	if !digestRegex.MatchString(digest) {
+		return "", false
+	}
+	path := c.entryPath(digest)
+
+	actual, err := fileSHA256(path)
+	if err != nil {
+		return "", false
+	}
+	if actual != digest {
+		os.Remove(path)
+		return "", false
+	}
+
+	// The modification time of the entries is their last access time, to evict the least recently used entries
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return path, true
+}
+
+// LookupPinned is Lookup, the package that is found is also pinned so that it is never evicted by the cache
+func (c *Cache) LookupPinned(digest string) (string, bool) {
+	path, ok := c.Lookup(digest)
+	if !ok {
+		return "", false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// The package may have been evicted by a concurrent store since it was looked up
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	c.pinned[path] = true
+	return path, true
+}
+
+// Store moves the package at path into the cache, under its sha256 digest, and returns its new path. The digest
+// must have been verified by the caller. The least recently used packages are evicted if the cache exceeds its size cap.
+func (c *Cache) Store(digest, path string) (string, error) {
+	return c.store(digest, path, false)
+}
+
+// StorePinned is Store, the stored package is also pinned so that it is never evicted by the cache
+func (c *Cache) StorePinned(digest, path string) (string, error) {
+	return c.store(digest, path, true)
+}
+
+func (c *Cache) store(digest, path string, pin bool) (string, error) {
+	digest = strings.ToLower(digest)
+	if !digestRegex.MatchString(digest) {
+		return "", fmt.Errorf("invalid sha256 digest: %q", digest)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entryPath := c.entryPath(digest)
+	if err := moveFile(path, entryPath); err != nil {
+		return "", fmt.Errorf("failed to store the package in the cache: %w", err)
+	}
+	if pin {
+		c.pinned[entryPath] = true
+	}
+
+	if c.maxSize > 0 {
+		if _, err := c.prune(c.maxSize, entryPath); err != nil {
+			return "", err
+		}
+	}
+	return entryPath, nil
+}
+
+// PruneResult summarizes the entries evicted from the cache
+type PruneResult struct {
+	Removed    int
+	FreedBytes int64
+}
+
+// Prune evicts the least recently used packages until the total size of the cache is at most maxSize.
+// A maxSize of 0 empties the cache, except for the pinned packages.
+func (c *Cache) Prune(maxSize int64) (PruneResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.prune(maxSize, "")
+}
+
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *Cache) prune(maxSize int64, keep string) (PruneResult, error) {
+	var result PruneResult
+
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return result, fmt.Errorf("failed to list the cache folder: %w", err)
+	}
+
+	var entries []entry
+	var totalSize int64
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), entryExtension) {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry{path: filepath.Join(c.dir, dirEntry.Name()), size: info.Size(), modTime: info.ModTime()})
+		totalSize += info.Size()
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+
+	for _, e := range entries {
+		if totalSize <= maxSize {
+			break
+		}
+		// The entry that was just stored is never evicted, even if it exceeds the size cap on its own, and neither
+		// are the pinned entries
+		if e.path == keep || c.pinned[e.path] {
+			continue
+		}
+		if err := os.Remove(e.path); err != nil {
+			return result, fmt.Errorf("failed to evict %s from the cache: %w", e.path, err)
+		}
+		totalSize -= e.size
+		result.Removed++
+		result.FreedBytes += e.size
+	}
+
+	return result, nil
+}
+
+// moveFile renames the file, and falls back to a copy if the file is on another filesystem. The file is written to a
+// temporary file first, so that a concurrent lookup never reads a partially written entry.
+func moveFile(src, dest string) error {
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	}
+
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(dest), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := io.Copy(tmpFile, srcFile); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), dest); err != nil {
+		return err
+	}
+	srcFile.Close()
+	return os.Remove(src)
+}
+
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writePackage(t *testing.T, content string) (string, string) {
+	path := filepath.Join(t.TempDir(), "package.tar.gz")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	digest := sha256.Sum256([]byte(content))
+	return path, hex.EncodeToString(digest[:])
+}
+
+func TestCacheLookupAndStore(t *testing.T) {
+	c, err := New(t.TempDir(), 0)
+	assert.NoError(t, err)
+
+	path, digest := writePackage(t, "connector package")
+	_, ok := c.Lookup(digest)
+	assert.False(t, ok)
+
+	cachedPath, err := c.Store(digest, path)
+	assert.NoError(t, err)
+	assert.NoFileExists(t, path)
+
+	lookupPath, ok := c.Lookup(digest)
+	assert.True(t, ok)
+	assert.Equal(t, cachedPath, lookupPath)
+
+	// A corrupted entry is evicted instead of being returned
+	assert.NoError(t, os.WriteFile(cachedPath, []byte("corrupted"), 0644))
+	_, ok = c.Lookup(digest)
+	assert.False(t, ok)
+	assert.NoFileExists(t, cachedPath)
+
+	_, err = c.Store("not-a-digest", path)
+	assert.Error(t, err)
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := New(t.TempDir(), 40)
+	assert.NoError(t, err)
+
+	var digests []string
+	for i, content := range []string{"package number 1", "package number 2"} {
+		path, digest := writePackage(t, content)
+		cachedPath, err := c.Store(digest, path)
+		assert.NoError(t, err)
+		// The entries are ordered by their last access time
+		accessTime := time.Now().Add(time.Duration(i-10) * time.Minute)
+		assert.NoError(t, os.Chtimes(cachedPath, accessTime, accessTime))
+		digests = append(digests, digest)
+	}
+
+	// The first package was used more recently than the second one
+	_, ok := c.Lookup(digests[0])
+	assert.True(t, ok)
+
+	path, digest := writePackage(t, "package 3")
+	_, err = c.Store(digest, path)
+	assert.NoError(t, err)
+
+	// Only the least recently used package is evicted to fit in the size cap
+	_, ok = c.Lookup(digests[1])
+	assert.False(t, ok)
+	_, ok = c.Lookup(digests[0])
+	assert.True(t, ok)
+	_, ok = c.Lookup(digest)
+	assert.True(t, ok)
+}
+
+func TestCachePrune(t *testing.T) {
+	c, err := New(t.TempDir(), 0)
+	assert.NoError(t, err)
+
+	for _, content := range []string{"package 1", "package 2"} {
+		path, digest := writePackage(t, content)
+		_, err := c.Store(digest, path)
+		assert.NoError(t, err)
+	}
+
+	result, err := c.Prune(0)
+	assert.NoError(t, err)
+	assert.Equal(t, PruneResult{Removed: 2, FreedBytes: 18}, result)
+}
+
+func TestCacheNeverEvictsPinnedEntries(t *testing.T) {
+	c, err := New(t.TempDir(), 20)
+	assert.NoError(t, err)
+
+	path, digest := writePackage(t, "package number 1")
+	pinnedPath, err := c.StorePinned(digest, path)
+	assert.NoError(t, err)
+
+	// The pinned package is kept even though the cache exceeds its size cap
+	path, otherDigest := writePackage(t, "package number 2")
+	_, err = c.Store(otherDigest, path)
+	assert.NoError(t, err)
+	assert.FileExists(t, pinnedPath)
+
+	result, err := c.Prune(0)
+	assert.NoError(t, err)
+	assert.Equal(t, PruneResult{Removed: 1, FreedBytes: 16}, result)
+	lookupPath, ok := c.LookupPinned(digest)
+	assert.True(t, ok)
+	assert.Equal(t, pinnedPath, lookupPath)
+}
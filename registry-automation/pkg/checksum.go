@@ -0,0 +1,43 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+)
+
+// SHA256ChecksumType is the checksum type of the connector packages in connector-packaging.json
+const SHA256ChecksumType = "sha256"
+
+// Checksum is the expected checksum of a downloaded file
+type Checksum struct {
+	Type  string
+	Value string
+}
+
+var checksumFuncs = map[string]func() hash.Hash{
+	SHA256ChecksumType: sha256.New,
+}
+
+// ChecksumMismatchError is returned when the checksum of a downloaded file doesn't match the expected checksum
+type ChecksumMismatchError struct {
+	URL      string
+	Type     string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s: %s checksum of the downloaded file: %s, but the expected checksum is: %s", e.URL, e.Type, e.Actual, e.Expected)
+}
+
+func newChecksumHash(checksum Checksum) (hash.Hash, error) {
+	if checksum.Value == "" {
+		return nil, fmt.Errorf("the checksum is not defined")
+	}
+	newHash, ok := checksumFuncs[checksum.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported checksum type: %s", checksum.Type)
+	}
+	return newHash(), nil
+}
@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadVerifiedFile(t *testing.T) {
+	content := []byte("connector package")
+	digest := sha256.Sum256(content)
+	expectedSHA256 := hex.EncodeToString(digest[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		checksum Checksum
+		wantErr  bool
+	}{
+		{"Matching checksum", Checksum{Type: SHA256ChecksumType, Value: expectedSHA256}, false},
+		{"Mismatching checksum", Checksum{Type: SHA256ChecksumType, Value: "0123456789abcdef"}, true},
+		{"Missing checksum", Checksum{Type: SHA256ChecksumType}, true},
+		{"Unsupported checksum type", Checksum{Type: "md5", Value: expectedSHA256}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "package.tar.gz")
+			actualSHA256, err := DownloadVerifiedFile(server.URL, destination, map[string]string{}, tc.checksum)
+			if tc.wantErr {
+				assert.Error(t, err)
+				// A package that failed the verification is never left behind
+				assert.NoFileExists(t, destination)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, expectedSHA256, actualSHA256)
+			downloaded, err := os.ReadFile(destination)
+			assert.NoError(t, err)
+			assert.Equal(t, content, downloaded)
+		})
+	}
+
+	_, err := DownloadVerifiedFile(server.URL, filepath.Join(t.TempDir(), "package.tar.gz"), map[string]string{}, Checksum{Type: SHA256ChecksumType, Value: "0123"})
+	var mismatchErr *ChecksumMismatchError
+	assert.ErrorAs(t, err, &mismatchErr)
+	assert.Equal(t, expectedSHA256, mismatchErr.Actual)
+}
@@ -0,0 +1,65 @@
+package ndchub
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// AliasedConnectorsFolder is the folder of a connector that holds its aliased connectors, e.g.
+// registry/hasura/postgres/aliased_connectors/neon/metadata.json
+const AliasedConnectorsFolder = "aliased_connectors"
+
+// AliasedConnector is a connector of the hub that is published with the releases of another connector, its parent,
+// under its own name, title, logo and README
+type AliasedConnector struct {
+	Namespace  string
+	Name       string
+	ParentName string
+	// Path is the path of the metadata.json file of the aliased connector
+	Path     string
+	Metadata *ConnectorMetadata
+}
+
+// Folder returns the folder of the aliased connector, with its metadata.json, logo and README
+func (ac *AliasedConnector) Folder() string {
+	return filepath.Dir(ac.Path)
+}
+
+// ParentMetadataPath returns the path of the metadata.json file of the parent connector
+func (ac *AliasedConnector) ParentMetadataPath() string {
+	return filepath.Join(filepath.Dir(filepath.Dir(ac.Folder())), MetadataJSON)
+}
+
+// IsAliasedConnectorPath reports whether the path is a file of an aliased connector
+func IsAliasedConnectorPath(path string) bool {
+	return strings.Contains(filepath.ToSlash(path), "/"+AliasedConnectorsFolder+"/")
+}
+
+// GetAliasedConnector reads the metadata.json file of an aliased connector
+func GetAliasedConnector(path string) (*AliasedConnector, error) {
+	// path looks like this: /some/folder/ndc-hub/registry/hasura/postgres/aliased_connectors/neon/metadata.json
+	aliasFolder := filepath.Dir(path)
+	aliasedConnectorsFolder := filepath.Dir(aliasFolder)
+	if filepath.Base(aliasedConnectorsFolder) != AliasedConnectorsFolder {
+		return nil, fmt.Errorf("%s is not the metadata.json file of an aliased connector", path)
+	}
+	parentFolder := filepath.Dir(aliasedConnectorsFolder)
+	namespaceFolder := filepath.Dir(parentFolder)
+
+	cm, err := readConnectorMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+	if cm.Overview.Name == "" {
+		cm.Overview.Name = filepath.Base(aliasFolder)
+	}
+
+	return &AliasedConnector{
+		Namespace:  filepath.Base(namespaceFolder),
+		Name:       cm.Overview.Name,
+		ParentName: filepath.Base(parentFolder),
+		Path:       path,
+		Metadata:   cm,
+	}, nil
+}
@@ -0,0 +1,36 @@
+package ndchub
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAliasedConnector(t *testing.T) {
+	registryFolder := t.TempDir()
+	parentFolder := filepath.Join(registryFolder, "hasura", "postgres")
+	aliasPath := filepath.Join(parentFolder, AliasedConnectorsFolder, "neon", MetadataJSON)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(aliasPath), 0755))
+	assert.NoError(t, os.WriteFile(aliasPath, []byte(`{"overview": {"namespace": "hasura", "title": "Neon PostgreSQL", "latest_version": "v3.1.0"}}`), 0644))
+
+	assert.True(t, IsAliasedConnectorPath(aliasPath))
+	assert.False(t, IsAliasedConnectorPath(filepath.Join(parentFolder, MetadataJSON)))
+
+	// The aliased connectors are skipped by GetConnectorMetadata
+	cm, err := GetConnectorMetadata(aliasPath)
+	assert.NoError(t, err)
+	assert.Nil(t, cm)
+
+	ac, err := GetAliasedConnector(aliasPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "hasura", ac.Namespace)
+	assert.Equal(t, "neon", ac.Name)
+	assert.Equal(t, "postgres", ac.ParentName)
+	assert.Equal(t, "Neon PostgreSQL", ac.Metadata.Overview.Title)
+	assert.Equal(t, filepath.Join(parentFolder, MetadataJSON), ac.ParentMetadataPath())
+
+	_, err = GetAliasedConnector(filepath.Join(parentFolder, MetadataJSON))
+	assert.ErrorContains(t, err, "is not the metadata.json file of an aliased connector")
+}
@@ -34,25 +34,38 @@ type ConnectorMetadata struct {
 		Name      string `json:"name"`
 	} `json:"hasura_hub_connector"`
 	SourceCode struct {
-		IsOpenSource bool   `json:"is_open_source"`
-		Repository   string `json:"repository"`
+		IsOpenSource bool                `json:"is_open_source"`
+		Repository   string              `json:"repository"`
+		Version      []SourceCodeVersion `json:"version,omitempty"`
 	} `json:"source_code"`
+	// Packages are the versions of the connectors that were added to the hub before connector-packaging.json
+	Packages []MetadataPackage `json:"packages,omitempty"`
+}
+
+// SourceCodeVersion is the git tag and commit of the source code of a version of the connector
+type SourceCodeVersion struct {
+	Tag        string `json:"tag"`
+	Hash       string `json:"hash"`
+	IsVerified bool   `json:"is_verified"`
+}
+
+// MetadataPackage is a version of the connector defined in metadata.json, with the fields of connector-packaging.json
+type MetadataPackage struct {
+	Version  string   `json:"version"`
+	URI      string   `json:"uri"`
+	Checksum Checksum `json:"checksum"`
+	Source   Source   `json:"source"`
 }
 
 func GetConnectorMetadata(path string) (*ConnectorMetadata, error) {
 	if strings.Contains(path, "aliased_connectors") {
 		// It should be safe to ignore aliased_connectors
-		// as their slug is not used in the connector init process
+		// as their slug is not used in the connector init process.
+		// They are read with GetAliasedConnector instead.
 		return nil, nil
 	}
 
-	connectorPackagingContent, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	var cm ConnectorMetadata
-	err = json.Unmarshal(connectorPackagingContent, &cm)
+	cm, err := readConnectorMetadata(path)
 	if err != nil {
 		return nil, err
 	}
@@ -63,5 +76,19 @@ func GetConnectorMetadata(path string) (*ConnectorMetadata, error) {
 		cm.Overview.Name = filepath.Base(connectorFolder)
 	}
 
+	return cm, nil
+}
+
+func readConnectorMetadata(path string) (*ConnectorMetadata, error) {
+	connectorMetadataContent, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cm ConnectorMetadata
+	err = json.Unmarshal(connectorMetadataContent, &cm)
+	if err != nil {
+		return nil, err
+	}
 	return &cm, nil
 }
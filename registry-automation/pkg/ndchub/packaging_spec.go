@@ -29,6 +29,14 @@ type ConnectorMetadataDefinition struct {
 	VersionStr string `json:"-" yaml:"-"`
 }
 
+// GetVersion returns the version of the packaging spec, the packaging specs without version are v1
+func (def *ConnectorMetadataDefinition) GetVersion() Version {
+	if def.Version == nil || *def.Version == "" {
+		return V1
+	}
+	return *def.Version
+}
+
 type PackagingType string
 
 const (
@@ -68,7 +76,7 @@ type PackagingDefinition struct {
 }
 
 func (p PackagingDefinition) GetDockerImage() string {
-	if p.Type == PrebuiltDockerImage {
+	if p.Type == PrebuiltDockerImage && p.DockerImage != nil {
 		return *p.DockerImage
 	}
 	return ""
@@ -132,6 +140,19 @@ type CliPluginDefinition struct {
 	Docker *DockerCliPluginDefinition
 }
 
+// Kind returns the kind of the CLI plugin: Docker, Binary for an external binary, or BinaryInline
+func (c *CliPluginDefinition) Kind() CliPluginType {
+	switch {
+	case c.Docker != nil:
+		return DockerPluginType
+	case c.Binary != nil && c.Binary.Inline != nil:
+		return BinaryInlinePluginType
+	case c.Binary != nil && c.Binary.External != nil:
+		return BinaryPluginType
+	}
+	return ""
+}
+
 func (c *CliPluginDefinition) GetDockerImage() string {
 	if c.Docker != nil {
 		return c.Docker.GetDockerImage()
@@ -159,6 +180,15 @@ const (
 	PlatformLinuxAmd64   PlatformSelector = "linux-amd64"
 )
 
+// PlatformSelectors are the platforms that the binary CLI plugins are provided for
+var PlatformSelectors = []PlatformSelector{
+	PlatformDarwinArm64,
+	PlatformLinuxArm64,
+	PlatformDarwinAmd64,
+	PlatformWindowsAmd64,
+	PlatformLinuxAmd64,
+}
+
 type BinaryCliPluginPlatform struct {
 	Selector PlatformSelector `json:"selector" yaml:"selector"`
 	URI      string           `json:"uri" yaml:"uri"`
@@ -351,16 +381,44 @@ func (x BinaryCliPluginDefinition) MarshalYAML() (interface{}, error) {
 	return nil, fmt.Errorf("marshaling BinaryCliPluginDefinition: no field found to marshal")
 }
 
+// Validate checks the packaging spec, every invalid field is reported in the returned ValidationErrors
 func (def *ConnectorMetadataDefinition) Validate() error {
+	var errs ValidationErrors
+
+	if def.Version != nil && *def.Version != V1 && *def.Version != V2 {
+		errs.add("version", "unsupported packaging spec version %q, expected %q or %q", *def.Version, V1, V2)
+	}
+	if def.NDCSpecGeneration != nil && *def.NDCSpecGeneration != "" && *def.NDCSpecGeneration != V01 && *def.NDCSpecGeneration != V02 {
+		errs.add("ndcSpecGeneration", "unsupported ndc spec generation %q, expected %q or %q", *def.NDCSpecGeneration, V01, V02)
+	}
+
+	switch def.PackagingDefinition.Type {
+	case PrebuiltDockerImage:
+		if def.PackagingDefinition.DockerImage == nil || *def.PackagingDefinition.DockerImage == "" {
+			errs.add("packagingDefinition.dockerImage", "is required for the %s packaging type", PrebuiltDockerImage)
+		}
+	case ManagedDockerBuild:
+	case "":
+		errs.add("packagingDefinition.type", "is required")
+	default:
+		errs.add("packagingDefinition.type", "unsupported packaging type %q, expected %q or %q", def.PackagingDefinition.Type, PrebuiltDockerImage, ManagedDockerBuild)
+	}
+
+	for i, envVar := range def.SupportedEnvironmentVariables {
+		if envVar.Name == "" {
+			errs.add(fmt.Sprintf("supportedEnvironmentVariables[%d].name", i), "is required")
+		}
+	}
+
 	if def.Version != nil && *def.Version == V2 {
 		// Must contain ndc spec generation
 		if def.NDCSpecGeneration == nil || *def.NDCSpecGeneration == "" {
-			return fmt.Errorf("packaging spec v2 must contain ndc spec generation")
+			errs.add("ndcSpecGeneration", "packaging spec v2 must contain ndc spec generation")
 		}
 		// If CLI plugin is specified, it must not be binary external
 		if def.CliPlugin != nil {
 			if def.CliPlugin.Binary != nil && def.CliPlugin.Binary.External != nil {
-				return fmt.Errorf("packaging spec v2 must not contain binary external cli plugin. " +
+				errs.add("cliPlugin", "packaging spec v2 must not contain binary external cli plugin. " +
 					"Can only be binary inline or docker")
 			}
 		}
@@ -369,12 +427,12 @@ func (def *ConnectorMetadataDefinition) Validate() error {
 		// If CLI plugin is specified, it must not be binary inline
 		if def.CliPlugin != nil {
 			if def.CliPlugin.Binary != nil && def.CliPlugin.Binary.Inline != nil {
-				return fmt.Errorf("packaging spec v1 must not contain binary inline cli plugin. " +
+				errs.add("cliPlugin", "packaging spec v1 must not contain binary inline cli plugin. " +
 					"Can only be binary external or docker")
 			}
 		}
 	}
-	return nil
+	return errs.errOrNil()
 }
 
 
@@ -404,7 +462,9 @@ func (def *ConnectorMetadataDefinition) GetArtifacts(artifactsPath string) (*Con
 	err := DownloadPluginBinaries(artifactsDirPath, WithConnectorMetadata(def))
 
 	return &ConnectorArtifacts{
-		DockerImages:  dockerImages,
+		Connector:        def.Namespace + "/" + def.Name,
+		Version:          def.VersionStr,
+		DockerImages:     dockerImages,
 		ArtifactsDirPath: artifactsDirPath,
 	}, err
 }
@@ -451,8 +511,8 @@ func (def *ConnectorMetadataDefinition) GetDockerImages() []string {
 	return dockerImgArr
 }
 
-func GetPackagingSpec(uri, namespace, name, version string) (connectorMetadataDefinition *ConnectorMetadataDefinition, tgzPath string, extractedTgzPath string, err error) {
-	def, tgzPath, extractedTgzPath, err := pkg.GetConnectorVersionMetadata(uri, namespace, name, version)
+func GetPackagingSpec(uri string, checksum Checksum, namespace, name, version string) (connectorMetadataDefinition *ConnectorMetadataDefinition, tgzPath string, extractedTgzPath string, err error) {
+	def, tgzPath, extractedTgzPath, err := pkg.GetConnectorVersionMetadata(uri, pkg.Checksum{Type: checksum.Type, Value: checksum.Value}, namespace, name, version)
 	if err != nil {
 		return nil, "", "", err
 	}
@@ -473,7 +533,33 @@ func GetPackagingSpec(uri, namespace, name, version string) (connectorMetadataDe
 	return &spec, tgzPath, extractedTgzPath, nil
 }
 
+// DownloadPackagingSpec downloads the connector package, verifies it against the checksum and decodes its packaging
+// spec without extracting the rest of the package. The sha256 digest of the package is returned along with its path.
+func DownloadPackagingSpec(uri string, checksum Checksum, namespace, name, version string) (connectorMetadataDefinition *ConnectorMetadataDefinition, tgzPath string, tgzSHA256 string, err error) {
+	tgzPath, tgzSHA256, err = pkg.DownloadConnectorPackage(uri, pkg.Checksum{Type: checksum.Type, Value: checksum.Value})
+	if err != nil {
+		return nil, "", "", err
+	}
+	specBytes, err := pkg.ReadFileFromTarGz(tgzPath, pkg.ConnectorMetadataPath, pkg.DefaultExtractLimits)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to read connector-metadata.yaml: %w", err)
+	}
+	var spec ConnectorMetadataDefinition
+	if err := yaml.Unmarshal(specBytes, &spec); err != nil {
+		return nil, "", "", fmt.Errorf("failed to unmarshal connector-metadata.yaml: %w", err)
+	}
+
+	spec.Namespace = namespace
+	spec.Name = name
+	spec.VersionStr = version
+
+	return &spec, tgzPath, tgzSHA256, nil
+}
+
 type ConnectorArtifacts struct {
-	DockerImages []string
+	// Connector is the namespace/name of the connector, and Version the release of the artifacts
+	Connector        string
+	Version          string
+	DockerImages     []string
 	ArtifactsDirPath string
 }
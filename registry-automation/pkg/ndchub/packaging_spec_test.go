@@ -0,0 +1,52 @@
+package ndchub
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConnectorMetadataDefinitionValidate(t *testing.T) {
+	testCases := []struct {
+		name       string
+		spec       string
+		wantFields []string
+	}{
+		{"Valid prebuilt docker image", "packagingDefinition:\n  type: PrebuiltDockerImage\n  dockerImage: ghcr.io/hasura/ndc-test:v1.0.0\n", nil},
+		{"Valid managed docker build", "packagingDefinition:\n  type: ManagedDockerBuild\n", nil},
+		{"Missing docker image", "packagingDefinition:\n  type: PrebuiltDockerImage\n", []string{"packagingDefinition.dockerImage"}},
+		{"Missing packaging type", "packagingDefinition: {}\n", []string{"packagingDefinition.type"}},
+		{"Unsupported version", "version: v3\npackagingDefinition:\n  type: ManagedDockerBuild\n", []string{"version"}},
+		{"Missing environment variable name", "packagingDefinition:\n  type: ManagedDockerBuild\nsupportedEnvironmentVariables:\n  - description: missing name\n",
+			[]string{"supportedEnvironmentVariables[0].name"}},
+		{"v2 without ndc spec generation", "version: v2\npackagingDefinition:\n  type: ManagedDockerBuild\n", []string{"ndcSpecGeneration"}},
+		{"Every invalid field is reported", "version: v2\npackagingDefinition:\n  type: PrebuiltDockerImage\n", []string{"packagingDefinition.dockerImage", "ndcSpecGeneration"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var spec ConnectorMetadataDefinition
+			assert.NoError(t, yaml.Unmarshal([]byte(tc.spec), &spec))
+
+			err := spec.Validate()
+			if tc.wantFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErrs ValidationErrors
+			assert.True(t, errors.As(err, &validationErrs))
+			fields := make([]string, 0, len(validationErrs))
+			for _, fieldErr := range validationErrs {
+				fields = append(fields, fieldErr.Field)
+			}
+			assert.Equal(t, tc.wantFields, fields)
+		})
+	}
+}
+
+func TestPackagingDefinitionGetDockerImage(t *testing.T) {
+	// A prebuilt docker image without docker image is reported by Validate instead of panicking
+	assert.Equal(t, "", PackagingDefinition{Type: PrebuiltDockerImage}.GetDockerImage())
+}
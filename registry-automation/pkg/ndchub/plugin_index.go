@@ -0,0 +1,231 @@
+package ndchub
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrPluginManifestNotFound is returned when the CLI plugins index has no manifest for the version of a CLI plugin
+var ErrPluginManifestNotFound = errors.New("plugin manifest not found")
+
+// PluginIndex resolves the manifests of the CLI plugins, laid out like the CLI plugins index: the manifest of a
+// version of a plugin is at plugins/<name>/<version>/manifest.yaml
+type PluginIndex interface {
+	// Manifest returns the manifest of the version of the CLI plugin, the error wraps ErrPluginManifestNotFound if
+	// the index has no such manifest
+	Manifest(name, version string) (*PluginManifest, error)
+	// String describes the index in the logs
+	String() string
+}
+
+// DefaultPluginIndexURL is the URL of the master branch of the CLI plugins index
+const DefaultPluginIndexURL = "https://raw.githubusercontent.com/hasura/cli-plugins-index/refs/heads/master"
+
+const githubRawURL = "https://raw.githubusercontent.com"
+
+// pluginManifestPath returns the path of the manifest of a version of a CLI plugin, relative to the root of the index
+func pluginManifestPath(name, version string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("error downloading plugins manifest: name cannot be empty")
+	}
+	if version == "" {
+		return "", fmt.Errorf("error downloading plugins manifest: version cannot be empty")
+	}
+	// The name and the version come from the packaging specs, they must not escape their folder of the index
+	for _, segment := range []string{name, version} {
+		if segment == "." || segment == ".." || strings.ContainsAny(segment, `/\`) {
+			return "", fmt.Errorf("error downloading plugins manifest: invalid name %q or version %q", name, version)
+		}
+	}
+	return path.Join("plugins", name, version, "manifest.yaml"), nil
+}
+
+func decodePluginManifest(r io.Reader) (*PluginManifest, error) {
+	var manifest PluginManifest
+	if err := yaml.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %v", err)
+	}
+	return &manifest, nil
+}
+
+// HTTPPluginIndex downloads the manifests from a CLI plugins index served over HTTP, e.g. the raw files of a branch
+// of a fork of the index
+type HTTPPluginIndex struct {
+	// BaseURL is the URL of the root of the index
+	BaseURL string
+	// Client downloads the manifests, http.DefaultClient if nil
+	Client *http.Client
+}
+
+// NewHTTPPluginIndex returns the index served at baseURL
+func NewHTTPPluginIndex(baseURL string) *HTTPPluginIndex {
+	return &HTTPPluginIndex{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (i *HTTPPluginIndex) String() string {
+	return i.BaseURL
+}
+
+func (i *HTTPPluginIndex) Manifest(name, version string) (*PluginManifest, error) {
+	manifestPath, err := pluginManifestPath(name, version)
+	if err != nil {
+		return nil, err
+	}
+	client := i.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	manifestURL := strings.TrimSuffix(i.BaseURL, "/") + "/" + manifestPath
+	log.Printf("Downloading manifest from %s", manifestURL)
+	resp, err := client.Get(manifestURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download manifest from %s: %v", manifestURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("failed to download manifest from %s: %w", manifestURL, ErrPluginManifestNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download manifest: %s", resp.Status)
+	}
+	return decodePluginManifest(resp.Body)
+}
+
+var commitRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// GitPluginIndex downloads the manifests from a commit of a GitHub repository of the CLI plugins index, so that the
+// manifests don't change when the branches of the repository move
+type GitPluginIndex struct {
+	// Repository is the owner/name of the GitHub repository, e.g. hasura/cli-plugins-index
+	Repository string
+	// Commit is the full hash of the commit
+	Commit string
+	// RawBaseURL serves the raw files of the GitHub repositories, https://raw.githubusercontent.com if empty
+	RawBaseURL string
+	// Client downloads the manifests, http.DefaultClient if nil
+	Client *http.Client
+}
+
+// NewGitPluginIndex returns the index of the GitHub repository at the commit, the commit must be a full hash
+func NewGitPluginIndex(repository, commit string) (*GitPluginIndex, error) {
+	if owner, name, ok := strings.Cut(repository, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid repository %q, expected owner/name", repository)
+	}
+	if !commitRegex.MatchString(commit) {
+		return nil, fmt.Errorf("invalid commit %q, expected a full commit hash", commit)
+	}
+	return &GitPluginIndex{Repository: repository, Commit: commit}, nil
+}
+
+func (i *GitPluginIndex) String() string {
+	return i.Repository + "@" + i.Commit
+}
+
+func (i *GitPluginIndex) Manifest(name, version string) (*PluginManifest, error) {
+	rawBaseURL := i.RawBaseURL
+	if rawBaseURL == "" {
+		rawBaseURL = githubRawURL
+	}
+	index := &HTTPPluginIndex{BaseURL: strings.TrimSuffix(rawBaseURL, "/") + "/" + i.Repository + "/" + i.Commit, Client: i.Client}
+	return index.Manifest(name, version)
+}
+
+// LocalPluginIndex reads the manifests from a local checkout of the CLI plugins index, so that the manifests are
+// resolved offline
+type LocalPluginIndex struct {
+	// Dir is the root folder of the checkout
+	Dir string
+}
+
+// NewLocalPluginIndex returns the index of the checkout at dir, which must have a plugins folder
+func NewLocalPluginIndex(dir string) (*LocalPluginIndex, error) {
+	info, err := os.Stat(filepath.Join(dir, "plugins"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CLI plugins index %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("invalid CLI plugins index %s: plugins is not a folder", dir)
+	}
+	return &LocalPluginIndex{Dir: dir}, nil
+}
+
+func (i *LocalPluginIndex) String() string {
+	return i.Dir
+}
+
+func (i *LocalPluginIndex) Manifest(name, version string) (*PluginManifest, error) {
+	manifestPath, err := pluginManifestPath(name, version)
+	if err != nil {
+		return nil, err
+	}
+	manifestFilePath := filepath.Join(i.Dir, filepath.FromSlash(manifestPath))
+	log.Printf("Reading manifest from %s", manifestFilePath)
+	file, err := os.Open(manifestFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", manifestFilePath, ErrPluginManifestNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %v", manifestFilePath, err)
+	}
+	defer file.Close()
+	return decodePluginManifest(file)
+}
+
+// ParsePluginIndex returns the index of a source:
+//   - an http:// or https:// URL of the root of an index, e.g. the raw files of a branch of a fork
+//   - github:owner/name@commit for a commit of a GitHub repository of the index
+//   - the folder of a local checkout of the index, optionally prefixed with file:
+//
+// An empty source is the master branch of the CLI plugins index.
+func ParsePluginIndex(source string) (PluginIndex, error) {
+	switch {
+	case source == "":
+		return NewHTTPPluginIndex(DefaultPluginIndexURL), nil
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+		return NewHTTPPluginIndex(source), nil
+	case strings.HasPrefix(source, "github:"):
+		repository, commit, ok := strings.Cut(strings.TrimPrefix(source, "github:"), "@")
+		if !ok {
+			return nil, fmt.Errorf("invalid CLI plugins index %q, expected github:owner/name@commit", source)
+		}
+		return NewGitPluginIndex(repository, commit)
+	default:
+		return NewLocalPluginIndex(strings.TrimPrefix(source, "file:"))
+	}
+}
+
+var (
+	pluginIndexMu sync.RWMutex
+	pluginIndex   PluginIndex
+)
+
+// SetPluginIndex sets the index that the manifests of the CLI plugins are resolved from. A nil index resets it to
+// the master branch of the CLI plugins index.
+func SetPluginIndex(index PluginIndex) {
+	pluginIndexMu.Lock()
+	defer pluginIndexMu.Unlock()
+	pluginIndex = index
+}
+
+func getPluginIndex() PluginIndex {
+	pluginIndexMu.RLock()
+	defer pluginIndexMu.RUnlock()
+	if pluginIndex == nil {
+		return NewHTTPPluginIndex(DefaultPluginIndexURL)
+	}
+	return pluginIndex
+}
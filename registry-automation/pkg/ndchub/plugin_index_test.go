@@ -0,0 +1,114 @@
+package ndchub
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testManifestYAML = "name: ndc-test\nversion: v1.0.0\nplatforms:\n  - selector: linux-amd64\n    uri: https://example.com/ndc-test-cli\n    sha256: abc\n    bin: hasura-ndc-test\n"
+
+func writeLocalPluginIndex(t *testing.T) string {
+	dir := t.TempDir()
+	manifestDir := filepath.Join(dir, "plugins", "ndc-test", "v1.0.0")
+	assert.NoError(t, os.MkdirAll(manifestDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(manifestDir, "manifest.yaml"), []byte(testManifestYAML), 0644))
+	return dir
+}
+
+func TestPluginIndexes(t *testing.T) {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hasura/cli-plugins-index/master/plugins/ndc-test/v1.0.0/manifest.yaml",
+			"/hasura/cli-plugins-index/" + commit + "/plugins/ndc-test/v1.0.0/manifest.yaml":
+			w.Write([]byte(testManifestYAML))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	gitIndex, err := NewGitPluginIndex("hasura/cli-plugins-index", commit)
+	assert.NoError(t, err)
+	gitIndex.RawBaseURL = server.URL
+	localIndex, err := NewLocalPluginIndex(writeLocalPluginIndex(t))
+	assert.NoError(t, err)
+
+	indexes := map[string]PluginIndex{
+		"HTTP":  NewHTTPPluginIndex(server.URL + "/hasura/cli-plugins-index/master/"),
+		"Git":   gitIndex,
+		"Local": localIndex,
+	}
+	for name, index := range indexes {
+		t.Run(name, func(t *testing.T) {
+			manifest, err := index.Manifest("ndc-test", "v1.0.0")
+			assert.NoError(t, err)
+			assert.Equal(t, "ndc-test", manifest.Name)
+			assert.Equal(t, "v1.0.0", manifest.Version)
+			assert.Len(t, manifest.Platforms, 1)
+
+			_, err = index.Manifest("ndc-test", "v2.0.0")
+			assert.ErrorIs(t, err, ErrPluginManifestNotFound)
+
+			// The name and the version can't escape their folder of the index
+			_, err = index.Manifest("../ndc-test", "v1.0.0")
+			assert.ErrorContains(t, err, "invalid name")
+		})
+	}
+}
+
+func TestParsePluginIndex(t *testing.T) {
+	localDir := writeLocalPluginIndex(t)
+
+	testCases := []struct {
+		name    string
+		source  string
+		want    string
+		wantErr string
+	}{
+		{"Default", "", DefaultPluginIndexURL, ""},
+		{"HTTP", "https://raw.githubusercontent.com/acme/cli-plugins-index/refs/heads/main/", "https://raw.githubusercontent.com/acme/cli-plugins-index/refs/heads/main", ""},
+		{"Git", "github:acme/cli-plugins-index@0123456789abcdef0123456789abcdef01234567", "acme/cli-plugins-index@0123456789abcdef0123456789abcdef01234567", ""},
+		{"Git without commit", "github:acme/cli-plugins-index", "", "expected github:owner/name@commit"},
+		{"Git with a branch", "github:acme/cli-plugins-index@main", "", "expected a full commit hash"},
+		{"Git with an invalid repository", "github:cli-plugins-index@0123456789abcdef0123456789abcdef01234567", "", "expected owner/name"},
+		{"Local", localDir, localDir, ""},
+		{"Local with file prefix", "file:" + localDir, localDir, ""},
+		{"Local without plugins folder", t.TempDir(), "", "invalid CLI plugins index"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			index, err := ParsePluginIndex(tc.source)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, index.String())
+		})
+	}
+}
+
+func TestDownloadPluginsManifestWithPluginIndex(t *testing.T) {
+	localIndex, err := NewLocalPluginIndex(writeLocalPluginIndex(t))
+	assert.NoError(t, err)
+	external := &BinaryExternalCliPluginDefinition{Name: "ndc-test", Version: "v1.0.0"}
+	spec := &ConnectorMetadataDefinition{CliPlugin: &CliPluginDefinition{Binary: &BinaryCliPluginDefinition{External: external}}}
+
+	// The index of the options takes precedence over the index set with SetPluginIndex
+	manifest, err := DownloadPluginsManifest(WithConnectorMetadata(spec), WithPluginIndex(localIndex))
+	assert.NoError(t, err)
+	assert.Equal(t, "ndc-test", manifest.Name)
+
+	SetPluginIndex(localIndex)
+	t.Cleanup(func() { SetPluginIndex(nil) })
+	manifest, err = DownloadPluginsManifest(WithNameAndVersion("ndc-test", "v1.0.0"))
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.0", manifest.Version)
+}
@@ -3,11 +3,10 @@ package ndchub
 import (
 	"fmt"
 	"log"
-	"net/http"
 	"path/filepath"
+	"slices"
 
 	"github.com/hasura/ndc-hub/registry-automation/pkg"
-	"gopkg.in/yaml.v3"
 )
 
 type PluginManifest struct {
@@ -32,25 +31,84 @@ type FilePair struct {
 	To   string `json:"to" yaml:"to"`
 }
 
+// Validate checks the manifest of the CLI plugins index against the external CLI plugin of a packaging spec, every
+// invalid field is reported in the returned ValidationErrors. The manifest must provide a binary for every platform
+// of PlatformSelectors.
+func (m *PluginManifest) Validate(external *BinaryExternalCliPluginDefinition) error {
+	var errs ValidationErrors
+
+	if m.Name != external.Name {
+		errs.add("name", "%q does not match the name %q of the cli plugin of the packaging spec", m.Name, external.Name)
+	}
+	if m.Version != external.Version {
+		errs.add("version", "%q does not match the version %q of the cli plugin of the packaging spec", m.Version, external.Version)
+	}
+
+	selectors := make(map[PlatformSelector]bool)
+	for i, platform := range m.Platforms {
+		field := fmt.Sprintf("platforms[%d]", i)
+		selector := PlatformSelector(platform.Selector)
+		switch {
+		case !slices.Contains(PlatformSelectors, selector):
+			errs.add(field+".selector", "unsupported platform %q", platform.Selector)
+		case selectors[selector]:
+			errs.add(field+".selector", "duplicate platform %q", platform.Selector)
+		}
+		selectors[selector] = true
+		if platform.URI == "" {
+			errs.add(field+".uri", "is required")
+		}
+		if platform.SHA256 == "" {
+			errs.add(field+".sha256", "is required")
+		}
+		if platform.Bin == "" {
+			errs.add(field+".bin", "is required")
+		}
+		for j, file := range platform.Files {
+			if file.From == "" {
+				errs.add(fmt.Sprintf("%s.files[%d].from", field, j), "is required")
+			}
+			if file.To == "" {
+				errs.add(fmt.Sprintf("%s.files[%d].to", field, j), "is required")
+			}
+		}
+	}
+	for _, selector := range PlatformSelectors {
+		if !selectors[selector] {
+			errs.add("platforms", "no binary for the platform %q", selector)
+		}
+	}
+	return errs.errOrNil()
+}
+
 type ManifestDownloadOptions struct {
-    Name           string
-    Version             string
-    ConnectorMetadata   *ConnectorMetadataDefinition
+	Name              string
+	Version           string
+	ConnectorMetadata *ConnectorMetadataDefinition
+	// Index resolves the manifest, the index set with SetPluginIndex if nil
+	Index PluginIndex
 }
 
 type ManifestOption func(*ManifestDownloadOptions)
 
 func WithNameAndVersion(name, version string) ManifestOption {
-    return func(opt *ManifestDownloadOptions) {
-        opt.Name = name
-        opt.Version = version
-    }
+	return func(opt *ManifestDownloadOptions) {
+		opt.Name = name
+		opt.Version = version
+	}
 }
 
 func WithConnectorMetadata(md *ConnectorMetadataDefinition) ManifestOption {
-    return func(opt *ManifestDownloadOptions) {
-        opt.ConnectorMetadata = md
-    }
+	return func(opt *ManifestDownloadOptions) {
+		opt.ConnectorMetadata = md
+	}
+}
+
+// WithPluginIndex resolves the manifest from the index instead of the index set with SetPluginIndex
+func WithPluginIndex(index PluginIndex) ManifestOption {
+	return func(opt *ManifestDownloadOptions) {
+		opt.Index = index
+	}
 }
 
 func DownloadPluginBinaries(artifactsDirPath string, opts ...ManifestOption) error {
@@ -67,7 +125,8 @@ func DownloadPluginBinaries(artifactsDirPath string, opts ...ManifestOption) err
 		fileName := fmt.Sprintf("%s-%s", platform.Selector, platform.Bin)
 		filePath := filepath.Join(artifactsDirPath, fileName)
 
-		if err := pkg.DownloadFile(platform.URI, filePath, map[string]string{"sha256": platform.SHA256}); err != nil {
+		checksum := pkg.Checksum{Type: pkg.SHA256ChecksumType, Value: platform.SHA256}
+		if _, err := pkg.DownloadVerifiedFile(platform.URI, filePath, map[string]string{}, checksum); err != nil {
 			return fmt.Errorf("failed to download plugin binary for %s: %w", platform.Selector, err)
 		}
 	}
@@ -75,26 +134,31 @@ func DownloadPluginBinaries(artifactsDirPath string, opts ...ManifestOption) err
 }
 
 func DownloadPluginsManifest(opts ...ManifestOption) (*PluginManifest, error) {
-    var options ManifestDownloadOptions
+	var options ManifestDownloadOptions
 
-    // Apply options
-    for _, opt := range opts {
-        opt(&options)
-    }
+	// Apply options
+	for _, opt := range opts {
+		opt(&options)
+	}
 
-    switch {
-    case options.ConnectorMetadata != nil:
-        return downloadPluginsManifestWithConnectorMetadata(options.ConnectorMetadata)
+	index := options.Index
+	if index == nil {
+		index = getPluginIndex()
+	}
 
-    case options.Name != "" && options.Version != "":
-        return downloadPluginsManifestWithNameAndVersion(options.Name, options.Version)
+	switch {
+	case options.ConnectorMetadata != nil:
+		return downloadPluginsManifestWithConnectorMetadata(index, options.ConnectorMetadata)
 
-    default:
-        return nil, fmt.Errorf("insufficient parameters provided to DownloadPluginsManifest")
-    }
+	case options.Name != "" && options.Version != "":
+		return index.Manifest(options.Name, options.Version)
+
+	default:
+		return nil, fmt.Errorf("insufficient parameters provided to DownloadPluginsManifest")
+	}
 }
 
-func downloadPluginsManifestWithConnectorMetadata(md *ConnectorMetadataDefinition) (*PluginManifest, error) {
+func downloadPluginsManifestWithConnectorMetadata(index PluginIndex, md *ConnectorMetadataDefinition) (*PluginManifest, error) {
 	if md == nil {
 		return nil, fmt.Errorf("error downloading plugins manifest: connector metadata cannot be nil")
 	}
@@ -113,37 +177,5 @@ func downloadPluginsManifestWithConnectorMetadata(md *ConnectorMetadataDefinitio
 	if md.CliPlugin.Binary == nil || md.CliPlugin.Binary.External == nil {
 		return nil, fmt.Errorf("error downloading plugins manifest: no binary external plugin definition found for %s/%s:%s", md.Namespace, md.Name, md.VersionStr)
 	}
-	return downloadPluginsManifestWithNameAndVersion(md.CliPlugin.Binary.External.Name, md.CliPlugin.Binary.External.Version)
-}
-
-func downloadPluginsManifestWithNameAndVersion(name, version string) (*PluginManifest, error) {
-	if name == "" {
-		return nil, fmt.Errorf("error downloading plugins manifest: name cannot be empty")
-	}
-	if version == "" {
-		return nil, fmt.Errorf("error downloading plugins manifest: version cannot be empty")
-	}
-
-	manifestURL := getManifestUrl(name, version)
-	log.Printf("Downloading manifest from %s", manifestURL)
-	resp, err := http.Get(manifestURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to download manifest from %s: %v", manifestURL, err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to download manifest: %s", resp.Status)
-	}
-
-	var manifest PluginManifest
-	if err := yaml.NewDecoder(resp.Body).Decode(&manifest); err != nil {
-		return nil, fmt.Errorf("failed to decode manifest: %v", err)
-	}
-
-	return &manifest, nil
+	return index.Manifest(md.CliPlugin.Binary.External.Name, md.CliPlugin.Binary.External.Version)
 }
-
-func getManifestUrl(name, version string) string {
-	return fmt.Sprintf("https://raw.githubusercontent.com/hasura/cli-plugins-index/refs/heads/master/plugins/%s/%s/manifest.yaml", name, version)
-}
\ No newline at end of file
@@ -0,0 +1,71 @@
+package ndchub
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPluginManifest() PluginManifest {
+	manifest := PluginManifest{Name: "ndc-test", Version: "v1.0.0"}
+	for _, selector := range PlatformSelectors {
+		manifest.Platforms = append(manifest.Platforms, Platform{
+			Selector: string(selector),
+			URI:      "https://example.com/ndc-test-" + string(selector),
+			SHA256:   "8e3e8e0bf7ad7bf6de21ea2d1d0d9ff0e4d3a2a8d8cd4c9e1e0e7b4bb9b4a1f2",
+			Bin:      "hasura-ndc-test",
+			Files:    []FilePair{{From: "./ndc-test-" + string(selector), To: "hasura-ndc-test"}},
+		})
+	}
+	return manifest
+}
+
+func TestPluginManifestValidate(t *testing.T) {
+	external := &BinaryExternalCliPluginDefinition{Name: "ndc-test", Version: "v1.0.0"}
+
+	testCases := []struct {
+		name       string
+		update     func(manifest *PluginManifest)
+		wantFields []string
+	}{
+		{"Valid manifest", func(manifest *PluginManifest) {}, nil},
+		{"Name and version mismatch", func(manifest *PluginManifest) {
+			manifest.Name = "ndc-other"
+			manifest.Version = "v1.0.1"
+		}, []string{"name", "version"}},
+		{"Missing platform", func(manifest *PluginManifest) {
+			manifest.Platforms = manifest.Platforms[1:]
+		}, []string{"platforms"}},
+		{"Unsupported and duplicate platforms", func(manifest *PluginManifest) {
+			manifest.Platforms[0].Selector = "freebsd-amd64"
+			manifest.Platforms[2].Selector = string(PlatformLinuxArm64)
+		}, []string{"platforms[0].selector", "platforms[2].selector", "platforms", "platforms"}},
+		{"Missing fields", func(manifest *PluginManifest) {
+			manifest.Platforms[0].URI = ""
+			manifest.Platforms[0].SHA256 = ""
+			manifest.Platforms[0].Bin = ""
+			manifest.Platforms[0].Files = []FilePair{{}}
+		}, []string{"platforms[0].uri", "platforms[0].sha256", "platforms[0].bin", "platforms[0].files[0].from", "platforms[0].files[0].to"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifest := testPluginManifest()
+			tc.update(&manifest)
+
+			err := manifest.Validate(external)
+			if tc.wantFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErrs ValidationErrors
+			assert.True(t, errors.As(err, &validationErrs))
+			fields := make([]string, 0, len(validationErrs))
+			for _, fieldErr := range validationErrs {
+				fields = append(fields, fieldErr.Field)
+			}
+			assert.Equal(t, tc.wantFields, fields)
+		})
+	}
+}
@@ -14,6 +14,14 @@ type TestConfig struct {
 	SetupComposeFilePath *string  `json:"setup_compose_file_path,omitempty"`
 	RunCloudTests        *bool    `json:"run_cloud_tests,omitempty"`
 	SnapshotsDir         string   `json:"snapshots_dir"`
+	// DDNWorkspace configures the tests of the connector in the DDN workspace image
+	DDNWorkspace *DDNWorkspaceTestConfig `json:"ddn_workspace,omitempty"`
+}
+
+type DDNWorkspaceTestConfig struct {
+	Enabled              bool     `json:"enabled"`
+	Envs                 []string `json:"envs,omitempty"`
+	SetupComposeFilePath *string  `json:"setup_compose_file_path,omitempty"`
 }
 
 func GetTestConfig(path string) (*TestConfig, error) {
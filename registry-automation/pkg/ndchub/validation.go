@@ -0,0 +1,49 @@
+package ndchub
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError is a validation error of a single field of a hub file. Field is the path of the field in the
+// file, e.g. "packagingDefinition.dockerImage"
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors reports every invalid field of a hub file
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Error())
+	}
+	return fmt.Sprintf("%d invalid field(s): %s", len(e), strings.Join(messages, "; "))
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, fieldErr := range e {
+		errs = append(errs, fieldErr)
+	}
+	return errs
+}
+
+// add records an invalid field
+func (e *ValidationErrors) add(field, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// errOrNil returns nil if no field is invalid, so that the result can be returned as an error
+func (e ValidationErrors) errOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,196 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Platform is the platform that an image runs on, e.g. linux/amd64 or linux/arm64/v8
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// String returns the platform as os/architecture, followed by /variant if it has one
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+var (
+	LinuxAmd64 = Platform{OS: "linux", Architecture: "amd64"}
+	LinuxArm64 = Platform{OS: "linux", Architecture: "arm64"}
+)
+
+// RequiredPlatforms are the platforms that the docker images of the hub must be available for, the connectors are
+// run on both
+var RequiredPlatforms = []Platform{LinuxAmd64, LinuxArm64}
+
+// Manifest is the manifest that a reference points to, with the platforms of its images
+type Manifest struct {
+	// MediaType is the media type of the manifest, an index for the multi-platform images
+	MediaType string
+	// Digest is the digest of the manifest
+	Digest string
+	// Platforms are the platforms of the images of an index, or the platform of the image of a single manifest. The
+	// attestations of the index, with the unknown platform, are skipped.
+	Platforms []Platform
+}
+
+// Index returns true if the manifest is a multi-platform index, an OCI image index or a Docker manifest list
+func (m Manifest) Index() bool {
+	return m.MediaType == manifestMediaTypes[0] || m.MediaType == manifestMediaTypes[1]
+}
+
+// MissingPlatforms returns the platforms that the manifest has no image for. The variant of a platform is only
+// compared when the required platform has one, e.g. linux/arm64/v8 is a linux/arm64 image.
+func (m Manifest) MissingPlatforms(platforms []Platform) []Platform {
+	var missing []Platform
+	for _, platform := range platforms {
+		found := false
+		for _, p := range m.Platforms {
+			if p.OS == platform.OS && p.Architecture == platform.Architecture && (platform.Variant == "" || p.Variant == platform.Variant) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, platform)
+		}
+	}
+	return missing
+}
+
+// MissingPlatformsError is returned when the manifest of a reference has no image for some of the required platforms
+type MissingPlatformsError struct {
+	Reference Reference
+	Missing   []Platform
+	Required  []Platform
+}
+
+func (e *MissingPlatformsError) Error() string {
+	return fmt.Sprintf("%s has no image for %s, it must be available for %s", e.Reference, joinPlatforms(e.Missing), joinPlatforms(e.Required))
+}
+
+// CheckPlatforms returns a *MissingPlatformsError if the manifest of the reference has no image for some of the
+// platforms
+func (m Manifest) CheckPlatforms(ref Reference, platforms []Platform) error {
+	if missing := m.MissingPlatforms(platforms); len(missing) > 0 {
+		return &MissingPlatformsError{Reference: ref, Missing: missing, Required: platforms}
+	}
+	return nil
+}
+
+func joinPlatforms(platforms []Platform) string {
+	names := make([]string, len(platforms))
+	for i, platform := range platforms {
+		names[i] = platform.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// manifestContent is the content of an image index or of an image manifest
+type manifestContent struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	MediaType     string `json:"mediaType"`
+	// Manifests are the manifests of the images of an index
+	Manifests []struct {
+		Platform *Platform `json:"platform"`
+	} `json:"manifests"`
+	// Config is the config blob of an image manifest, which has the platform of the image
+	Config *struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+}
+
+// Inspect fetches the manifest that the reference points to, along with the platforms of its images. Like Resolve,
+// the digest of a pinned reference is checked against the registry.
+func (r *Resolver) Inspect(ref Reference) (Manifest, error) {
+	var manifest Manifest
+	resp, err := r.do(http.MethodGet, r.manifestURL(ref), ref)
+	if err != nil {
+		return manifest, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return manifest, fmt.Errorf("error inspecting %s: %w", ref, ErrManifestNotFound)
+	default:
+		return manifest, fmt.Errorf("error inspecting %s: unexpected status %s", ref, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return manifest, fmt.Errorf("error reading the manifest of %s: %w", ref, err)
+	}
+	hash := sha256.Sum256(body)
+	manifest.Digest = "sha256:" + hex.EncodeToString(hash[:])
+	if ref.Pinned() && manifest.Digest != ref.Digest {
+		return manifest, fmt.Errorf("error inspecting %s: the registry returned the digest %s", ref, manifest.Digest)
+	}
+
+	var content manifestContent
+	if err := json.Unmarshal(body, &content); err != nil {
+		return manifest, fmt.Errorf("error decoding the manifest of %s: %w", ref, err)
+	}
+	if content.SchemaVersion != 2 {
+		return manifest, fmt.Errorf("error inspecting %s: unsupported manifest schema version %d", ref, content.SchemaVersion)
+	}
+	// The media type of the Docker manifests is only in the Content-Type header
+	manifest.MediaType = content.MediaType
+	if manifest.MediaType == "" {
+		manifest.MediaType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	}
+
+	if manifest.Index() || len(content.Manifests) > 0 {
+		for _, m := range content.Manifests {
+			if m.Platform != nil && m.Platform.OS != "unknown" {
+				manifest.Platforms = append(manifest.Platforms, *m.Platform)
+			}
+		}
+		return manifest, nil
+	}
+	if content.Config == nil {
+		return manifest, fmt.Errorf("error inspecting %s: the manifest has no config", ref)
+	}
+	platform, err := r.imagePlatform(ref, content.Config.Digest)
+	if err != nil {
+		return manifest, err
+	}
+	manifest.Platforms = []Platform{platform}
+	return manifest, nil
+}
+
+// imagePlatform fetches the config blob of an image, and returns its platform
+func (r *Resolver) imagePlatform(ref Reference, configDigest string) (Platform, error) {
+	var platform Platform
+	if !digestRegex.MatchString(configDigest) {
+		return platform, fmt.Errorf("error inspecting %s: invalid config digest %q", ref, configDigest)
+	}
+	resp, err := r.do(http.MethodGet, fmt.Sprintf("%s/v2/%s/blobs/%s", r.endpoint(ref), ref.Repository, configDigest), ref)
+	if err != nil {
+		return platform, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return platform, fmt.Errorf("error fetching the config of %s: unexpected status %s", ref, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&platform); err != nil {
+		return platform, fmt.Errorf("error decoding the config of %s: %w", ref, err)
+	}
+	if platform.OS == "" || platform.Architecture == "" {
+		return platform, fmt.Errorf("error inspecting %s: the config has no platform", ref)
+	}
+	return platform, nil
+}
@@ -0,0 +1,93 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	multiArchIndex = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {"digest": "sha256:1111111111111111111111111111111111111111111111111111111111111111", "platform": {"os": "linux", "architecture": "amd64"}},
+    {"digest": "sha256:2222222222222222222222222222222222222222222222222222222222222222", "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}},
+    {"digest": "sha256:3333333333333333333333333333333333333333333333333333333333333333", "platform": {"os": "unknown", "architecture": "unknown"}}
+  ]
+}`
+	singleArchManifest = `{
+  "schemaVersion": 2,
+  "config": {"mediaType": "application/vnd.docker.container.image.v1+json", "digest": "sha256:4444444444444444444444444444444444444444444444444444444444444444"}
+}`
+)
+
+// manifestRegistry serves a multi-platform index and a single platform image, without authentication like registry:2
+func manifestRegistry(t *testing.T) *httptest.Server {
+	indexDigest := sha256.Sum256([]byte(multiArchIndex))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/hasura/ndc-test/manifests/v1.0.0", "/v2/hasura/ndc-test/manifests/sha256:" + hex.EncodeToString(indexDigest[:]):
+			w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+			w.Write([]byte(multiArchIndex))
+		case "/v2/hasura/ndc-test/manifests/amd64-only":
+			// The Docker manifests have their media type in the Content-Type header only
+			w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+			w.Write([]byte(singleArchManifest))
+		case "/v2/hasura/ndc-test/blobs/sha256:4444444444444444444444444444444444444444444444444444444444444444":
+			w.Write([]byte(`{"architecture": "amd64", "os": "linux", "rootfs": {"type": "layers"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestInspect(t *testing.T) {
+	resolver := NewResolver(manifestRegistry(t).URL)
+
+	ref, err := ParseReference("ghcr.io/hasura/ndc-test:v1.0.0")
+	assert.NoError(t, err)
+	manifest, err := resolver.Inspect(ref)
+	assert.NoError(t, err)
+	indexDigest := sha256.Sum256([]byte(multiArchIndex))
+	assert.Equal(t, "sha256:"+hex.EncodeToString(indexDigest[:]), manifest.Digest)
+	assert.True(t, manifest.Index())
+	assert.Equal(t, []Platform{LinuxAmd64, {OS: "linux", Architecture: "arm64", Variant: "v8"}}, manifest.Platforms)
+	assert.Empty(t, manifest.MissingPlatforms(RequiredPlatforms))
+
+	// A pinned reference is inspected by digest
+	pinnedManifest, err := resolver.Inspect(ref.WithDigest(manifest.Digest))
+	assert.NoError(t, err)
+	assert.Equal(t, manifest, pinnedManifest)
+
+	ref, err = ParseReference("ghcr.io/hasura/ndc-test:amd64-only")
+	assert.NoError(t, err)
+	manifest, err = resolver.Inspect(ref)
+	assert.NoError(t, err)
+	assert.False(t, manifest.Index())
+	assert.Equal(t, "application/vnd.docker.distribution.manifest.v2+json", manifest.MediaType)
+	assert.Equal(t, []Platform{LinuxAmd64}, manifest.Platforms)
+	assert.Equal(t, []Platform{LinuxArm64}, manifest.MissingPlatforms(RequiredPlatforms))
+	assert.EqualError(t, manifest.CheckPlatforms(ref, RequiredPlatforms), "ghcr.io/hasura/ndc-test:amd64-only has no image for linux/arm64, it must be available for linux/amd64, linux/arm64")
+
+	ref, err = ParseReference("ghcr.io/hasura/ndc-test:v2.0.0")
+	assert.NoError(t, err)
+	_, err = resolver.Inspect(ref)
+	assert.ErrorIs(t, err, ErrManifestNotFound)
+}
+
+func TestPlatform(t *testing.T) {
+	assert.Equal(t, "linux/amd64", LinuxAmd64.String())
+	assert.Equal(t, "linux/arm64/v8", Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}.String())
+
+	// The variant is only compared when the required platform has one
+	manifest := Manifest{Platforms: []Platform{{OS: "linux", Architecture: "arm", Variant: "v6"}}}
+	assert.Empty(t, manifest.MissingPlatforms([]Platform{{OS: "linux", Architecture: "arm"}}))
+	assert.Len(t, manifest.MissingPlatforms([]Platform{{OS: "linux", Architecture: "arm", Variant: "v7"}}), 1)
+}
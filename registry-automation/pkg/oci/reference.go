@@ -0,0 +1,149 @@
+// Package oci parses the references of the docker images of the packaging specs, and resolves their tags to the
+// digests of their manifests with the Registry HTTP API V2.
+package oci
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DockerHubRegistry is the registry of the references without registry, e.g. postgres:16
+const DockerHubRegistry = "docker.io"
+
+var (
+	// The components of a repository, e.g. hasura and ndc-postgres in hasura/ndc-postgres
+	pathComponentRegex = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	// A registry host with an optional port, e.g. ghcr.io or localhost:5000
+	registryRegex = regexp.MustCompile(`^(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*(?::[0-9]+)?$`)
+	tagRegex      = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestRegex   = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]+$`)
+	sha256Regex   = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// maxNameLength is the maximum length of the name of an image, registry included
+const maxNameLength = 255
+
+// Reference is a reference to an image, e.g. ghcr.io/hasura/ndc-postgres:v1.0.0 or
+// ghcr.io/hasura/ndc-postgres@sha256:<hex>
+type Reference struct {
+	// Registry is the host of the registry, e.g. ghcr.io, DockerHubRegistry for the references without registry
+	Registry string
+	// Repository is the path of the image in the registry, e.g. hasura/ndc-postgres. The official images of Docker
+	// Hub are in the library namespace, e.g. library/postgres.
+	Repository string
+	// Tag is empty if the reference has no tag, the registries then serve the latest tag
+	Tag string
+	// Digest is the digest of the manifest of a pinned reference, e.g. sha256:<hex>
+	Digest string
+}
+
+// ParseReference parses an image reference, with the grammar of the docker CLI
+func ParseReference(s string) (Reference, error) {
+	var ref Reference
+	if s == "" {
+		return ref, fmt.Errorf("invalid reference: empty")
+	}
+	if strings.TrimSpace(s) != s {
+		return ref, fmt.Errorf("invalid reference %q: leading or trailing whitespace", s)
+	}
+
+	name := s
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+		if !digestRegex.MatchString(ref.Digest) {
+			return ref, fmt.Errorf("invalid reference %q: invalid digest %q", s, ref.Digest)
+		}
+		if strings.HasPrefix(ref.Digest, "sha256:") && !sha256Regex.MatchString(ref.Digest) {
+			return ref, fmt.Errorf("invalid reference %q: invalid sha256 digest %q", s, ref.Digest)
+		}
+	}
+	// The tag is after the last colon, unless the colon is the port of the registry
+	if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i+1:], "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+		if !tagRegex.MatchString(ref.Tag) {
+			return ref, fmt.Errorf("invalid reference %q: invalid tag %q", s, ref.Tag)
+		}
+	}
+	if name == "" {
+		return ref, fmt.Errorf("invalid reference %q: missing repository", s)
+	}
+	if len(name) > maxNameLength {
+		return ref, fmt.Errorf("invalid reference %q: the name is longer than %d characters", s, maxNameLength)
+	}
+
+	ref.Registry = DockerHubRegistry
+	ref.Repository = name
+	// The first component is a registry if it looks like a host, like the docker CLI does
+	if first, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		if !registryRegex.MatchString(first) {
+			return ref, fmt.Errorf("invalid reference %q: invalid registry %q", s, first)
+		}
+		ref.Registry = first
+		ref.Repository = rest
+	}
+	if ref.Registry == "index.docker.io" {
+		ref.Registry = DockerHubRegistry
+	}
+	for _, component := range strings.Split(ref.Repository, "/") {
+		if strings.ToLower(component) != component {
+			return ref, fmt.Errorf("invalid reference %q: the repository must be lowercase", s)
+		}
+		if !pathComponentRegex.MatchString(component) {
+			return ref, fmt.Errorf("invalid reference %q: invalid repository %q", s, ref.Repository)
+		}
+	}
+	if ref.Registry == DockerHubRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	return ref, nil
+}
+
+// Name returns the registry and the repository of the reference, e.g. ghcr.io/hasura/ndc-postgres
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String returns the normalized reference, with its registry
+func (r Reference) String() string {
+	s := r.Name()
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// Pinned returns true if the reference has a digest, the image it references never changes
+func (r Reference) Pinned() bool {
+	return r.Digest != ""
+}
+
+// WithDigest returns the reference pinned to the digest, its tag is kept for the readers
+func (r Reference) WithDigest(digest string) Reference {
+	r.Digest = digest
+	return r
+}
+
+// mutableTags are the tags that are moved to the new images by convention
+var mutableTags = map[string]bool{
+	"latest":  true,
+	"main":    true,
+	"master":  true,
+	"dev":     true,
+	"develop": true,
+	"edge":    true,
+	"nightly": true,
+	"stable":  true,
+}
+
+// MutableTag returns true if the reference isn't pinned and its tag is moved to the new images by convention, like
+// latest, which is also the tag of the references without tag
+func (r Reference) MutableTag() bool {
+	if r.Pinned() {
+		return false
+	}
+	return r.Tag == "" || mutableTags[strings.ToLower(r.Tag)]
+}
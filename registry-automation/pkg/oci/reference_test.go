@@ -0,0 +1,70 @@
+package oci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDigest = "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+
+func TestParseReference(t *testing.T) {
+	testCases := []struct {
+		reference string
+		want      Reference
+		wantErr   string
+	}{
+		{"ghcr.io/hasura/ndc-postgres:v1.0.0", Reference{Registry: "ghcr.io", Repository: "hasura/ndc-postgres", Tag: "v1.0.0"}, ""},
+		{"ghcr.io/hasura/ndc-postgres:v1.0.0@" + testDigest, Reference{Registry: "ghcr.io", Repository: "hasura/ndc-postgres", Tag: "v1.0.0", Digest: testDigest}, ""},
+		{"ghcr.io/hasura/ndc-postgres@" + testDigest, Reference{Registry: "ghcr.io", Repository: "hasura/ndc-postgres", Digest: testDigest}, ""},
+		{"localhost:5000/hasura/ndc-postgres", Reference{Registry: "localhost:5000", Repository: "hasura/ndc-postgres"}, ""},
+		{"localhost/ndc-postgres:v1", Reference{Registry: "localhost", Repository: "ndc-postgres", Tag: "v1"}, ""},
+		{"hasura/ndc-postgres:v1.0.0", Reference{Registry: DockerHubRegistry, Repository: "hasura/ndc-postgres", Tag: "v1.0.0"}, ""},
+		{"postgres:16", Reference{Registry: DockerHubRegistry, Repository: "library/postgres", Tag: "16"}, ""},
+		{"index.docker.io/library/postgres", Reference{Registry: DockerHubRegistry, Repository: "library/postgres"}, ""},
+		{"", Reference{}, "empty"},
+		{" ghcr.io/hasura/ndc-postgres:v1.0.0", Reference{}, "whitespace"},
+		{"ghcr.io/hasura/NDC-postgres:v1.0.0", Reference{}, "must be lowercase"},
+		{"ghcr.io/hasura/ndc-postgres:v1.0.0:v2", Reference{}, "invalid repository"},
+		{"ghcr.io/hasura/ndc-postgres:-v1", Reference{}, "invalid tag"},
+		{"ghcr.io/hasura/ndc-postgres@sha256:abc", Reference{}, "invalid sha256 digest"},
+		{"ghcr.io/hasura/ndc-postgres@latest", Reference{}, "invalid digest"},
+		{":v1.0.0", Reference{}, "missing repository"},
+		{"ghcr.io//ndc-postgres", Reference{}, "invalid repository"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.reference, func(t *testing.T) {
+			ref, err := ParseReference(tc.reference)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, ref)
+		})
+	}
+}
+
+func TestReference(t *testing.T) {
+	ref, err := ParseReference("postgres")
+	assert.NoError(t, err)
+	assert.Equal(t, "docker.io/library/postgres", ref.String())
+	assert.True(t, ref.MutableTag())
+
+	ref, err = ParseReference("ghcr.io/hasura/ndc-postgres:latest")
+	assert.NoError(t, err)
+	assert.True(t, ref.MutableTag())
+	assert.False(t, ref.Pinned())
+
+	// A pinned reference never changes, whatever its tag
+	pinned := ref.WithDigest(testDigest)
+	assert.True(t, pinned.Pinned())
+	assert.False(t, pinned.MutableTag())
+	assert.Equal(t, "ghcr.io/hasura/ndc-postgres:latest@"+testDigest, pinned.String())
+
+	ref, err = ParseReference("ghcr.io/hasura/ndc-postgres:v1.0.0")
+	assert.NoError(t, err)
+	assert.False(t, ref.MutableTag())
+	assert.Equal(t, "ghcr.io/hasura/ndc-postgres", ref.Name())
+}
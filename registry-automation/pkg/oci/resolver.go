@@ -0,0 +1,259 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrManifestNotFound is returned when the registry has no manifest for the tag or the digest of a reference
+var ErrManifestNotFound = errors.New("manifest not found")
+
+// The media types of the manifests accepted from the registries, the digests of the multi-platform images are the
+// digests of their index
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// dockerHubEndpoint serves the Registry HTTP API V2 of Docker Hub, docker.io itself doesn't
+const dockerHubEndpoint = "https://registry-1.docker.io"
+
+// maxManifestSize bounds the manifests read to compute their digest
+const maxManifestSize = 4 << 20
+
+// Resolver resolves the tags of the image references to the digests of their manifests, with the Registry HTTP API V2.
+// The registries that require a token, like ghcr.io and Docker Hub, are queried with an anonymous bearer token.
+type Resolver struct {
+	// Endpoint is the base URL of the registry that every reference is resolved against, e.g. http://localhost:5000
+	// for a local registry:2 stand-in. The registry of each reference is queried over https if empty.
+	Endpoint string
+	// Client sends the requests, http.DefaultClient if nil
+	Client *http.Client
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// NewResolver returns a resolver of the references against the registry at endpoint, or against the registry of each
+// reference if endpoint is empty
+func NewResolver(endpoint string) *Resolver {
+	return &Resolver{Endpoint: strings.TrimSuffix(endpoint, "/")}
+}
+
+// Resolve returns the digest of the manifest that the reference points to. The digest of a pinned reference is
+// checked against the registry too, so that a pinned image that doesn't exist is reported.
+func (r *Resolver) Resolve(ref Reference) (string, error) {
+	manifestURL := r.manifestURL(ref)
+	resp, err := r.do(http.MethodHead, manifestURL, ref)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	digest := resp.Header.Get("Docker-Content-Digest")
+	// Some registries only send the digest of the manifests they serve with GET
+	if resp.StatusCode == http.StatusMethodNotAllowed || (resp.StatusCode == http.StatusOK && digest == "") {
+		resp, err = r.do(http.MethodGet, manifestURL, ref)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		digest = resp.Header.Get("Docker-Content-Digest")
+		if resp.StatusCode == http.StatusOK && digest == "" {
+			hash := sha256.New()
+			if _, err := io.Copy(hash, io.LimitReader(resp.Body, maxManifestSize)); err != nil {
+				return "", fmt.Errorf("error reading the manifest of %s: %w", ref, err)
+			}
+			digest = "sha256:" + hex.EncodeToString(hash.Sum(nil))
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", fmt.Errorf("error resolving %s: %w", ref, ErrManifestNotFound)
+	default:
+		return "", fmt.Errorf("error resolving %s: unexpected status %s", ref, resp.Status)
+	}
+	if !digestRegex.MatchString(digest) {
+		return "", fmt.Errorf("error resolving %s: invalid digest %q", ref, digest)
+	}
+	if ref.Pinned() && digest != ref.Digest {
+		return "", fmt.Errorf("error resolving %s: the registry returned the digest %s", ref, digest)
+	}
+	return digest, nil
+}
+
+// manifestURL returns the URL of the manifest of the reference, by digest if it is pinned
+func (r *Resolver) manifestURL(ref Reference) string {
+	manifestRef := ref.Tag
+	if ref.Pinned() {
+		manifestRef = ref.Digest
+	} else if manifestRef == "" {
+		manifestRef = "latest"
+	}
+	return fmt.Sprintf("%s/v2/%s/manifests/%s", r.endpoint(ref), ref.Repository, manifestRef)
+}
+
+func (r *Resolver) endpoint(ref Reference) string {
+	switch {
+	case r.Endpoint != "":
+		return r.Endpoint
+	case ref.Registry == DockerHubRegistry:
+		return dockerHubEndpoint
+	default:
+		return "https://" + ref.Registry
+	}
+}
+
+func (r *Resolver) client() *http.Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	return http.DefaultClient
+}
+
+// do sends a request for the manifest or a blob of the reference, the request is sent again with a bearer token if
+// the registry challenges it
+func (r *Resolver) do(method, requestURL string, ref Reference) (*http.Response, error) {
+	scope := "repository:" + ref.Repository + ":pull"
+	resp, err := r.send(method, requestURL, r.token(scope))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	challenge, ok := parseBearerChallenge(resp.Header.Get("WWW-Authenticate"))
+	if !ok {
+		return nil, fmt.Errorf("error resolving %s: unauthorized, and the registry doesn't support bearer tokens", ref)
+	}
+	if challenge["scope"] == "" {
+		challenge["scope"] = scope
+	}
+	token, err := r.fetchToken(challenge)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %s: %w", ref, err)
+	}
+	r.mu.Lock()
+	if r.tokens == nil {
+		r.tokens = make(map[string]string)
+	}
+	r.tokens[scope] = token
+	r.mu.Unlock()
+
+	resp, err = r.send(method, requestURL, token)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return nil, fmt.Errorf("error resolving %s: unauthorized", ref)
+	}
+	return resp, nil
+}
+
+func (r *Resolver) token(scope string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.tokens[scope]
+}
+
+func (r *Resolver) send(method, requestURL, token string) (*http.Response, error) {
+	req, err := http.NewRequest(method, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	return resp, nil
+}
+
+// fetchToken requests an anonymous token from the realm of the challenge
+func (r *Resolver) fetchToken(challenge map[string]string) (string, error) {
+	tokenURL, err := url.Parse(challenge["realm"])
+	if err != nil || tokenURL.Scheme == "" {
+		return "", fmt.Errorf("invalid token realm %q", challenge["realm"])
+	}
+	query := tokenURL.Query()
+	for _, param := range []string{"service", "scope"} {
+		if challenge[param] != "" {
+			query.Set(param, challenge[param])
+		}
+	}
+	tokenURL.RawQuery = query.Encode()
+
+	resp, err := r.client().Get(tokenURL.String())
+	if err != nil {
+		return "", fmt.Errorf("error requesting a token: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error requesting a token: %s", resp.Status)
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("error decoding the token: %v", err)
+	}
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+	if tokenResponse.AccessToken != "" {
+		return tokenResponse.AccessToken, nil
+	}
+	return "", fmt.Errorf("error requesting a token: empty token")
+}
+
+// parseBearerChallenge parses the parameters of a WWW-Authenticate header with the Bearer scheme, e.g.
+// Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:hasura/ndc-postgres:pull"
+func parseBearerChallenge(header string) (map[string]string, bool) {
+	scheme, params, _ := strings.Cut(strings.TrimSpace(header), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return nil, false
+	}
+
+	challenge := make(map[string]string)
+	for params = strings.TrimSpace(params); params != ""; {
+		key, rest, ok := strings.Cut(params, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			// The quoted values may contain commas, e.g. the scopes of several repositories
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				return nil, false
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+		challenge[key] = value
+		params = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), ","))
+	}
+	return challenge, challenge["realm"] != ""
+}
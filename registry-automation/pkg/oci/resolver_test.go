@@ -0,0 +1,90 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRegistry is a registry that requires an anonymous bearer token, like ghcr.io
+func testRegistry(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:hasura/ndc-test:pull" || r.URL.Query().Get("service") != "test-registry" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"token": "test-token"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry",scope="repository:hasura/ndc-test:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/hasura/ndc-test/manifests/v1.0.0", "/v2/hasura/ndc-test/manifests/" + testDigest:
+			w.Header().Set("Docker-Content-Digest", testDigest)
+		case "/v2/hasura/ndc-test/manifests/latest":
+			// The digest is only computed from the manifest served with GET
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Write([]byte(`{"schemaVersion": 2}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResolver(t *testing.T) {
+	server := testRegistry(t)
+	resolver := NewResolver(server.URL)
+
+	ref, err := ParseReference("ghcr.io/hasura/ndc-test:v1.0.0")
+	assert.NoError(t, err)
+	digest, err := resolver.Resolve(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+
+	ref, err = ParseReference("ghcr.io/hasura/ndc-test")
+	assert.NoError(t, err)
+	digest, err = resolver.Resolve(ref)
+	assert.NoError(t, err)
+	manifestDigest := sha256.Sum256([]byte(`{"schemaVersion": 2}`))
+	assert.Equal(t, "sha256:"+hex.EncodeToString(manifestDigest[:]), digest)
+
+	// The digest of a pinned reference is checked against the registry
+	digest, err = resolver.Resolve(ref.WithDigest(testDigest))
+	assert.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+
+	ref, err = ParseReference("ghcr.io/hasura/ndc-test:v2.0.0")
+	assert.NoError(t, err)
+	_, err = resolver.Resolve(ref)
+	assert.ErrorIs(t, err, ErrManifestNotFound)
+}
+
+func TestParseBearerChallenge(t *testing.T) {
+	challenge, ok := parseBearerChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/postgres:pull,push"`)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/postgres:pull,push",
+	}, challenge)
+
+	_, ok = parseBearerChallenge(`Basic realm="registry"`)
+	assert.False(t, ok)
+	_, ok = parseBearerChallenge(`Bearer service="registry"`)
+	assert.False(t, ok)
+}
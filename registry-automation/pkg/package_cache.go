@@ -0,0 +1,31 @@
+package pkg
+
+import (
+	"sync"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/cache"
+)
+
+var (
+	packageCacheMu sync.RWMutex
+	packageCache   *cache.Cache
+)
+
+// SetPackageCache sets the cache shared by the downloads of the connector packages. A nil cache disables the
+// cache, the packages are then downloaded every time.
+func SetPackageCache(c *cache.Cache) {
+	packageCacheMu.Lock()
+	defer packageCacheMu.Unlock()
+	packageCache = c
+}
+
+func getPackageCache() *cache.Cache {
+	packageCacheMu.RLock()
+	defer packageCacheMu.RUnlock()
+	return packageCache
+}
+
+// PackageCacheEnabled returns true if the downloads of the connector packages are shared through a cache
+func PackageCacheEnabled() bool {
+	return getPackageCache() != nil
+}
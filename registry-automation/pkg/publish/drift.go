@@ -0,0 +1,340 @@
+package publish
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+)
+
+// DriftKind is the kind of difference between the registry folder and the live hub registry
+type DriftKind string
+
+const (
+	MissingConnectorDrift     DriftKind = "missing_connector"
+	MissingVersionDrift       DriftKind = "missing_version"
+	DeprecatedVersionDrift    DriftKind = "deprecated_version"
+	StaleLatestVersionDrift   DriftKind = "stale_latest_version"
+	StaleDocsDrift            DriftKind = "stale_docs"
+	StaleTitleDrift           DriftKind = "stale_title"
+	StaleDescriptionDrift     DriftKind = "stale_description"
+	PackageDefinitionURLDrift DriftKind = "mismatched_package_definition_url"
+)
+
+// Drift is a difference between the registry folder and the live hub registry
+type Drift struct {
+	Kind      DriftKind `json:"kind"`
+	Connector Connector `json:"connector"`
+	Version   string    `json:"version,omitempty"`
+	// Expected is the value in the registry folder, it is not set for the docs
+	Expected string `json:"expected,omitempty"`
+	// Actual is the value in the hub registry, it is not set for the docs
+	Actual string `json:"actual,omitempty"`
+}
+
+func (d Drift) String() string {
+	target := fmt.Sprintf("%s/%s", d.Connector.Namespace, d.Connector.Name)
+	if d.Version != "" {
+		target = fmt.Sprintf("%s %s", target, d.Version)
+	}
+	switch d.Kind {
+	case MissingConnectorDrift:
+		return fmt.Sprintf("%s: the connector is missing from the registry", target)
+	case MissingVersionDrift:
+		return fmt.Sprintf("%s: the connector version is missing from the registry", target)
+	case DeprecatedVersionDrift:
+		return fmt.Sprintf("%s: the connector version is deprecated in the registry", target)
+	case StaleDocsDrift:
+		return fmt.Sprintf("%s: the docs in the registry differ from the README", target)
+	default:
+		return fmt.Sprintf("%s: %s, expected %q, found %q", target, d.Kind, d.Expected, d.Actual)
+	}
+}
+
+// fixable returns true if the drift is reconciled by a registry mutation. The missing connectors
+// and versions require their packages and logos to be uploaded, so they are only published by the CI.
+func (d Drift) fixable() bool {
+	return d.Kind != MissingConnectorDrift && d.Kind != MissingVersionDrift
+}
+
+// DriftReport lists the differences between the registry folder and the live hub registry
+type DriftReport struct {
+	Drifts []Drift `json:"drifts"`
+
+	// expectedDocs holds the README of the connectors with stale docs, to reconcile them
+	expectedDocs map[Connector]string
+}
+
+// HasDrift returns true if the registry folder and the live hub registry differ
+func (r DriftReport) HasDrift() bool {
+	return len(r.Drifts) > 0
+}
+
+// Unfixable returns the drifts that are not reconciled by the reconciling mutation
+func (r DriftReport) Unfixable() []Drift {
+	var drifts []Drift
+	for _, drift := range r.Drifts {
+		if !drift.fixable() {
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts
+}
+
+// ReconcilingMutation is a GraphQL request that reconciles the live hub registry with the registry folder
+type ReconcilingMutation struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// ReconcilingMutation returns the mutation that reconciles the overviews and the versions that drifted
+func (r DriftReport) ReconcilingMutation() ReconcilingMutation {
+	overviewUpdates := make(map[Connector]*ConnectorOverviewUpdate)
+	var connectors []Connector
+	connectorVersionUpdates := make([]ConnectorVersionUpdate, 0)
+
+	for _, drift := range r.Drifts {
+		if !drift.fixable() {
+			continue
+		}
+		if drift.Kind == PackageDefinitionURLDrift || drift.Kind == DeprecatedVersionDrift {
+			var connectorVersionUpdate ConnectorVersionUpdate
+			if drift.Kind == PackageDefinitionURLDrift {
+				packageDefinitionURL := drift.Expected
+				connectorVersionUpdate.Set.PackageDefinitionURL = &packageDefinitionURL
+			} else {
+				// The release is still in the registry folder, so its version is restored
+				isDeprecated := false
+				connectorVersionUpdate.Set.IsDeprecated = &isDeprecated
+			}
+			connectorVersionUpdate.Where = ConnectorVersionWhereClause{
+				ConnectorName:      drift.Connector.Name,
+				ConnectorNamespace: drift.Connector.Namespace,
+				Version:            drift.Version,
+			}
+			connectorVersionUpdates = append(connectorVersionUpdates, connectorVersionUpdate)
+			continue
+		}
+
+		update, ok := overviewUpdates[drift.Connector]
+		if !ok {
+			update = &ConnectorOverviewUpdate{Where: WhereClause{ConnectorName: drift.Connector.Name, ConnectorNamespace: drift.Connector.Namespace}}
+			overviewUpdates[drift.Connector] = update
+			connectors = append(connectors, drift.Connector)
+		}
+		expected := drift.Expected
+		switch drift.Kind {
+		case StaleLatestVersionDrift:
+			update.Set.LatestVersion = &expected
+		case StaleTitleDrift:
+			update.Set.Title = &expected
+		case StaleDescriptionDrift:
+			update.Set.Description = &expected
+		case StaleDocsDrift:
+			docs := r.expectedDocs[drift.Connector]
+			update.Set.Docs = &docs
+		}
+	}
+
+	connectorOverviewUpdates := make([]ConnectorOverviewUpdate, 0, len(connectors))
+	for _, connector := range connectors {
+		connectorOverviewUpdates = append(connectorOverviewUpdates, *overviewUpdates[connector])
+	}
+
+	return ReconcilingMutation{
+		Query: reconcileDriftMutation,
+		Variables: map[string]interface{}{
+			"connector_overview_updates": connectorOverviewUpdates,
+			"connector_version_updates":  connectorVersionUpdates,
+		},
+	}
+}
+
+// DriftDetector compares the registry folder against the live hub registry
+type DriftDetector struct {
+	client            GraphQLClientInterface
+	publicationKey    string
+	bucketName        string
+	deprecationColumn bool
+}
+
+// NewDriftDetector builds a drift detector, the bucket name is used to compute the expected package definition URLs.
+// The deprecated versions are only detected if deprecationColumn is set, once the registry has the is_deprecated
+// column of hub_registry_connector_version.
+func NewDriftDetector(client GraphQLClientInterface, publicationKey string, bucketName string, deprecationColumn bool) (*DriftDetector, error) {
+	if client == nil {
+		return nil, &ConfigError{Setting: "clients", Err: fmt.Errorf("the registry client is required")}
+	}
+	if publicationKey == "" {
+		return nil, &ConfigError{Setting: "publication key", Err: fmt.Errorf("not set")}
+	}
+	if bucketName == "" {
+		return nil, &ConfigError{Setting: "bucket name", Err: fmt.Errorf("not set")}
+	}
+	return &DriftDetector{client: client, publicationKey: publicationKey, bucketName: bucketName, deprecationColumn: deprecationColumn}, nil
+}
+
+// registryState indexes the snapshot of the live hub registry by connector
+type registryState struct {
+	registeredConnectors  map[Connector]bool
+	overviews             map[Connector]ConnectorOverview
+	packageDefinitionURLs map[Connector]map[string]string
+	deprecatedVersions    map[Connector]map[string]bool
+}
+
+func newRegistryState(snapshot RegistrySnapshot) registryState {
+	state := registryState{
+		registeredConnectors:  make(map[Connector]bool),
+		overviews:             make(map[Connector]ConnectorOverview),
+		packageDefinitionURLs: make(map[Connector]map[string]string),
+		deprecatedVersions:    make(map[Connector]map[string]bool),
+	}
+	for _, connector := range snapshot.HubRegistryConnector {
+		state.registeredConnectors[Connector{Name: connector.Name, Namespace: connector.Namespace}] = true
+	}
+	for _, overview := range snapshot.ConnectorOverview {
+		state.overviews[Connector{Name: overview.Name, Namespace: overview.Namespace}] = overview
+	}
+	for _, version := range snapshot.HubRegistryConnectorVersion {
+		connector := Connector{Name: version.Name, Namespace: version.Namespace}
+		// The deprecated versions are the releases that were deleted, they are reported on their own
+		if version.IsDeprecated {
+			if _, ok := state.deprecatedVersions[connector]; !ok {
+				state.deprecatedVersions[connector] = make(map[string]bool)
+			}
+			state.deprecatedVersions[connector][version.Version] = true
+			continue
+		}
+		if _, ok := state.packageDefinitionURLs[connector]; !ok {
+			state.packageDefinitionURLs[connector] = make(map[string]string)
+		}
+		state.packageDefinitionURLs[connector][version.Version] = version.PackageDefinitionURL
+	}
+	return state
+}
+
+// Detect walks the connectors and the aliased connectors of the registry folder and compares them with the
+// connectors, overviews and versions of the live hub registry. The versions of an aliased connector are compared
+// against the releases of its parent. It fails if the registry folder has no connectors.
+func (d *DriftDetector) Detect(registryFolder string) (DriftReport, error) {
+	report := DriftReport{Drifts: make([]Drift, 0), expectedDocs: make(map[Connector]string)}
+
+	snapshot, err := getRegistrySnapshot(d.client, d.publicationKey, d.deprecationColumn)
+	if err != nil {
+		return report, err
+	}
+	state := newRegistryState(snapshot)
+
+	metadataFiles, err := filepath.Glob(filepath.Join(registryFolder, "*", "*", ndchub.MetadataJSON))
+	if err != nil {
+		return report, fmt.Errorf("failed to list the connectors of the registry folder: %w", err)
+	}
+	// An empty registry folder is more likely a wrong path than a registry without connectors
+	if len(metadataFiles) == 0 {
+		return report, fmt.Errorf("no connector metadata found in the registry folder %s", registryFolder)
+	}
+	sort.Strings(metadataFiles)
+
+	for _, metadataFile := range metadataFiles {
+		connectorFolder := filepath.Dir(metadataFile)
+		connector := Connector{Name: filepath.Base(connectorFolder), Namespace: filepath.Base(filepath.Dir(connectorFolder))}
+
+		connectorMetadata, err := ndchub.GetConnectorMetadata(metadataFile)
+		if err != nil {
+			return report, &ConnectorError{Connector: connector, Err: fmt.Errorf("failed to read the connector metadata: %w", err)}
+		}
+		if connectorMetadata == nil {
+			continue
+		}
+		if err := d.detectConnectorDrift(&report, state, connector, connectorFolder, connectorMetadata, connector, connectorFolder); err != nil {
+			return report, err
+		}
+
+		// The aliased connectors are published with the releases of the connector, under their own name
+		aliasMetadataFiles, err := filepath.Glob(filepath.Join(connectorFolder, ndchub.AliasedConnectorsFolder, "*", ndchub.MetadataJSON))
+		if err != nil {
+			return report, &ConnectorError{Connector: connector, Err: fmt.Errorf("failed to list the aliased connectors: %w", err)}
+		}
+		sort.Strings(aliasMetadataFiles)
+		for _, aliasMetadataFile := range aliasMetadataFiles {
+			aliasFolder := filepath.Dir(aliasMetadataFile)
+			alias := Connector{Name: filepath.Base(aliasFolder), Namespace: connector.Namespace}
+			aliasedConnector, err := ndchub.GetAliasedConnector(aliasMetadataFile)
+			if err != nil {
+				return report, &ConnectorError{Connector: alias, Err: fmt.Errorf("failed to read the aliased connector metadata: %w", err)}
+			}
+			if err := d.detectConnectorDrift(&report, state, alias, aliasFolder, aliasedConnector.Metadata, connector, connectorFolder); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// detectConnectorDrift compares the overview of the connector, with the README of its folder, and the releases of the
+// parent in the parent folder, with the registry. The parent is the connector itself, unless it is an aliased connector.
+func (d *DriftDetector) detectConnectorDrift(report *DriftReport, state registryState, connector Connector, connectorFolder string,
+	connectorMetadata *ndchub.ConnectorMetadata, parent Connector, parentFolder string) error {
+	overview, hasOverview := state.overviews[connector]
+	if !state.registeredConnectors[connector] || !hasOverview {
+		report.Drifts = append(report.Drifts, Drift{Kind: MissingConnectorDrift, Connector: connector})
+		return nil
+	}
+
+	if overview.LatestVersion != connectorMetadata.Overview.LatestVersion {
+		report.Drifts = append(report.Drifts, Drift{Kind: StaleLatestVersionDrift, Connector: connector, Expected: connectorMetadata.Overview.LatestVersion, Actual: overview.LatestVersion})
+	}
+	if overview.Title != connectorMetadata.Overview.Title {
+		report.Drifts = append(report.Drifts, Drift{Kind: StaleTitleDrift, Connector: connector, Expected: connectorMetadata.Overview.Title, Actual: overview.Title})
+	}
+	if overview.Description != connectorMetadata.Overview.Description {
+		report.Drifts = append(report.Drifts, Drift{Kind: StaleDescriptionDrift, Connector: connector, Expected: connectorMetadata.Overview.Description, Actual: overview.Description})
+	}
+
+	docs, err := os.ReadFile(filepath.Join(connectorFolder, "README.md"))
+	if err != nil {
+		return &ConnectorError{Connector: connector, Err: fmt.Errorf("failed to read the README: %w", err)}
+	}
+	if overview.Docs != string(docs) {
+		report.Drifts = append(report.Drifts, Drift{Kind: StaleDocsDrift, Connector: connector})
+		report.expectedDocs[connector] = string(docs)
+	}
+
+	connectorPackagingFiles, err := filepath.Glob(filepath.Join(parentFolder, "releases", "*", ndchub.ConnectorPackagingJSON))
+	if err != nil {
+		return &ConnectorError{Connector: parent, Err: fmt.Errorf("failed to list the connector versions: %w", err)}
+	}
+	sort.Strings(connectorPackagingFiles)
+
+	for _, connectorPackagingFile := range connectorPackagingFiles {
+		connectorPackaging, err := ndchub.GetConnectorPackaging(connectorPackagingFile)
+		if err != nil {
+			return &ConnectorError{Connector: parent, Err: fmt.Errorf("failed to read the connector packaging %s: %w", connectorPackagingFile, err)}
+		}
+		if connectorPackaging == nil {
+			continue
+		}
+		// The folder name is the version that the CI publishes
+		version := filepath.Base(filepath.Dir(connectorPackagingFile))
+
+		if state.deprecatedVersions[connector][version] {
+			report.Drifts = append(report.Drifts, Drift{Kind: DeprecatedVersionDrift, Connector: connector, Version: version})
+			continue
+		}
+		packageDefinitionURL, ok := state.packageDefinitionURLs[connector][version]
+		if !ok {
+			report.Drifts = append(report.Drifts, Drift{Kind: MissingVersionDrift, Connector: connector, Version: version})
+			continue
+		}
+		// The versions of an aliased connector share the package of the parent
+		expectedURL := gcsPublicURL(d.bucketName, generateGCPObjectName(parent.Namespace, parent.Name, version))
+		if packageDefinitionURL != expectedURL {
+			report.Drifts = append(report.Drifts, Drift{Kind: PackageDefinitionURLDrift, Connector: connector, Version: version, Expected: expectedURL, Actual: packageDefinitionURL})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package publish
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func writeTestFile(t *testing.T, path string, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestDriftDetectorDetect(t *testing.T) {
+	registryFolder := t.TempDir()
+	connectorFolder := filepath.Join(registryFolder, "namespace1", "connector1")
+	writeTestFile(t, filepath.Join(connectorFolder, "metadata.json"), `{"overview": {"title": "Connector 1", "description": "A test connector", "latest_version": "v1.1.0"}}`)
+	writeTestFile(t, filepath.Join(connectorFolder, "README.md"), "# Connector 1")
+	writeTestFile(t, filepath.Join(connectorFolder, "releases", "v1.0.0", "connector-packaging.json"), `{"version": "v1.0.0", "uri": "https://example.com/v1.0.0.tgz"}`)
+	writeTestFile(t, filepath.Join(connectorFolder, "releases", "v1.1.0", "connector-packaging.json"), `{"version": "v1.1.0", "uri": "https://example.com/v1.1.0.tgz"}`)
+	writeTestFile(t, filepath.Join(connectorFolder, "releases", "v1.2.0", "connector-packaging.json"), `{"version": "v1.2.0", "uri": "https://example.com/v1.2.0.tgz"}`)
+	// The aliased connectors are compared against the releases of their parent
+	aliasFolder := filepath.Join(connectorFolder, "aliased_connectors", "alias1")
+	writeTestFile(t, filepath.Join(aliasFolder, "metadata.json"), `{"overview": {"title": "Alias 1", "description": "An aliased connector", "latest_version": "v1.1.0"}}`)
+	writeTestFile(t, filepath.Join(aliasFolder, "README.md"), "# Alias 1")
+	writeTestFile(t, filepath.Join(connectorFolder, "aliased_connectors", "alias2", "metadata.json"), `{"overview": {"title": "Alias 2"}}`)
+	writeTestFile(t, filepath.Join(registryFolder, "namespace1", "connector2", "metadata.json"), `{"overview": {"title": "Connector 2"}}`)
+
+	client := &MockGraphQLClient{}
+	client.On("Run", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		snapshot := args.Get(2).(*RegistrySnapshot)
+		snapshot.HubRegistryConnector = []HubRegistryConnector{{Name: "connector1", Namespace: "namespace1"}, {Name: "alias1", Namespace: "namespace1"}}
+		snapshot.ConnectorOverview = []ConnectorOverview{{Name: "connector1", Namespace: "namespace1", Title: "Connector 1",
+			Description: "An outdated description", Docs: "# Connector 1", LatestVersion: "v1.0.0"},
+			{Name: "alias1", Namespace: "namespace1", Title: "Alias 1", Description: "An aliased connector", Docs: "# Outdated alias", LatestVersion: "v1.1.0"}}
+		snapshot.HubRegistryConnectorVersion = []HubRegistryConnectorVersion{{Name: "connector1", Namespace: "namespace1", Version: "v1.0.0",
+			PackageDefinitionURL: "https://storage.googleapis.com/old-bucket/packages/namespace1/connector1/v1.0.0/package.tgz"},
+			{Name: "connector1", Namespace: "namespace1", Version: "v1.2.0", IsDeprecated: true,
+				PackageDefinitionURL: "https://storage.googleapis.com/test-bucket/packages/namespace1/connector1/v1.2.0/package.tgz"},
+			{Name: "alias1", Namespace: "namespace1", Version: "v1.0.0",
+				PackageDefinitionURL: "https://storage.googleapis.com/test-bucket/packages/namespace1/connector1/v1.0.0/package.tgz"},
+			{Name: "alias1", Namespace: "namespace1", Version: "v1.2.0",
+				PackageDefinitionURL: "https://storage.googleapis.com/test-bucket/packages/namespace1/alias1/v1.2.0/package.tgz"}}
+	}).Return(nil)
+
+	detector, err := NewDriftDetector(client, "key", "test-bucket", true)
+	assert.NoError(t, err)
+	report, err := detector.Detect(registryFolder)
+	assert.NoError(t, err)
+
+	connector1 := Connector{Name: "connector1", Namespace: "namespace1"}
+	alias1 := Connector{Name: "alias1", Namespace: "namespace1"}
+	assert.Equal(t, []Drift{
+		{Kind: StaleLatestVersionDrift, Connector: connector1, Expected: "v1.1.0", Actual: "v1.0.0"},
+		{Kind: StaleDescriptionDrift, Connector: connector1, Expected: "A test connector", Actual: "An outdated description"},
+		{Kind: PackageDefinitionURLDrift, Connector: connector1, Version: "v1.0.0",
+			Expected: "https://storage.googleapis.com/test-bucket/packages/namespace1/connector1/v1.0.0/package.tgz",
+			Actual:   "https://storage.googleapis.com/old-bucket/packages/namespace1/connector1/v1.0.0/package.tgz"},
+		{Kind: MissingVersionDrift, Connector: connector1, Version: "v1.1.0"},
+		{Kind: DeprecatedVersionDrift, Connector: connector1, Version: "v1.2.0"},
+		{Kind: StaleDocsDrift, Connector: alias1},
+		{Kind: MissingVersionDrift, Connector: alias1, Version: "v1.1.0"},
+		{Kind: PackageDefinitionURLDrift, Connector: alias1, Version: "v1.2.0",
+			Expected: "https://storage.googleapis.com/test-bucket/packages/namespace1/connector1/v1.2.0/package.tgz",
+			Actual:   "https://storage.googleapis.com/test-bucket/packages/namespace1/alias1/v1.2.0/package.tgz"},
+		{Kind: MissingConnectorDrift, Connector: Connector{Name: "alias2", Namespace: "namespace1"}},
+		{Kind: MissingConnectorDrift, Connector: Connector{Name: "connector2", Namespace: "namespace1"}},
+	}, report.Drifts)
+	assert.Len(t, report.Unfixable(), 4)
+
+	mutation := report.ReconcilingMutation()
+	overviewUpdates := mutation.Variables["connector_overview_updates"].([]ConnectorOverviewUpdate)
+	assert.Len(t, overviewUpdates, 2)
+	assert.Equal(t, "v1.1.0", *overviewUpdates[0].Set.LatestVersion)
+	assert.Equal(t, "A test connector", *overviewUpdates[0].Set.Description)
+	assert.Nil(t, overviewUpdates[0].Set.Docs)
+	assert.Equal(t, "alias1", overviewUpdates[1].Where.ConnectorName)
+	assert.Equal(t, "# Alias 1", *overviewUpdates[1].Set.Docs)
+	versionUpdates := mutation.Variables["connector_version_updates"].([]ConnectorVersionUpdate)
+	assert.Len(t, versionUpdates, 3)
+	assert.Equal(t, "https://storage.googleapis.com/test-bucket/packages/namespace1/connector1/v1.0.0/package.tgz", *versionUpdates[0].Set.PackageDefinitionURL)
+	assert.Equal(t, "v1.2.0", versionUpdates[1].Where.Version)
+	assert.False(t, *versionUpdates[1].Set.IsDeprecated)
+	assert.Nil(t, versionUpdates[1].Set.PackageDefinitionURL)
+	client.AssertExpectations(t)
+}
+
+func TestDriftDetectorDetectEmptyRegistryFolder(t *testing.T) {
+	client := &MockGraphQLClient{}
+	client.On("Run", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	detector, err := NewDriftDetector(client, "key", "test-bucket", true)
+	assert.NoError(t, err)
+	_, err = detector.Detect(filepath.Join(t.TempDir(), "registry"))
+	assert.ErrorContains(t, err, "no connector metadata found")
+}
@@ -0,0 +1,128 @@
+package publish
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrDryRun is returned when applying a plan with a publisher built in dry-run mode
+var ErrDryRun = errors.New("the publication plan cannot be applied in dry-run mode")
+
+// ConfigError is returned when a publisher is built with a missing or invalid setting
+type ConfigError struct {
+	Setting string
+	Err     error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid publisher configuration %s: %v", e.Setting, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectorError is the failure of planning the publication of a connector
+type ConnectorError struct {
+	Connector Connector
+	Err       error
+}
+
+func (e *ConnectorError) Error() string {
+	return fmt.Sprintf("%s/%s: %v", e.Connector.Namespace, e.Connector.Name, e.Err)
+}
+
+func (e *ConnectorError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectorVersionError is the failure of processing a single connector version
+type ConnectorVersionError struct {
+	Connector Connector
+	Version   string
+	Err       error
+}
+
+func (e ConnectorVersionError) Error() string {
+	return fmt.Sprintf("%s/%s %s: %v", e.Connector.Namespace, e.Connector.Name, e.Version, e.Err)
+}
+
+func (e ConnectorVersionError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectorVersionErrors is the aggregated report of the connector versions that failed to be processed
+type ConnectorVersionErrors []ConnectorVersionError
+
+func (errs ConnectorVersionErrors) Error() string {
+	sorted := make(ConnectorVersionErrors, len(errs))
+	copy(sorted, errs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Error() < sorted[j].Error()
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d connector version(s) failed:", len(sorted))
+	for _, err := range sorted {
+		fmt.Fprintf(&sb, "\n  - %s", err.Error())
+	}
+	return sb.String()
+}
+
+func (errs ConnectorVersionErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for i, err := range errs {
+		unwrapped[i] = err
+	}
+	return unwrapped
+}
+
+// RegistryError is the failure of a query or a mutation of the registry
+type RegistryError struct {
+	Err error
+}
+
+func (e *RegistryError) Error() string {
+	return fmt.Sprintf("failed to update the registry: %v", e.Err)
+}
+
+func (e *RegistryError) Unwrap() error {
+	return e.Err
+}
+
+// PackageDeletionError is returned when the packages of the withdrawn versions could not be deleted. The registry was
+// already updated, so the publication is not rolled back and only the deletions have to be retried.
+type PackageDeletionError struct {
+	Err ConnectorVersionErrors
+}
+
+func (e *PackageDeletionError) Error() string {
+	return fmt.Sprintf("the registry was updated, but the packages of the withdrawn versions could not be deleted: %v", e.Err)
+}
+
+func (e *PackageDeletionError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyError is returned when applying a plan fails. The side effects of the publication are rolled back,
+// RollbackErr is set if some of them could not be rolled back.
+type ApplyError struct {
+	Err         error
+	RollbackErr error
+}
+
+func (e *ApplyError) Error() string {
+	if e.RollbackErr == nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v\nFailed to roll back the publication: %v", e.Err, e.RollbackErr)
+}
+
+func (e *ApplyError) Unwrap() []error {
+	if e.RollbackErr == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Err, e.RollbackErr}
+}
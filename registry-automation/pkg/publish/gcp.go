@@ -0,0 +1,109 @@
+// Description: This file contains the functions to interact with Google Cloud Storage.
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"cloud.google.com/go/storage"
+)
+
+// deleteFile deletes a file from Google Cloud Storage
+func deleteFile(client StorageClientInterface, bucketName, objectName string) error {
+	bucket := client.Bucket(bucketName)
+	object := bucket.Object(objectName)
+
+	return object.Delete(context.Background())
+}
+
+// getObjectGeneration returns the generation of an object in Google Cloud Storage, or 0 if the object does not exist
+func getObjectGeneration(client StorageClientInterface, bucketName, objectName string) (int64, error) {
+	attrs, err := client.Bucket(bucketName).Object(objectName).Attrs(context.Background())
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return attrs.Generation, nil
+}
+
+// restoreObjectGeneration makes a previous generation of an object the live version of the object again,
+// the bucket must have object versioning enabled for the previous generation to be available
+func restoreObjectGeneration(client StorageClientInterface, bucketName, objectName string, generation int64) error {
+	bucket := client.Bucket(bucketName)
+	object := bucket.Object(objectName)
+
+	_, err := object.CopierFrom(object.Generation(generation)).Run(context.Background())
+	return err
+}
+
+// sha256ObjectMetadataKey is the key of the object metadata holding the sha256 digest of a connector package
+const sha256ObjectMetadataKey = "sha256"
+
+// uploadFile uploads a file to Google Cloud Storage
+// document this function with comments
+func uploadFile(client StorageClientInterface, bucketName, objectName, filePath string, metadata map[string]string) (string, error) {
+	bucket := client.Bucket(bucketName)
+	object := bucket.Object(objectName)
+	newCtx := context.Background()
+	wc := object.NewWriter(newCtx)
+	wc.Metadata = metadata
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(wc, file); err != nil {
+		return "", fmt.Errorf("failed to upload file: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return "", fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	// Return the public URL of the uploaded object.
+	publicURL := gcsPublicURL(bucketName, objectName)
+
+	fmt.Printf("File %s uploaded to bucket %s as %s and is available at %s.\n", filePath, bucketName, objectName, publicURL)
+	return publicURL, nil
+}
+
+// gcsPublicURL returns the public URL of an object in Google Cloud Storage
+func gcsPublicURL(bucketName, objectName string) string {
+	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", bucketName, objectName)
+}
+
+// objectMatchesFile returns true if the object exists in Google Cloud Storage, its CRC32C checksum
+// matches the checksum of the local file and its metadata contains the given metadata
+func objectMatchesFile(client StorageClientInterface, bucketName, objectName, filePath string, metadata map[string]string) (bool, error) {
+	attrs, err := client.Bucket(bucketName).Object(objectName).Attrs(context.Background())
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for key, value := range metadata {
+		if attrs.Metadata[key] != value {
+			return false, nil
+		}
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	hash := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	if _, err := io.Copy(hash, file); err != nil {
+		return false, fmt.Errorf("failed to compute the checksum of the file: %w", err)
+	}
+	return hash.Sum32() == attrs.CRC32C, nil
+}
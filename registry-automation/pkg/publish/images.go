@@ -0,0 +1,103 @@
+package publish
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/oci"
+)
+
+// ImageResolver fetches the manifests of the image references, along with their digest and their platforms, e.g. an
+// *oci.Resolver
+type ImageResolver interface {
+	Inspect(ref oci.Reference) (oci.Manifest, error)
+}
+
+// ImageDigest is the digest that the docker image of a published connector version is resolved to, the connector
+// version is published with the pinned image instead of its tag
+type ImageDigest struct {
+	Connector Connector `json:"connector"`
+	Version   string    `json:"version"`
+	Image     string    `json:"image"`
+	Digest    string    `json:"digest"`
+	// PinnedImage is the image pinned to its digest, e.g. ghcr.io/hasura/ndc-postgres:v1.0.0@sha256:<hex>
+	PinnedImage string `json:"pinned_image"`
+}
+
+// resolveImageDigests resolves the docker images of the connector versions to their digests, and checks that they are
+// available for the required platforms. The connector versions without docker image, like the ManagedDockerBuild
+// ones, are skipped.
+func (p *Publisher) resolveImageDigests(connectorVersions []ConnectorVersion) ([]ImageDigest, error) {
+	var withImage []ConnectorVersion
+	for _, connectorVersion := range sortedConnectorVersions(connectorVersions) {
+		if connectorVersion.Image != nil {
+			withImage = append(withImage, connectorVersion)
+		}
+	}
+
+	imageDigests := make([]ImageDigest, len(withImage))
+	var (
+		mu   sync.Mutex
+		errs ConnectorVersionErrors
+	)
+	forEachConcurrently(withImage, p.concurrency, func(i int, connectorVersion ConnectorVersion) {
+		connector := Connector{Name: connectorVersion.Name, Namespace: connectorVersion.Namespace}
+		imageDigest, err := resolveImageDigest(p.imageResolver, connector, connectorVersion.Version, *connectorVersion.Image)
+		if err != nil {
+			mu.Lock()
+			errs = append(errs, ConnectorVersionError{Connector: connector, Version: connectorVersion.Version, Err: err})
+			mu.Unlock()
+			return
+		}
+		imageDigests[i] = imageDigest
+	})
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	for _, imageDigest := range imageDigests {
+		fmt.Fprintf(os.Stderr, "Resolved the image %s of %s/%s %s to %s\n", imageDigest.Image,
+			imageDigest.Connector.Namespace, imageDigest.Connector.Name, imageDigest.Version, imageDigest.Digest)
+	}
+	return imageDigests, nil
+}
+
+// pinImageDigests replaces the images of the connector versions by their pinned images, so that a tag moved after the
+// publication doesn't change the published connector versions
+func pinImageDigests(connectorVersions []ConnectorVersion, imageDigests []ImageDigest) {
+	pinnedImages := make(map[Connector]map[string]string)
+	for _, imageDigest := range imageDigests {
+		if pinnedImages[imageDigest.Connector] == nil {
+			pinnedImages[imageDigest.Connector] = make(map[string]string)
+		}
+		pinnedImages[imageDigest.Connector][imageDigest.Version] = imageDigest.PinnedImage
+	}
+	for i, connectorVersion := range connectorVersions {
+		connector := Connector{Name: connectorVersion.Name, Namespace: connectorVersion.Namespace}
+		if pinnedImage, ok := pinnedImages[connector][connectorVersion.Version]; ok {
+			connectorVersions[i].Image = &pinnedImage
+		}
+	}
+}
+
+func resolveImageDigest(resolver ImageResolver, connector Connector, version, image string) (ImageDigest, error) {
+	ref, err := oci.ParseReference(image)
+	if err != nil {
+		return ImageDigest{}, err
+	}
+	manifest, err := resolver.Inspect(ref)
+	if err != nil {
+		return ImageDigest{}, fmt.Errorf("Failed to resolve the digest of the image: %w", err)
+	}
+	if err := manifest.CheckPlatforms(ref, oci.RequiredPlatforms); err != nil {
+		return ImageDigest{}, err
+	}
+	return ImageDigest{
+		Connector:   connector,
+		Version:     version,
+		Image:       image,
+		Digest:      manifest.Digest,
+		PinnedImage: ref.WithDigest(manifest.Digest).String(),
+	}, nil
+}
@@ -0,0 +1,102 @@
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// publicationJournal records the side effects of applying a publication plan, so that they can be
+// compensated in reverse order when a later step of the publication fails. Side effects can be recorded
+// concurrently.
+type publicationJournal struct {
+	mu      sync.Mutex
+	entries []journalEntry
+}
+
+// journalEntry is a side effect of the publication that can be compensated
+type journalEntry interface {
+	fmt.Stringer
+	compensate(p *Publisher) error
+}
+
+// gcsUploadEntry records the upload of an object to Google Cloud Storage
+type gcsUploadEntry struct {
+	Bucket     string
+	ObjectName string
+	// Generation of the object that was overwritten by the upload, 0 if the object did not exist.
+	// Restoring an overwritten object requires object versioning to be enabled on the bucket.
+	PreviousGeneration int64
+}
+
+func (e gcsUploadEntry) String() string {
+	return fmt.Sprintf("upload of gs://%s/%s", e.Bucket, e.ObjectName)
+}
+
+func (e gcsUploadEntry) compensate(p *Publisher) error {
+	if e.PreviousGeneration != 0 {
+		return restoreObjectGeneration(p.storageClient, e.Bucket, e.ObjectName, e.PreviousGeneration)
+	}
+	return deleteFile(p.storageClient, e.Bucket, e.ObjectName)
+}
+
+// cloudinaryUploadEntry records the upload of an asset to Cloudinary
+type cloudinaryUploadEntry struct {
+	PublicID string
+	// Overwritten is set when the upload replaced an existing asset with the same public ID
+	Overwritten bool
+}
+
+func (e cloudinaryUploadEntry) String() string {
+	return fmt.Sprintf("upload of the cloudinary asset %s", e.PublicID)
+}
+
+func (e cloudinaryUploadEntry) compensate(p *Publisher) error {
+	if e.Overwritten {
+		// Cloudinary doesn't keep the previous version of an overwritten asset, destroying the asset
+		// would leave the connector without a logo, so the new logo is kept instead.
+		return fmt.Errorf("the previous asset was overwritten and cannot be restored, the logo has to be restored manually")
+	}
+	result, err := p.cloudinary.Destroy(context.Background(), uploader.DestroyParams{PublicID: e.PublicID})
+	if err != nil {
+		return err
+	}
+	if result.Error.Message != "" {
+		return errors.New(result.Error.Message)
+	}
+	return nil
+}
+
+func (j *publicationJournal) record(entry journalEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = append(j.entries, entry)
+}
+
+// rollback compensates the recorded side effects in reverse order. Every side effect is compensated even
+// if compensating another one fails, the failures are returned together.
+func (j *publicationJournal) rollback(p *Publisher) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.entries) == 0 {
+		return nil
+	}
+	fmt.Println("Rolling back the side effects of the publication")
+
+	var errs []error
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		entry := j.entries[i]
+		if err := entry.compensate(p); err != nil {
+			errs = append(errs, fmt.Errorf("failed to roll back the %s: %w", entry, err))
+			continue
+		}
+		fmt.Printf("Rolled back the %s\n", entry)
+	}
+	j.entries = nil
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,375 @@
+package publish
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// PublicationPlan contains all the side effects of publishing the changed files of a PR: the uploads to
+// Google Cloud Storage and Cloudinary, and the payloads of the registry mutation.
+type PublicationPlan struct {
+	Env                      string                    `json:"env"`
+	NewConnectors            NewConnectorsInsertInput  `json:"new_connectors"`
+	ConnectorOverviewUpdates []ConnectorOverviewUpdate `json:"connector_overview_updates"`
+	ConnectorVersions        []ConnectorVersion        `json:"connector_versions"`
+	ConnectorVersionUpdates  []ConnectorVersionUpdate  `json:"connector_version_updates"`
+	PackageUploads           []PackageUpload           `json:"package_uploads"`
+	PackageDeletions         []PackageDeletion         `json:"package_deletions"`
+	LogoUploads              []LogoUpload              `json:"logo_uploads"`
+	// ImageDigests are the digests of the docker images of the published connector versions, empty unless the
+	// publisher resolves the images
+	ImageDigests []ImageDigest `json:"image_digests"`
+	// UnresolvedAliasLinks are the new aliased connectors whose links to the versions of their parent that are
+	// already in the registry are not in the plan, because the registry was not queried in dry-run mode
+	UnresolvedAliasLinks []UnresolvedAliasLink `json:"unresolved_alias_links"`
+}
+
+// UnresolvedAliasLink is a new aliased connector that is linked to the versions of its parent in the registry when
+// the plan is applied, which are not known to a dry-run plan computed without a registry client
+type UnresolvedAliasLink struct {
+	Alias  Connector `json:"alias"`
+	Parent Connector `json:"parent"`
+}
+
+// PackageUpload represents the upload of a connector version's tarball to Google Cloud Storage
+type PackageUpload struct {
+	Connector  Connector `json:"connector"`
+	Version    string    `json:"version"`
+	Bucket     string    `json:"bucket"`
+	ObjectName string    `json:"object_name"`
+	PublicURL  string    `json:"public_url"`
+	// SHA256 is the verified sha256 digest of the tarball, stored in the metadata of the object
+	SHA256 string `json:"sha256"`
+	// Path of the downloaded tarball on the local filesystem
+	LocalPath string `json:"-"`
+}
+
+// PackageDeletion represents the deletion of a withdrawn connector version's tarball from Google Cloud Storage
+type PackageDeletion struct {
+	Connector  Connector `json:"connector"`
+	Version    string    `json:"version"`
+	Bucket     string    `json:"bucket"`
+	ObjectName string    `json:"object_name"`
+}
+
+// LogoUpload represents the upload of a connector's logo to Cloudinary
+type LogoUpload struct {
+	Connector Connector `json:"connector"`
+	PublicID  string    `json:"public_id"`
+	Logo      Logo      `json:"logo"`
+}
+
+// PlanFormat is the format that a publication plan is written in
+type PlanFormat string
+
+const (
+	JSONPlanFormat     PlanFormat = "json"
+	MarkdownPlanFormat PlanFormat = "markdown"
+)
+
+func (f PlanFormat) IsValid() bool {
+	return f == JSONPlanFormat || f == MarkdownPlanFormat
+}
+
+func newPublicationPlan(env string) PublicationPlan {
+	return PublicationPlan{
+		Env: env,
+		NewConnectors: NewConnectorsInsertInput{
+			HubRegistryConnectors: make([]HubRegistryConnectorInsertInput, 0),
+			ConnectorOverviews:    make([]ConnectorOverviewInsert, 0),
+		},
+		ConnectorOverviewUpdates: make([]ConnectorOverviewUpdate, 0),
+		ConnectorVersions:        make([]ConnectorVersion, 0),
+		ConnectorVersionUpdates:  make([]ConnectorVersionUpdate, 0),
+		PackageUploads:           make([]PackageUpload, 0),
+		PackageDeletions:         make([]PackageDeletion, 0),
+		LogoUploads:              make([]LogoUpload, 0),
+		ImageDigests:             make([]ImageDigest, 0),
+		UnresolvedAliasLinks:     make([]UnresolvedAliasLink, 0),
+	}
+}
+
+func newPackageUpload(connector Connector, version string, bucketName string, localPath string, sha256 string) PackageUpload {
+	objectName := generateGCPObjectName(connector.Namespace, connector.Name, version)
+	return PackageUpload{
+		Connector:  connector,
+		Version:    version,
+		Bucket:     bucketName,
+		ObjectName: objectName,
+		PublicURL:  gcsPublicURL(bucketName, objectName),
+		SHA256:     sha256,
+		LocalPath:  localPath,
+	}
+}
+
+// objectMetadata returns the metadata of the uploaded object, so that consumers can verify the package
+func (u PackageUpload) objectMetadata() map[string]string {
+	return map[string]string{sha256ObjectMetadataKey: u.SHA256}
+}
+
+func newPackageDeletion(connector Connector, version string, bucketName string) PackageDeletion {
+	return PackageDeletion{
+		Connector:  connector,
+		Version:    version,
+		Bucket:     bucketName,
+		ObjectName: generateGCPObjectName(connector.Namespace, connector.Name, version),
+	}
+}
+
+// newConnectorVersionDeprecation returns the update that withdraws the connector version from the registry
+func newConnectorVersionDeprecation(connector Connector, version string) ConnectorVersionUpdate {
+	isDeprecated := true
+	var connectorVersionUpdate ConnectorVersionUpdate
+	connectorVersionUpdate.Set.IsDeprecated = &isDeprecated
+	connectorVersionUpdate.Where = ConnectorVersionWhereClause{
+		ConnectorName:      connector.Name,
+		ConnectorNamespace: connector.Namespace,
+		Version:            version,
+	}
+	return connectorVersionUpdate
+}
+
+func newLogoUpload(connector Connector, logo Logo) LogoUpload {
+	return LogoUpload{
+		Connector: connector,
+		PublicID:  cloudinaryPublicID(connector),
+		Logo:      logo,
+	}
+}
+
+// plannedLogoURL is the placeholder used for the logo URL of a connector until its logo is
+// uploaded to cloudinary, the actual URL is only known after the upload.
+func plannedLogoURL(connector Connector) string {
+	return fmt.Sprintf("cloudinary://%s", cloudinaryPublicID(connector))
+}
+
+// resolveLogoURL replaces the placeholder logo URL of the connector with the URL of the uploaded logo
+func (p *PublicationPlan) resolveLogoURL(connector Connector, logoURL string) {
+	for i := range p.NewConnectors.ConnectorOverviews {
+		overview := &p.NewConnectors.ConnectorOverviews[i]
+		if overview.Namespace == connector.Namespace && overview.Name == connector.Name {
+			overview.Logo = logoURL
+		}
+	}
+	for i := range p.ConnectorOverviewUpdates {
+		update := &p.ConnectorOverviewUpdates[i]
+		if update.Set.Logo != nil && update.Where.ConnectorNamespace == connector.Namespace && update.Where.ConnectorName == connector.Name {
+			*update.Set.Logo = logoURL
+		}
+	}
+}
+
+// requiresRegistryMutation returns true if the registry is updated when the plan is applied: when connectors are
+// inserted, connector overviews are updated, or connector versions are published or withdrawn.
+func (p *PublicationPlan) requiresRegistryMutation() bool {
+	return len(p.NewConnectors.ConnectorOverviews) > 0 || len(p.NewConnectors.HubRegistryConnectors) > 0 ||
+		len(p.ConnectorOverviewUpdates) > 0 || len(p.ConnectorVersions) > 0 || len(p.ConnectorVersionUpdates) > 0
+}
+
+// WritePublicationPlan writes the plan in the given format
+func WritePublicationPlan(w io.Writer, plan PublicationPlan, format PlanFormat) error {
+	switch format {
+	case JSONPlanFormat:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(plan)
+	case MarkdownPlanFormat:
+		_, err := io.WriteString(w, renderPublicationPlanMarkdown(plan))
+		return err
+	default:
+		return fmt.Errorf("unsupported plan format: %s", format)
+	}
+}
+
+// renderPublicationPlanMarkdown renders a summary of the plan, meant to be posted as a PR comment
+func renderPublicationPlanMarkdown(plan PublicationPlan) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "## Hub registry publication plan (%s)\n\n", plan.Env)
+
+	if len(plan.NewConnectors.ConnectorOverviews) > 0 {
+		sb.WriteString("### New connectors\n\n")
+		sb.WriteString("| Connector | Title | Latest version | Verified | Hosted by Hasura |\n")
+		sb.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, overview := range plan.NewConnectors.ConnectorOverviews {
+			fmt.Fprintf(&sb, "| `%s/%s` | %s | `%s` | %t | %t |\n", overview.Namespace, overview.Name,
+				overview.Title, overview.LatestVersion, overview.IsVerified, overview.IsHosted)
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(plan.ConnectorOverviewUpdates) > 0 {
+		sb.WriteString("### Connector overview updates\n\n")
+		sb.WriteString("| Connector | Updated fields |\n")
+		sb.WriteString("| --- | --- |\n")
+		for _, update := range plan.ConnectorOverviewUpdates {
+			fmt.Fprintf(&sb, "| `%s/%s` | %s |\n", update.Where.ConnectorNamespace, update.Where.ConnectorName,
+				strings.Join(updatedOverviewFields(update), ", "))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(plan.ConnectorVersions) > 0 {
+		sb.WriteString("### Connector versions\n\n")
+		sb.WriteString("| Connector | Version | Packaging spec | Type | Image | Package definition URL |\n")
+		sb.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		for _, connectorVersion := range sortedConnectorVersions(plan.ConnectorVersions) {
+			image := "-"
+			if connectorVersion.Image != nil {
+				image = fmt.Sprintf("`%s`", *connectorVersion.Image)
+			}
+			fmt.Fprintf(&sb, "| `%s/%s` | `%s` | %s | %s | %s | %s |\n", connectorVersion.Namespace, connectorVersion.Name,
+				connectorVersion.Version, describePackagingSpec(connectorVersion), connectorVersion.Type, image, connectorVersion.PackageDefinitionURL)
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(plan.ConnectorVersionUpdates) > 0 {
+		sb.WriteString("### Withdrawn connector versions\n\n")
+		sb.WriteString("| Connector | Version |\n")
+		sb.WriteString("| --- | --- |\n")
+		for _, update := range plan.ConnectorVersionUpdates {
+			fmt.Fprintf(&sb, "| `%s/%s` | `%s` |\n", update.Where.ConnectorNamespace, update.Where.ConnectorName, update.Where.Version)
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(plan.UnresolvedAliasLinks) > 0 {
+		sb.WriteString("### Unresolved aliased connector links\n\n")
+		sb.WriteString("The new aliased connectors are also linked to the versions of their parent that are already in the registry, ")
+		sb.WriteString("which are not listed above because the registry was not queried.\n\n")
+		sb.WriteString("| Aliased connector | Parent connector |\n")
+		sb.WriteString("| --- | --- |\n")
+		for _, link := range plan.UnresolvedAliasLinks {
+			fmt.Fprintf(&sb, "| `%s/%s` | `%s/%s` |\n", link.Alias.Namespace, link.Alias.Name, link.Parent.Namespace, link.Parent.Name)
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(plan.PackageUploads) > 0 {
+		sb.WriteString("### Google Cloud Storage uploads\n\n")
+		sb.WriteString("| Bucket | Object |\n")
+		sb.WriteString("| --- | --- |\n")
+		for _, packageUpload := range plan.PackageUploads {
+			fmt.Fprintf(&sb, "| `%s` | `%s` |\n", packageUpload.Bucket, packageUpload.ObjectName)
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(plan.PackageDeletions) > 0 {
+		sb.WriteString("### Google Cloud Storage deletions\n\n")
+		sb.WriteString("| Bucket | Object |\n")
+		sb.WriteString("| --- | --- |\n")
+		for _, packageDeletion := range plan.PackageDeletions {
+			fmt.Fprintf(&sb, "| `%s` | `%s` |\n", packageDeletion.Bucket, packageDeletion.ObjectName)
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(plan.LogoUploads) > 0 {
+		sb.WriteString("### Cloudinary uploads\n\n")
+		sb.WriteString("| Public ID | Logo |\n")
+		sb.WriteString("| --- | --- |\n")
+		for _, logoUpload := range plan.LogoUploads {
+			fmt.Fprintf(&sb, "| `%s` | `%s` |\n", logoUpload.PublicID, logoUpload.Logo.Path)
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(plan.ImageDigests) > 0 {
+		sb.WriteString("### Docker image digests\n\n")
+		sb.WriteString("| Connector | Version | Image | Digest |\n")
+		sb.WriteString("| --- | --- | --- | --- |\n")
+		for _, imageDigest := range plan.ImageDigests {
+			fmt.Fprintf(&sb, "| `%s/%s` | `%s` | `%s` | `%s` |\n", imageDigest.Connector.Namespace, imageDigest.Connector.Name,
+				imageDigest.Version, imageDigest.Image, imageDigest.Digest)
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// WritePublicationSummary writes the connector versions published by the plan, along with their packaging spec
+func WritePublicationSummary(w io.Writer, plan PublicationPlan) error {
+	if len(plan.ConnectorVersions) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "Published %d connector version(s):\n", len(plan.ConnectorVersions)); err != nil {
+		return err
+	}
+	for _, connectorVersion := range sortedConnectorVersions(plan.ConnectorVersions) {
+		if _, err := fmt.Fprintf(w, "  - %s/%s %s: %s\n", connectorVersion.Namespace, connectorVersion.Name,
+			connectorVersion.Version, describePackagingSpec(connectorVersion)); err != nil {
+			return err
+		}
+	}
+	if len(plan.ImageDigests) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "Pinned %d docker image(s):\n", len(plan.ImageDigests)); err != nil {
+		return err
+	}
+	for _, imageDigest := range plan.ImageDigests {
+		if _, err := fmt.Fprintf(w, "  - %s/%s %s: %s\n", imageDigest.Connector.Namespace, imageDigest.Connector.Name,
+			imageDigest.Version, imageDigest.PinnedImage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describePackagingSpec describes the packaging spec of a connector version, e.g. "v2, ndc spec v0.2, BinaryInline CLI plugin"
+func describePackagingSpec(connectorVersion ConnectorVersion) string {
+	specVersion := connectorVersion.PackagingSpecVersion
+	if specVersion == "" {
+		specVersion = "v1"
+	}
+	parts := []string{specVersion}
+	if connectorVersion.NDCSpecGeneration != nil {
+		parts = append(parts, fmt.Sprintf("ndc spec %s", *connectorVersion.NDCSpecGeneration))
+	}
+	if connectorVersion.CLIPluginKind != nil {
+		parts = append(parts, fmt.Sprintf("%s CLI plugin", *connectorVersion.CLIPluginKind))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func updatedOverviewFields(update ConnectorOverviewUpdate) []string {
+	var fields []string
+	if update.Set.Docs != nil {
+		fields = append(fields, "docs")
+	}
+	if update.Set.Logo != nil {
+		fields = append(fields, "logo")
+	}
+	if update.Set.LatestVersion != nil {
+		fields = append(fields, fmt.Sprintf("latest_version (`%s`)", *update.Set.LatestVersion))
+	}
+	if update.Set.Title != nil {
+		fields = append(fields, "title")
+	}
+	if update.Set.Description != nil {
+		fields = append(fields, "description")
+	}
+	return fields
+}
+
+// sortedConnectorVersions returns a copy of the connector versions, sorted to render them in a stable order
+func sortedConnectorVersions(connectorVersions []ConnectorVersion) []ConnectorVersion {
+	sorted := make([]ConnectorVersion, len(connectorVersions))
+	copy(sorted, connectorVersions)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return sorted[i].Version < sorted[j].Version
+	})
+	return sorted
+}
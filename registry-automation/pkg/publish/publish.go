@@ -0,0 +1,980 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+)
+
+// ReadChangedFiles reads the JSON file listing the added, modified and deleted files of a PR
+func ReadChangedFiles(path string) (ChangedFiles, error) {
+	var changedFiles ChangedFiles
+
+	changedFilesContent, err := os.Open(path)
+	if err != nil {
+		return changedFiles, fmt.Errorf("failed to open the file: %v, err: %w", path, err)
+	}
+	defer changedFilesContent.Close()
+
+	// Read the changed file's contents. This file contains all the changed files in the PR
+	changedFilesByteValue, err := io.ReadAll(changedFilesContent)
+	if err != nil {
+		return changedFiles, fmt.Errorf("failed to read the changed files JSON file: %w", err)
+	}
+
+	if err := json.Unmarshal(changedFilesByteValue, &changedFiles); err != nil {
+		return changedFiles, fmt.Errorf("failed to unmarshal the changed files content: %w", err)
+	}
+
+	return changedFiles, nil
+}
+
+type fileProcessor struct {
+	regex               *regexp.Regexp
+	newFileHandler      func(matches []string, file string)
+	modifiedFileHandler func(matches []string, file string) error
+	deletedFileHandler  func(matches []string, file string)
+}
+
+type fileChange string
+
+const (
+	addedFile    fileChange = "newly added"
+	modifiedFile fileChange = "modified"
+	deletedFile  fileChange = "deleted"
+)
+
+// ProcessChangedFiles categorizes changes in connector files within a registry system.
+// It handles new, modified and deleted files including metadata, logos, READMEs, and connector versions.
+//
+// The function takes a ChangedFiles struct containing slices of added, modified and deleted filenames,
+// and returns a ProcessedChangedFiles struct with categorized changes.
+//
+// Files are processed based on their path and type:
+//   - metadata.json: New, modified or deleted connectors
+//   - logo.(png|svg): New, modified or deleted logos
+//   - README.md: New, modified or deleted READMEs
+//   - connector-packaging.json: New or deleted connector versions
+//   - aliased_connectors/<alias>/(metadata.json|logo.(png|svg)|README.md): The same files of the aliased connectors
+//
+// Any files not matching these patterns are logged as skipped.
+//
+// Example usage:
+//
+//	changedFiles := ChangedFiles{
+//		Added: []string{"registry/namespace1/connector1/metadata.json"},
+//		Modified: []string{"registry/namespace2/connector2/README.md"},
+//	}
+//	result := ProcessChangedFiles(changedFiles)
+func ProcessChangedFiles(changedFiles ChangedFiles) ProcessedChangedFiles {
+	result := ProcessedChangedFiles{
+		NewConnectorVersions: make(map[Connector]map[string]string),
+		ModifiedLogos:        make(map[Connector]Logo),
+		ModifiedReadmes:      make(map[Connector]string),
+		NewConnectors:        make(map[Connector]MetadataFile),
+		NewLogos:             make(map[Connector]Logo),
+		NewReadmes:           make(map[Connector]string),
+		ModifiedConnectors:   make(map[Connector]MetadataFile),
+		DeletedConnectors:    make(map[Connector]MetadataFile),
+		DeletedLogos:         make(map[Connector]Logo),
+		DeletedReadmes:       make(map[Connector]string),
+		DeletedVersions:      make(map[Connector]map[string]string),
+		AliasedConnectors:    make(map[Connector]Connector),
+	}
+
+	// The aliased connectors are in the aliased_connectors folder of their parent, e.g.
+	// registry/hasura/postgres/aliased_connectors/neon/metadata.json, they are published as connectors of their own
+	aliasedConnector := func(matches []string) Connector {
+		alias := Connector{Name: matches[3], Namespace: matches[1]}
+		result.AliasedConnectors[alias] = Connector{Name: matches[2], Namespace: matches[1]}
+		return alias
+	}
+
+	processors := []fileProcessor{
+		{
+			regex: regexp.MustCompile(`^registry/([^/]+)/([^/]+)/metadata.json$`),
+			newFileHandler: func(matches []string, file string) {
+				connector := Connector{Name: matches[2], Namespace: matches[1]}
+				result.NewConnectors[connector] = MetadataFile(file)
+				fmt.Fprintf(os.Stderr, "Processing metadata file for new connector: %s\n", connector.Name)
+			},
+			modifiedFileHandler: func(matches []string, file string) error {
+				connector := Connector{Name: matches[2], Namespace: matches[1]}
+				result.ModifiedConnectors[connector] = MetadataFile(file)
+				fmt.Fprintf(os.Stderr, "Processing metadata file for modified connector: %s\n", connector.Name)
+				return nil
+			},
+			deletedFileHandler: func(matches []string, file string) {
+				connector := Connector{Name: matches[2], Namespace: matches[1]}
+				result.DeletedConnectors[connector] = MetadataFile(file)
+				fmt.Fprintf(os.Stderr, "Processing metadata file for deleted connector: %s\n", connector.Name)
+			},
+		},
+		{
+			regex: regexp.MustCompile(`^registry/([^/]+)/([^/]+)/logo\.(png|svg)$`),
+			newFileHandler: func(matches []string, file string) {
+				connector := Connector{Name: matches[2], Namespace: matches[1]}
+				result.NewLogos[connector] = Logo{Path: file, Extension: LogoExtension(matches[3])}
+				fmt.Fprintf(os.Stderr, "Processing logo file for new connector: %s\n", connector.Name)
+			},
+			modifiedFileHandler: func(matches []string, file string) error {
+				connector := Connector{Name: matches[2], Namespace: matches[1]}
+				result.ModifiedLogos[connector] = Logo{Path: file, Extension: LogoExtension(matches[3])}
+				fmt.Fprintf(os.Stderr, "Processing logo file for modified connector: %s\n", connector.Name)
+				return nil
+			},
+			deletedFileHandler: func(matches []string, file string) {
+				connector := Connector{Name: matches[2], Namespace: matches[1]}
+				result.DeletedLogos[connector] = Logo{Path: file, Extension: LogoExtension(matches[3])}
+				fmt.Fprintf(os.Stderr, "Processing deleted logo file of connector: %s\n", connector.Name)
+			},
+		},
+		{
+			regex: regexp.MustCompile(`^registry/([^/]+)/([^/]+)/README\.md$`),
+			newFileHandler: func(matches []string, file string) {
+				connector := Connector{Name: matches[2], Namespace: matches[1]}
+				result.NewReadmes[connector] = file
+				fmt.Fprintf(os.Stderr, "Processing README file for new connector: %s\n", connector.Name)
+			},
+			modifiedFileHandler: func(matches []string, file string) error {
+				connector := Connector{Name: matches[2], Namespace: matches[1]}
+				result.ModifiedReadmes[connector] = file
+				fmt.Fprintf(os.Stderr, "Processing README file for modified connector: %s\n", connector.Name)
+				return nil
+			},
+			deletedFileHandler: func(matches []string, file string) {
+				connector := Connector{Name: matches[2], Namespace: matches[1]}
+				result.DeletedReadmes[connector] = file
+				fmt.Fprintf(os.Stderr, "Processing deleted README file of connector: %s\n", connector.Name)
+			},
+		},
+		{
+			regex: regexp.MustCompile(`^registry/([^/]+)/([^/]+)/aliased_connectors/([^/]+)/metadata\.json$`),
+			newFileHandler: func(matches []string, file string) {
+				connector := aliasedConnector(matches)
+				result.NewConnectors[connector] = MetadataFile(file)
+				fmt.Fprintf(os.Stderr, "Processing metadata file for new aliased connector: %s\n", connector.Name)
+			},
+			modifiedFileHandler: func(matches []string, file string) error {
+				connector := aliasedConnector(matches)
+				result.ModifiedConnectors[connector] = MetadataFile(file)
+				fmt.Fprintf(os.Stderr, "Processing metadata file for modified aliased connector: %s\n", connector.Name)
+				return nil
+			},
+			deletedFileHandler: func(matches []string, file string) {
+				connector := aliasedConnector(matches)
+				result.DeletedConnectors[connector] = MetadataFile(file)
+				fmt.Fprintf(os.Stderr, "Processing metadata file for deleted aliased connector: %s\n", connector.Name)
+			},
+		},
+		{
+			regex: regexp.MustCompile(`^registry/([^/]+)/([^/]+)/aliased_connectors/([^/]+)/logo\.(png|svg)$`),
+			newFileHandler: func(matches []string, file string) {
+				connector := aliasedConnector(matches)
+				result.NewLogos[connector] = Logo{Path: file, Extension: LogoExtension(matches[4])}
+				fmt.Fprintf(os.Stderr, "Processing logo file for new aliased connector: %s\n", connector.Name)
+			},
+			modifiedFileHandler: func(matches []string, file string) error {
+				connector := aliasedConnector(matches)
+				result.ModifiedLogos[connector] = Logo{Path: file, Extension: LogoExtension(matches[4])}
+				fmt.Fprintf(os.Stderr, "Processing logo file for modified aliased connector: %s\n", connector.Name)
+				return nil
+			},
+			deletedFileHandler: func(matches []string, file string) {
+				connector := aliasedConnector(matches)
+				result.DeletedLogos[connector] = Logo{Path: file, Extension: LogoExtension(matches[4])}
+				fmt.Fprintf(os.Stderr, "Processing deleted logo file of aliased connector: %s\n", connector.Name)
+			},
+		},
+		{
+			regex: regexp.MustCompile(`^registry/([^/]+)/([^/]+)/aliased_connectors/([^/]+)/README\.md$`),
+			newFileHandler: func(matches []string, file string) {
+				connector := aliasedConnector(matches)
+				result.NewReadmes[connector] = file
+				fmt.Fprintf(os.Stderr, "Processing README file for new aliased connector: %s\n", connector.Name)
+			},
+			modifiedFileHandler: func(matches []string, file string) error {
+				connector := aliasedConnector(matches)
+				result.ModifiedReadmes[connector] = file
+				fmt.Fprintf(os.Stderr, "Processing README file for modified aliased connector: %s\n", connector.Name)
+				return nil
+			},
+			deletedFileHandler: func(matches []string, file string) {
+				connector := aliasedConnector(matches)
+				result.DeletedReadmes[connector] = file
+				fmt.Fprintf(os.Stderr, "Processing deleted README file of aliased connector: %s\n", connector.Name)
+			},
+		},
+		{
+			regex: regexp.MustCompile(`^registry/([^/]+)/([^/]+)/releases/([^/]+)/connector-packaging\.json$`),
+			newFileHandler: func(matches []string, file string) {
+				connector := Connector{Name: matches[2], Namespace: matches[1]}
+				version := matches[3]
+				if _, exists := result.NewConnectorVersions[connector]; !exists {
+					result.NewConnectorVersions[connector] = make(map[string]string)
+				}
+				result.NewConnectorVersions[connector][version] = file
+			},
+			modifiedFileHandler: func(matches []string, file string) error {
+				return fmt.Errorf("Connector packaging files (%s) are immutable and should not be changed", file)
+			},
+			deletedFileHandler: func(matches []string, file string) {
+				connector := Connector{Name: matches[2], Namespace: matches[1]}
+				version := matches[3]
+				if _, exists := result.DeletedVersions[connector]; !exists {
+					result.DeletedVersions[connector] = make(map[string]string)
+				}
+				result.DeletedVersions[connector][version] = file
+				fmt.Fprintf(os.Stderr, "Processing deleted version %s of connector: %s\n", version, connector.Name)
+			},
+		},
+	}
+
+	processFile := func(file string, change fileChange) {
+		for _, processor := range processors {
+			if matches := processor.regex.FindStringSubmatch(file); matches != nil {
+				switch change {
+				case modifiedFile:
+					processError := processor.modifiedFileHandler(matches, file)
+					if processError != nil {
+						fmt.Fprintf(os.Stderr, "Error processing modified %s file: %s: %v\n", matches[2], file, processError)
+					}
+				case deletedFile:
+					processor.deletedFileHandler(matches, file)
+				default:
+					processor.newFileHandler(matches, file)
+				}
+				return
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Skipping %s file: %s\n", change, file)
+	}
+
+	for _, file := range changedFiles.Added {
+		processFile(file, addedFile)
+	}
+
+	for _, file := range changedFiles.Modified {
+		processFile(file, modifiedFile)
+	}
+
+	for _, file := range changedFiles.Deleted {
+		processFile(file, deletedFile)
+	}
+
+	return result
+}
+
+// processModifiedConnectors processes the modified connectors and updates the connector metadata in the registry
+// This function updates the registry with the latest version, title, and description of the connector
+func processModifiedConnector(metadataFile MetadataFile, connector Connector) (ConnectorOverviewUpdate, error) {
+	// Iterate over the modified connectors and update the connectors in the registry
+	var connectorOverviewUpdate ConnectorOverviewUpdate
+	connectorMetadata, err := readJSONFile[ndchub.ConnectorMetadata](string(metadataFile))
+	if err != nil {
+		return connectorOverviewUpdate, fmt.Errorf("Failed to parse the connector metadata file: %v", err)
+	}
+
+	connectorOverviewUpdate = ConnectorOverviewUpdate{
+		Set: struct {
+			Docs          *string `json:"docs,omitempty"`
+			Logo          *string `json:"logo,omitempty"`
+			LatestVersion *string `json:"latest_version,omitempty"`
+			Title         *string `json:"title,omitempty"`
+			Description   *string `json:"description,omitempty"`
+		}{
+			LatestVersion: &connectorMetadata.Overview.LatestVersion,
+			Title:         &connectorMetadata.Overview.Title,
+			Description:   &connectorMetadata.Overview.Description,
+		},
+		Where: WhereClause{
+			ConnectorName:      connector.Name,
+			ConnectorNamespace: connector.Namespace,
+		},
+	}
+	return connectorOverviewUpdate, nil
+}
+
+// processNewConnector builds the registry payloads of a newly added connector. The logo of the
+// connector is uploaded later, so the overview points to a placeholder until the plan is applied.
+func (p *Publisher) processNewConnector(connector Connector, metadataFile MetadataFile, logo Logo) (ConnectorOverviewInsert, HubRegistryConnectorInsertInput, error) {
+	// Process the newly added connector
+	// Get the string value from metadataFile
+	var connectorOverviewAndAuthor ConnectorOverviewInsert
+	var hubRegistryConnectorInsertInput HubRegistryConnectorInsertInput
+
+	connectorMetadata, err := readJSONFile[ndchub.ConnectorMetadata](string(metadataFile))
+	if err != nil {
+		return connectorOverviewAndAuthor, hubRegistryConnectorInsertInput, fmt.Errorf("Failed to parse the connector metadata file: %v", err)
+	}
+
+	// The README is next to metadata.json, in the folder of the connector or of the aliased connector
+	docs, err := readFile(filepath.Join(filepath.Dir(string(metadataFile)), "README.md"))
+
+	if err != nil {
+		return connectorOverviewAndAuthor, hubRegistryConnectorInsertInput, fmt.Errorf("Failed to read the README file of the connector: %s : %v", connector.Name, err)
+	}
+
+	if logo.Path == "" {
+		return connectorOverviewAndAuthor, hubRegistryConnectorInsertInput, fmt.Errorf("Failed to find the logo of the new connector: %s", connector.Name)
+	}
+
+	// The registry is not queried in dry-run mode, since no credentials are required to compute the plan
+	if !p.dryRun {
+		// Get connector info from the registry
+		connectorInfo, err := p.getConnectorInfoFromRegistry(connector.Namespace, connector.Name)
+		if err != nil {
+			return connectorOverviewAndAuthor, hubRegistryConnectorInsertInput,
+				fmt.Errorf("Failed to get the connector info from the registry: %v", err)
+		}
+
+		// Check if the connector already exists in the registry
+		if len(connectorInfo.HubRegistryConnector) > 0 {
+			if p.env == StagingEnv {
+				fmt.Printf("Connector already exists in the registry: %s/%s\n", connector.Namespace, connector.Name)
+				fmt.Println("The connector is going to be overwritten in the registry.")
+
+			} else {
+
+				return connectorOverviewAndAuthor, hubRegistryConnectorInsertInput, fmt.Errorf("Attempting to create a new hub connector, but the connector already exists in the registry: %s/%s", connector.Namespace, connector.Name)
+			}
+
+		}
+	}
+
+	hubRegistryConnectorInsertInput = HubRegistryConnectorInsertInput{
+		Name:      connector.Name,
+		Namespace: connector.Namespace,
+		Title:     connectorMetadata.Overview.Title,
+	}
+
+	connectorOverviewAndAuthor = ConnectorOverviewInsert{
+		Name:          connector.Name,
+		Namespace:     connector.Namespace,
+		Docs:          string(docs),
+		Logo:          plannedLogoURL(connector),
+		Title:         connectorMetadata.Overview.Title,
+		Description:   connectorMetadata.Overview.Description,
+		IsVerified:    connectorMetadata.IsVerified,
+		IsHosted:      connectorMetadata.IsHostedByHasura,
+		LatestVersion: connectorMetadata.Overview.LatestVersion,
+		Author: ConnectorAuthorNestedInsert{
+			Data: ConnectorAuthor{
+				Name:         connectorMetadata.Author.Name,
+				SupportEmail: connectorMetadata.Author.SupportEmail,
+				Website:      connectorMetadata.Author.Homepage,
+			},
+		},
+	}
+
+	return connectorOverviewAndAuthor, hubRegistryConnectorInsertInput, nil
+}
+
+// buildPublicationPlan computes all the uploads and the registry payloads required to publish the changed files.
+// The connector version tarballs are downloaded to inspect them, but nothing is uploaded and the registry is not mutated.
+func (p *Publisher) buildPublicationPlan(processedChangedFiles ProcessedChangedFiles) (PublicationPlan, error) {
+	plan := newPublicationPlan(p.env)
+
+	newlyAddedConnectorVersions := processedChangedFiles.NewConnectorVersions
+	modifiedLogos := processedChangedFiles.ModifiedLogos
+	modifiedReadmes := processedChangedFiles.ModifiedReadmes
+
+	newlyAddedConnectors := processedChangedFiles.NewConnectors
+	modifiedConnectors := processedChangedFiles.ModifiedConnectors
+	newLogos := processedChangedFiles.NewLogos
+
+	deletedConnectors := processedChangedFiles.DeletedConnectors
+	deletedVersions := processedChangedFiles.DeletedVersions
+
+	if err := checkDeletedConnectorFiles(processedChangedFiles); err != nil {
+		return plan, err
+	}
+
+	// A deleted logo can only be replaced by a logo with another extension, which is then a modified logo
+	for connector := range processedChangedFiles.DeletedLogos {
+		if _, isDeletedConnector := deletedConnectors[connector]; isDeletedConnector {
+			continue
+		}
+		if _, isNewConnector := newlyAddedConnectors[connector]; !isNewConnector {
+			if modifiedLogos == nil {
+				modifiedLogos = make(ModifiedLogos)
+			}
+			modifiedLogos[connector] = newLogos[connector]
+		}
+	}
+
+	if len(newlyAddedConnectors) > 0 {
+		fmt.Fprintln(os.Stderr, "New connectors to be added to the registry: ", newlyAddedConnectors)
+
+		for connector, metadataFile := range newlyAddedConnectors {
+			// Find the logo corresponding to the connector from the newLogos map
+			logo := newLogos[connector]
+			connectorOverviewAndAuthor, hubRegistryConnector, err := p.processNewConnector(connector, metadataFile, logo)
+
+			if err != nil {
+				return plan, &ConnectorError{Connector: connector, Err: fmt.Errorf("Failed to process the new connector: %w", err)}
+			}
+			plan.NewConnectors.ConnectorOverviews = append(plan.NewConnectors.ConnectorOverviews, connectorOverviewAndAuthor)
+			plan.NewConnectors.HubRegistryConnectors = append(plan.NewConnectors.HubRegistryConnectors, hubRegistryConnector)
+			plan.LogoUploads = append(plan.LogoUploads, newLogoUpload(connector, logo))
+		}
+	}
+
+	if len(modifiedConnectors) > 0 {
+		fmt.Fprintln(os.Stderr, "Modified connectors: ", modifiedConnectors)
+		// Process the modified connectors
+		for connector, metadataFile := range modifiedConnectors {
+			connectorOverviewUpdate, err := processModifiedConnector(metadataFile, connector)
+			if err != nil {
+				return plan, &ConnectorError{Connector: connector, Err: fmt.Errorf("Failed to process the modified connector: %w", err)}
+			}
+			plan.ConnectorOverviewUpdates = append(plan.ConnectorOverviewUpdates, connectorOverviewUpdate)
+		}
+	}
+
+	if len(newlyAddedConnectorVersions) > 0 {
+		newlyAddedConnectors := make(map[Connector]bool)
+		for connector := range newlyAddedConnectorVersions {
+			newlyAddedConnectors[connector] = true
+		}
+		connectorVersions, packageUploads, err := p.processNewlyAddedConnectorVersions(newlyAddedConnectorVersions, newlyAddedConnectors)
+		if err != nil {
+			return plan, err
+		}
+		// The aliased connectors share the pinned images of their parent, which are only resolved once
+		if p.imageResolver != nil {
+			imageDigests, err := p.resolveImageDigests(connectorVersions)
+			if err != nil {
+				return plan, err
+			}
+			pinImageDigests(connectorVersions, imageDigests)
+			plan.ImageDigests = append(plan.ImageDigests, imageDigests...)
+		}
+		plan.ConnectorVersions = append(plan.ConnectorVersions, connectorVersions...)
+		plan.PackageUploads = append(plan.PackageUploads, packageUploads...)
+	}
+
+	if len(modifiedReadmes) > 0 {
+		readMeUpdates, err := processModifiedReadmes(modifiedReadmes)
+		if err != nil {
+			return plan, fmt.Errorf("Failed to process the modified READMEs: %v", err)
+		}
+		plan.ConnectorOverviewUpdates = append(plan.ConnectorOverviewUpdates, readMeUpdates...)
+	}
+
+	if len(modifiedLogos) > 0 {
+		logoUpdates, logoUploads := processModifiedLogos(modifiedLogos)
+		plan.ConnectorOverviewUpdates = append(plan.ConnectorOverviewUpdates, logoUpdates...)
+		plan.LogoUploads = append(plan.LogoUploads, logoUploads...)
+	}
+
+	if len(deletedConnectors) > 0 {
+		fmt.Fprintln(os.Stderr, "Deleted connectors, their overviews are kept in the registry: ", deletedConnectors)
+	}
+
+	if len(deletedVersions) > 0 {
+		versionUpdates, packageDeletions, err := p.processDeletedConnectorVersions(deletedVersions, deletedConnectors)
+		if err != nil {
+			return plan, err
+		}
+		plan.ConnectorVersionUpdates = append(plan.ConnectorVersionUpdates, versionUpdates...)
+		plan.PackageDeletions = append(plan.PackageDeletions, packageDeletions...)
+	}
+
+	if err := p.linkAliasedConnectorVersions(&plan, processedChangedFiles); err != nil {
+		return plan, err
+	}
+
+	return plan, nil
+}
+
+// linkAliasedConnectorVersions links the aliased connectors to the versions of their parent connector. The versions
+// published or withdrawn for a connector are also published or withdrawn for its aliased connectors, with the same
+// package, and a new aliased connector is linked to the versions of its parent that are already in the registry.
+func (p *Publisher) linkAliasedConnectorVersions(plan *PublicationPlan, processedChangedFiles ProcessedChangedFiles) error {
+	// The aliased connectors of a connector are listed from the folder of the connector, found from its releases
+	connectorFolders := make(map[Connector]string)
+	for connector, versions := range processedChangedFiles.NewConnectorVersions {
+		for _, connectorVersionPath := range versions {
+			connectorFolders[connector] = releaseConnectorFolder(connectorVersionPath)
+		}
+	}
+	for connector, versions := range processedChangedFiles.DeletedVersions {
+		for _, connectorVersionPath := range versions {
+			connectorFolders[connector] = releaseConnectorFolder(connectorVersionPath)
+		}
+	}
+	aliasesOf := make(map[Connector][]Connector)
+	for _, connector := range sortedConnectors(connectorFolders) {
+		aliases, err := listAliasedConnectors(connector, connectorFolders[connector])
+		if err != nil {
+			return &ConnectorError{Connector: connector, Err: err}
+		}
+		aliasesOf[connector] = aliases
+	}
+
+	linked := make(map[Connector]map[string]bool)
+	link := func(alias Connector, connectorVersion ConnectorVersion) {
+		if linked[alias] == nil {
+			linked[alias] = make(map[string]bool)
+		}
+		if linked[alias][connectorVersion.Version] {
+			return
+		}
+		linked[alias][connectorVersion.Version] = true
+		connectorVersion.Name = alias.Name
+		connectorVersion.Namespace = alias.Namespace
+		plan.ConnectorVersions = append(plan.ConnectorVersions, connectorVersion)
+	}
+
+	for _, connectorVersion := range sortedConnectorVersions(plan.ConnectorVersions) {
+		parent := Connector{Name: connectorVersion.Name, Namespace: connectorVersion.Namespace}
+		for _, alias := range aliasesOf[parent] {
+			link(alias, connectorVersion)
+		}
+	}
+
+	for _, connectorVersionUpdate := range plan.ConnectorVersionUpdates {
+		parent := Connector{Name: connectorVersionUpdate.Where.ConnectorName, Namespace: connectorVersionUpdate.Where.ConnectorNamespace}
+		for _, alias := range aliasesOf[parent] {
+			plan.ConnectorVersionUpdates = append(plan.ConnectorVersionUpdates, newConnectorVersionDeprecation(alias, connectorVersionUpdate.Where.Version))
+		}
+	}
+
+	for _, alias := range sortedConnectors(processedChangedFiles.NewConnectors) {
+		parent, isAlias := processedChangedFiles.AliasedConnectors[alias]
+		if !isAlias {
+			continue
+		}
+		// The versions of the parent are only queried in dry-run mode if a registry client is configured, the plan
+		// lists the links that it can't resolve otherwise
+		if p.dryRun && p.registryClient == nil {
+			plan.UnresolvedAliasLinks = append(plan.UnresolvedAliasLinks, UnresolvedAliasLink{Alias: alias, Parent: parent})
+			continue
+		}
+		parentVersions, err := p.getConnectorVersionsFromRegistry(parent.Namespace, parent.Name)
+		if err != nil {
+			return &ConnectorError{Connector: alias, Err: fmt.Errorf("Failed to get the versions of the parent connector from the registry: %w", err)}
+		}
+		for _, connectorVersion := range parentVersions {
+			link(alias, connectorVersion)
+		}
+	}
+
+	return nil
+}
+
+// releaseConnectorFolder returns the folder of the connector of a connector-packaging.json file,
+// `registry/<namespace>/<name>/releases/<version>/connector-packaging.json` -> `registry/<namespace>/<name>`
+func releaseConnectorFolder(connectorVersionPath string) string {
+	return filepath.Dir(filepath.Dir(filepath.Dir(connectorVersionPath)))
+}
+
+// checkDeletedConnectorFiles checks that the README and the logo of a connector are only deleted along
+// with the connector itself. The logo can also be replaced by a logo with another extension.
+func checkDeletedConnectorFiles(processedChangedFiles ProcessedChangedFiles) error {
+	for connector := range processedChangedFiles.DeletedReadmes {
+		if _, isDeletedConnector := processedChangedFiles.DeletedConnectors[connector]; !isDeletedConnector {
+			return &ConnectorError{Connector: connector, Err: errors.New("The README of the connector cannot be deleted without deleting the connector")}
+		}
+	}
+	for connector := range processedChangedFiles.DeletedLogos {
+		if _, isDeletedConnector := processedChangedFiles.DeletedConnectors[connector]; isDeletedConnector {
+			continue
+		}
+		if _, isReplaced := processedChangedFiles.NewLogos[connector]; !isReplaced {
+			return &ConnectorError{Connector: connector, Err: errors.New("The logo of the connector cannot be deleted without deleting the connector or adding a new logo")}
+		}
+	}
+	return nil
+}
+
+// processDeletedConnectorVersions withdraws the deleted connector versions from the registry and deletes their packages
+// from the google bucket. A version cannot be deleted while it is the latest version of its connector, unless
+// the whole connector is deleted.
+func (p *Publisher) processDeletedConnectorVersions(deletedVersions DeletedConnectorVersions, deletedConnectors DeletedConnectors) ([]ConnectorVersionUpdate, []PackageDeletion, error) {
+	var connectorVersionUpdates []ConnectorVersionUpdate
+	var packageDeletions []PackageDeletion
+
+	for connector, versions := range deletedVersions {
+		_, isDeletedConnector := deletedConnectors[connector]
+
+		for version, connectorVersionPath := range versions {
+			// The withdrawn versions are marked as deprecated, which requires the is_deprecated column
+			if !p.deprecationColumn {
+				return nil, nil, &ConnectorVersionError{Connector: connector, Version: version, Err: errors.New("The version cannot be deleted because the registry doesn't have the is_deprecated column, see --deprecation-column")}
+			}
+			if !isDeletedConnector {
+				metadataFile := filepath.Join(releaseConnectorFolder(connectorVersionPath), ndchub.MetadataJSON)
+				connectorMetadata, err := readJSONFile[ndchub.ConnectorMetadata](metadataFile)
+				if err != nil {
+					return nil, nil, &ConnectorError{Connector: connector, Err: fmt.Errorf("Failed to read the metadata of the connector: %w", err)}
+				}
+				if connectorMetadata.Overview.LatestVersion == version {
+					return nil, nil, &ConnectorVersionError{Connector: connector, Version: version, Err: errors.New("The version cannot be deleted because it is the latest_version in metadata.json")}
+				}
+			}
+
+			connectorVersionUpdates = append(connectorVersionUpdates, newConnectorVersionDeprecation(connector, version))
+			packageDeletions = append(packageDeletions, newPackageDeletion(connector, version, p.bucketName))
+		}
+	}
+
+	return connectorVersionUpdates, packageDeletions, nil
+}
+
+// applyPublicationPlan uploads the logos and the connector version tarballs of the plan, and then
+// updates the registry with the payloads of the plan. Every upload is recorded in a journal, if any
+// step fails, including the registry mutation, the uploads are rolled back in reverse order.
+func (p *Publisher) applyPublicationPlan(plan *PublicationPlan) error {
+	journal := &publicationJournal{}
+
+	if err := p.publishPlan(plan, journal); err != nil {
+		return &ApplyError{Err: err, RollbackErr: journal.rollback(p)}
+	}
+
+	// The packages of the withdrawn versions are only deleted once the registry doesn't serve them anymore. The
+	// registry is already updated at this point, so every deletion is attempted and the failures are reported together.
+	var errs ConnectorVersionErrors
+	for _, packageDeletion := range plan.PackageDeletions {
+		if err := deleteFile(p.storageClient, packageDeletion.Bucket, packageDeletion.ObjectName); err != nil {
+			errs = append(errs, ConnectorVersionError{Connector: packageDeletion.Connector, Version: packageDeletion.Version, Err: fmt.Errorf("Failed to delete the package: %w", err)})
+			continue
+		}
+		fmt.Printf("Successfully deleted the connector version definition from google cloud registry for the connector: %v version: %v\n", packageDeletion.Connector.Name, packageDeletion.Version)
+	}
+	if len(errs) > 0 {
+		return &PackageDeletionError{Err: errs}
+	}
+
+	return nil
+}
+
+// publishPlan performs the uploads and the registry mutation of the plan, recording the uploads in the journal
+func (p *Publisher) publishPlan(plan *PublicationPlan, journal *publicationJournal) error {
+	for _, logoUpload := range plan.LogoUploads {
+		uploadResult, err := uploadLogoToCloudinary(p.cloudinary, logoUpload.Connector, logoUpload.Logo)
+		if err != nil {
+			return err
+		}
+		journal.record(cloudinaryUploadEntry{PublicID: logoUpload.PublicID, Overwritten: uploadResult.Overwritten})
+		plan.resolveLogoURL(logoUpload.Connector, uploadResult.SecureURL)
+	}
+	if len(plan.LogoUploads) > 0 {
+		fmt.Println("Successfully uploaded the logos to cloudinary.")
+	}
+
+	if err := p.uploadConnectorVersionPackages(plan.PackageUploads, journal); err != nil {
+		return err
+	}
+
+	if !plan.requiresRegistryMutation() {
+		return nil
+	}
+
+	var err error
+	if p.env == ProductionEnv {
+		err = p.registryDbMutation(plan.NewConnectors, plan.ConnectorOverviewUpdates, plan.ConnectorVersions, plan.ConnectorVersionUpdates)
+
+	} else if p.env == StagingEnv {
+		err = p.registryDbMutationStaging(plan.NewConnectors, plan.ConnectorOverviewUpdates, plan.ConnectorVersions, plan.ConnectorVersionUpdates)
+	} else {
+		return fmt.Errorf("Unexpected: invalid publication environment: %s", p.env)
+	}
+
+	if err != nil {
+		return &RegistryError{Err: err}
+	}
+
+	return nil
+}
+
+func uploadLogoToCloudinary(cloudinary CloudinaryInterface, connector Connector, logo Logo) (*uploader.UploadResult, error) {
+	logoContent, err := readFile(logo.Path)
+	if err != nil {
+		fmt.Printf("Failed to read the logo file: %v", err)
+		return nil, err
+	}
+
+	imageReader := bytes.NewReader(logoContent)
+
+	uploadResult, err := cloudinary.Upload(context.Background(), imageReader, uploader.UploadParams{
+		PublicID: cloudinaryPublicID(connector),
+		Format:   string(logo.Extension),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to upload the logo to cloudinary for the connector: %s, Error: %v\n", connector.Name, err)
+	}
+	return uploadResult, nil
+}
+
+// cloudinaryPublicID returns the public ID of the logo of the connector in cloudinary
+func cloudinaryPublicID(connector Connector) string {
+	return fmt.Sprintf("%s-%s", connector.Namespace, connector.Name)
+}
+
+// processModifiedLogos returns the connector overview updates and the logo uploads for the modified logos.
+// The logo URLs of the updates are placeholders until the logos are uploaded.
+func processModifiedLogos(modifiedLogos ModifiedLogos) ([]ConnectorOverviewUpdate, []LogoUpload) {
+	// Iterate over the modified logos and update the logos in the registry
+	var connectorOverviewUpdates []ConnectorOverviewUpdate
+	var logoUploads []LogoUpload
+
+	for connector, logo := range modifiedLogos {
+		var connectorOverviewUpdate ConnectorOverviewUpdate
+
+		connectorOverviewUpdate.Set.Logo = new(string)
+		*connectorOverviewUpdate.Set.Logo = plannedLogoURL(connector)
+
+		connectorOverviewUpdate.Where.ConnectorName = connector.Name
+		connectorOverviewUpdate.Where.ConnectorNamespace = connector.Namespace
+
+		connectorOverviewUpdates = append(connectorOverviewUpdates, connectorOverviewUpdate)
+		logoUploads = append(logoUploads, newLogoUpload(connector, logo))
+	}
+
+	return connectorOverviewUpdates, logoUploads
+
+}
+
+func processModifiedReadmes(modifiedReadmes ModifiedReadmes) ([]ConnectorOverviewUpdate, error) {
+	// Iterate over the modified READMEs and update the READMEs in the registry
+	var connectorOverviewUpdates []ConnectorOverviewUpdate
+
+	for connector, readmePath := range modifiedReadmes {
+		// open the README file
+		readmeContent, err := readFile(readmePath)
+		if err != nil {
+			return connectorOverviewUpdates, err
+
+		}
+
+		readMeContentString := string(readmeContent)
+
+		var connectorOverviewUpdate ConnectorOverviewUpdate
+		connectorOverviewUpdate.Set.Docs = &readMeContentString
+
+		connectorOverviewUpdate.Where.ConnectorName = connector.Name
+		connectorOverviewUpdate.Where.ConnectorNamespace = connector.Namespace
+
+		connectorOverviewUpdates = append(connectorOverviewUpdates, connectorOverviewUpdate)
+
+	}
+
+	return connectorOverviewUpdates, nil
+
+}
+
+// processNewlyAddedConnectorVersions downloads the newly added connector versions and builds their registry payloads
+// along with the uploads of their tarballs. The versions are processed concurrently, and the failures of all the
+// versions are reported together.
+func (p *Publisher) processNewlyAddedConnectorVersions(newlyAddedConnectorVersions NewConnectorVersions, newConnectorsAdded map[Connector]bool) ([]ConnectorVersion, []PackageUpload, error) {
+	jobs := connectorVersionJobs(newlyAddedConnectorVersions)
+
+	type result struct {
+		connectorVersion ConnectorVersion
+		packageUpload    PackageUpload
+		err              error
+	}
+	results := make([]result, len(jobs))
+
+	forEachConcurrently(jobs, p.concurrency, func(i int, job connectorVersionJob) {
+		isNewConnector := newConnectorsAdded[job.Connector]
+		connectorVersion, packageUpload, err := p.prepareConnectorVersionPackage(job.Connector, job.Version, job.Path, isNewConnector)
+		results[i] = result{connectorVersion: connectorVersion, packageUpload: packageUpload, err: err}
+	})
+
+	var connectorVersions []ConnectorVersion
+	var packageUploads []PackageUpload
+	var errs ConnectorVersionErrors
+
+	for i, job := range jobs {
+		if err := results[i].err; err != nil {
+			errs = append(errs, ConnectorVersionError{Connector: job.Connector, Version: job.Version, Err: err})
+			continue
+		}
+		connectorVersions = append(connectorVersions, results[i].connectorVersion)
+		packageUploads = append(packageUploads, results[i].packageUpload)
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, fmt.Errorf("Error while processing the connector versions: %w", errs)
+	}
+
+	return connectorVersions, packageUploads, nil
+
+}
+
+// uploadConnectorVersionPackages uploads the connector version packages concurrently. The packages that
+// failed to upload are reported together, the successful uploads are recorded in the journal.
+func (p *Publisher) uploadConnectorVersionPackages(packageUploads []PackageUpload, journal *publicationJournal) error {
+	var mu sync.Mutex
+	var errs ConnectorVersionErrors
+
+	forEachConcurrently(packageUploads, p.concurrency, func(_ int, packageUpload PackageUpload) {
+		if err := p.uploadConnectorVersionPackage(packageUpload, journal); err != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, ConnectorVersionError{Connector: packageUpload.Connector, Version: packageUpload.Version, Err: err})
+		}
+	})
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Failed to upload the connector versions: %w", errs)
+	}
+	return nil
+}
+
+// prepareConnectorVersionPackage downloads the connector version package and builds its registry payload,
+// along with the upload of the package to the google bucket.
+func (p *Publisher) prepareConnectorVersionPackage(connector Connector, version string, changedConnectorVersionPath string, isNewConnector bool) (ConnectorVersion, PackageUpload, error) {
+
+	var connectorVersion ConnectorVersion
+	var packageUpload PackageUpload
+
+	// connector version's metadata, `registry/mongodb/releases/v1.0.0/connector-packaging.json`
+	connectorPackaging, err := readJSONFile[ndchub.ConnectorPackaging](changedConnectorVersionPath) // Read metadata file
+	if err != nil {
+		return connectorVersion, packageUpload, fmt.Errorf("failed to read the connector packaging file: %v", err)
+	}
+
+	// Check if the TGZ URL is valid
+	if connectorPackaging.URI == "" {
+		return connectorVersion, packageUpload, fmt.Errorf("invalid or undefined TGZ URL: %v", connectorPackaging.URI)
+	}
+
+	// The package is verified against the checksum of connector-packaging.json while it is downloaded, and only
+	// the packaging spec is read, the package is uploaded as is
+	packagingSpec, connectorMetadataTgzPath, tgzSHA256, err := ndchub.DownloadPackagingSpec(connectorPackaging.URI, connectorPackaging.Checksum,
+		connector.Namespace, connector.Name, version)
+	if err != nil {
+		return connectorVersion, packageUpload, err
+	}
+
+	// The packaging spec is validated before anything is uploaded
+	if err := packagingSpec.Validate(); err != nil {
+		return connectorVersion, packageUpload, fmt.Errorf("invalid packaging spec: %w", err)
+	}
+
+	packageUpload = newPackageUpload(connector, version, p.bucketName, connectorMetadataTgzPath, tgzSHA256)
+
+	// Build payload for registry upsert
+	connectorVersion, err = p.buildRegistryPayload(connector.Namespace, connector.Name, version, packagingSpec, packageUpload.PublicURL, isNewConnector)
+	return connectorVersion, packageUpload, err
+}
+
+// uploadConnectorVersionPackage uploads the connector version package to the google bucket, and records
+// the upload along with the generation of the object it overwrites in the journal
+func (p *Publisher) uploadConnectorVersionPackage(packageUpload PackageUpload, journal *publicationJournal) error {
+	connector := packageUpload.Connector
+	previousGeneration, err := getObjectGeneration(p.storageClient, packageUpload.Bucket, packageUpload.ObjectName)
+	if err != nil {
+		return fmt.Errorf("failed to get the existing connector version definition - connector: %v version:%v - err: %v", connector.Name, packageUpload.Version, err)
+	}
+	_, err = uploadFile(p.storageClient, packageUpload.Bucket, packageUpload.ObjectName, packageUpload.LocalPath, packageUpload.objectMetadata())
+	if err != nil {
+		return fmt.Errorf("failed to upload the connector version definition - connector: %v version:%v - err: %v", connector.Name, packageUpload.Version, err)
+	}
+	journal.record(gcsUploadEntry{Bucket: packageUpload.Bucket, ObjectName: packageUpload.ObjectName, PreviousGeneration: previousGeneration})
+	// print success message with the name of the connector and the version
+	fmt.Printf("Successfully uploaded the connector version definition in google cloud registry for the connector: %v version: %v\n", connector.Name, packageUpload.Version)
+	return nil
+}
+
+// buildRegistryPayload builds the payload for the registry upsert API
+func (p *Publisher) buildRegistryPayload(connectorNamespace string,
+	connectorName string,
+	version string,
+	packagingSpec *ndchub.ConnectorMetadataDefinition,
+	uploadedConnectorDefinitionTgzUrl string,
+	isNewConnector bool,
+) (ConnectorVersion, error) {
+	var connectorVersion ConnectorVersion
+
+	var isMultitenant bool
+
+	// The registry is not queried in dry-run mode, the connector is assumed to not be multitenant
+	if !p.dryRun {
+		connectorInfo, err := p.getConnectorInfoFromRegistry(connectorNamespace, connectorName)
+
+		if err != nil {
+			return connectorVersion, err
+		}
+
+		// Check if the connector exists in the registry first
+		if len(connectorInfo.HubRegistryConnector) == 0 {
+
+			if isNewConnector {
+				isMultitenant = false
+			} else {
+				return connectorVersion, fmt.Errorf("Unexpected: Couldn't get the connector info of the connector: %s", connectorName)
+
+			}
+
+		} else {
+			if len(connectorInfo.HubRegistryConnector) == 1 {
+				// check if the connector is multitenant
+				isMultitenant = connectorInfo.HubRegistryConnector[0].MultitenantConnector != nil
+
+			}
+
+		}
+	}
+
+	var connectorVersionType string
+
+	if packagingSpec.PackagingDefinition.Type == ndchub.PrebuiltDockerImage {
+		// Note: The connector version type is set to `PreBuiltDockerImage` if the connector version is of type `PrebuiltDockerImage`, this is a HACK because this value might be removed in the future and we might not even need to insert new connector versions in the `hub_registry_connector_version` table.
+		connectorVersionType = "PreBuiltDockerImage"
+	} else {
+		connectorVersionType = string(ndchub.ManagedDockerBuild)
+	}
+
+	var connectorVersionImage *string
+
+	if dockerImage := packagingSpec.PackagingDefinition.GetDockerImage(); dockerImage != "" {
+		connectorVersionImage = &dockerImage
+	}
+
+	var ndcSpecGeneration *string
+
+	if packagingSpec.NDCSpecGeneration != nil && *packagingSpec.NDCSpecGeneration != "" {
+		generation := string(*packagingSpec.NDCSpecGeneration)
+		ndcSpecGeneration = &generation
+	}
+
+	var cliPluginKind *string
+
+	if packagingSpec.CliPlugin != nil {
+		if kind := packagingSpec.CliPlugin.Kind(); kind != "" {
+			kindStr := string(kind)
+			cliPluginKind = &kindStr
+		}
+	}
+
+	connectorVersion = ConnectorVersion{
+		Namespace:            connectorNamespace,
+		Name:                 connectorName,
+		Version:              version,
+		Image:                connectorVersionImage,
+		PackageDefinitionURL: uploadedConnectorDefinitionTgzUrl,
+		IsMultitenant:        isMultitenant,
+		Type:                 connectorVersionType,
+		NDCSpecGeneration:    ndcSpecGeneration,
+		CLIPluginKind:        cliPluginKind,
+		PackagingSpecVersion: string(packagingSpec.GetVersion()),
+	}
+
+	return connectorVersion, nil
+}
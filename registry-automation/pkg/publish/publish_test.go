@@ -0,0 +1,878 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/oci"
+)
+
+// Mock structures
+type MockStorageClient struct {
+	mock.Mock
+}
+
+func (m *MockStorageClient) Bucket(name string) *storage.BucketHandle {
+	args := m.Called(name)
+	return args.Get(0).(*storage.BucketHandle)
+}
+
+type MockCloudinaryUploader struct {
+	mock.Mock
+}
+
+type MockCloudinary struct {
+	mock.Mock
+}
+
+func (m *MockCloudinary) Upload(ctx context.Context, file interface{}, uploadParams uploader.UploadParams) (*uploader.UploadResult, error) {
+	args := m.Called(ctx, file, uploadParams)
+	return args.Get(0).(*uploader.UploadResult), args.Error(1)
+}
+
+func (m *MockCloudinary) Destroy(ctx context.Context, destroyParams uploader.DestroyParams) (*uploader.DestroyResult, error) {
+	args := m.Called(ctx, destroyParams)
+	return args.Get(0).(*uploader.DestroyResult), args.Error(1)
+}
+
+type MockGraphQLClient struct {
+	mock.Mock
+}
+
+func (m *MockGraphQLClient) Run(ctx context.Context, query *graphql.Request, resp interface{}) error {
+	args := m.Called(ctx, query, resp)
+	return args.Error(0)
+}
+
+type MockImageResolver struct {
+	mock.Mock
+}
+
+func (m *MockImageResolver) Inspect(ref oci.Reference) (oci.Manifest, error) {
+	args := m.Called(ref.String())
+	return args.Get(0).(oci.Manifest), args.Error(1)
+}
+
+func createTestPublisher() *Publisher {
+	return &Publisher{
+		env:         "staging",
+		bucketName:  "test-bucket",
+		concurrency: DefaultConcurrency,
+		// The tests publish to a registry that has the is_deprecated column
+		deprecationColumn: true,
+		registryClient:    &MockGraphQLClient{},
+		storageClient:     &MockStorageClient{},
+		cloudinary:        &MockCloudinary{},
+	}
+}
+
+// Test ProcessChangedFiles
+func TestProcessChangedFiles(t *testing.T) {
+	testCases := []struct {
+		name         string
+		changedFiles ChangedFiles
+		expected     ProcessedChangedFiles
+	}{
+		{
+			name: "New connector added",
+			changedFiles: ChangedFiles{
+				Added: []string{"registry/namespace1/connector1/metadata.json"},
+			},
+			expected: ProcessedChangedFiles{
+				NewConnectorVersions: map[Connector]map[string]string{},
+				ModifiedLogos:        map[Connector]Logo{},
+				ModifiedReadmes:      map[Connector]string{},
+				NewConnectors:        map[Connector]MetadataFile{{Name: "connector1", Namespace: "namespace1"}: "registry/namespace1/connector1/metadata.json"},
+				NewLogos:             map[Connector]Logo{},
+				NewReadmes:           map[Connector]string{},
+				ModifiedConnectors:   map[Connector]MetadataFile{},
+				DeletedConnectors:    map[Connector]MetadataFile{},
+				DeletedLogos:         map[Connector]Logo{},
+				DeletedReadmes:       map[Connector]string{},
+				DeletedVersions:      map[Connector]map[string]string{},
+				AliasedConnectors:    map[Connector]Connector{},
+			},
+		},
+		{
+			name: "Modified logo and README",
+			changedFiles: ChangedFiles{
+				Modified: []string{
+					"registry/namespace1/connector1/logo.png",
+					"registry/namespace1/connector1/README.md",
+				},
+			},
+			expected: ProcessedChangedFiles{
+				NewConnectorVersions: map[Connector]map[string]string{},
+				ModifiedLogos:        map[Connector]Logo{{Name: "connector1", Namespace: "namespace1"}: {Path: "registry/namespace1/connector1/logo.png", Extension: "png"}},
+				ModifiedReadmes:      map[Connector]string{{Name: "connector1", Namespace: "namespace1"}: "registry/namespace1/connector1/README.md"},
+				NewConnectors:        map[Connector]MetadataFile{},
+				NewLogos:             map[Connector]Logo{},
+				NewReadmes:           map[Connector]string{},
+				ModifiedConnectors:   map[Connector]MetadataFile{},
+				DeletedConnectors:    map[Connector]MetadataFile{},
+				DeletedLogos:         map[Connector]Logo{},
+				DeletedReadmes:       map[Connector]string{},
+				DeletedVersions:      map[Connector]map[string]string{},
+				AliasedConnectors:    map[Connector]Connector{},
+			},
+		},
+		{
+			name: "Modified logo and README and metadata",
+			changedFiles: ChangedFiles{
+				Modified: []string{
+					"registry/namespace1/connector1/logo.png",
+					"registry/namespace1/connector1/README.md",
+					"registry/namespace1/connector1/metadata.json",
+				},
+			},
+			expected: ProcessedChangedFiles{
+				NewConnectorVersions: map[Connector]map[string]string{},
+				ModifiedLogos:        map[Connector]Logo{{Name: "connector1", Namespace: "namespace1"}: {Path: "registry/namespace1/connector1/logo.png", Extension: "png"}},
+				ModifiedReadmes:      map[Connector]string{{Name: "connector1", Namespace: "namespace1"}: "registry/namespace1/connector1/README.md"},
+				NewConnectors:        map[Connector]MetadataFile{},
+				NewLogos:             map[Connector]Logo{},
+				NewReadmes:           map[Connector]string{},
+				ModifiedConnectors:   map[Connector]MetadataFile{{Name: "connector1", Namespace: "namespace1"}: "registry/namespace1/connector1/metadata.json"},
+				DeletedConnectors:    map[Connector]MetadataFile{},
+				DeletedLogos:         map[Connector]Logo{},
+				DeletedReadmes:       map[Connector]string{},
+				DeletedVersions:      map[Connector]map[string]string{},
+				AliasedConnectors:    map[Connector]Connector{},
+			},
+		},
+		{
+			name: "Deleted connector version and connector",
+			changedFiles: ChangedFiles{
+				Deleted: []string{
+					"registry/namespace1/connector1/releases/v1.0.0/connector-packaging.json",
+					"registry/namespace2/connector2/metadata.json",
+					"registry/namespace2/connector2/logo.svg",
+					"registry/namespace2/connector2/README.md",
+				},
+			},
+			expected: ProcessedChangedFiles{
+				NewConnectorVersions: map[Connector]map[string]string{},
+				ModifiedLogos:        map[Connector]Logo{},
+				ModifiedReadmes:      map[Connector]string{},
+				NewConnectors:        map[Connector]MetadataFile{},
+				NewLogos:             map[Connector]Logo{},
+				NewReadmes:           map[Connector]string{},
+				ModifiedConnectors:   map[Connector]MetadataFile{},
+				DeletedConnectors:    map[Connector]MetadataFile{{Name: "connector2", Namespace: "namespace2"}: "registry/namespace2/connector2/metadata.json"},
+				DeletedLogos:         map[Connector]Logo{{Name: "connector2", Namespace: "namespace2"}: {Path: "registry/namespace2/connector2/logo.svg", Extension: "svg"}},
+				DeletedReadmes:       map[Connector]string{{Name: "connector2", Namespace: "namespace2"}: "registry/namespace2/connector2/README.md"},
+				DeletedVersions:      map[Connector]map[string]string{{Name: "connector1", Namespace: "namespace1"}: {"v1.0.0": "registry/namespace1/connector1/releases/v1.0.0/connector-packaging.json"}},
+				AliasedConnectors:    map[Connector]Connector{},
+			},
+		},
+		{
+			name: "Aliased connector files",
+			changedFiles: ChangedFiles{
+				Added: []string{
+					"registry/namespace1/connector1/aliased_connectors/alias1/metadata.json",
+					"registry/namespace1/connector1/aliased_connectors/alias1/logo.svg",
+					"registry/namespace1/connector1/aliased_connectors/alias1/README.md",
+				},
+				Modified: []string{"registry/namespace1/connector1/aliased_connectors/alias2/README.md"},
+			},
+			expected: ProcessedChangedFiles{
+				NewConnectorVersions: map[Connector]map[string]string{},
+				ModifiedLogos:        map[Connector]Logo{},
+				ModifiedReadmes:      map[Connector]string{{Name: "alias2", Namespace: "namespace1"}: "registry/namespace1/connector1/aliased_connectors/alias2/README.md"},
+				NewConnectors:        map[Connector]MetadataFile{{Name: "alias1", Namespace: "namespace1"}: "registry/namespace1/connector1/aliased_connectors/alias1/metadata.json"},
+				NewLogos:             map[Connector]Logo{{Name: "alias1", Namespace: "namespace1"}: {Path: "registry/namespace1/connector1/aliased_connectors/alias1/logo.svg", Extension: "svg"}},
+				NewReadmes:           map[Connector]string{{Name: "alias1", Namespace: "namespace1"}: "registry/namespace1/connector1/aliased_connectors/alias1/README.md"},
+				ModifiedConnectors:   map[Connector]MetadataFile{},
+				DeletedConnectors:    map[Connector]MetadataFile{},
+				DeletedLogos:         map[Connector]Logo{},
+				DeletedReadmes:       map[Connector]string{},
+				DeletedVersions:      map[Connector]map[string]string{},
+				AliasedConnectors: map[Connector]Connector{
+					{Name: "alias1", Namespace: "namespace1"}: {Name: "connector1", Namespace: "namespace1"},
+					{Name: "alias2", Namespace: "namespace1"}: {Name: "connector1", Namespace: "namespace1"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := ProcessChangedFiles(tc.changedFiles)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+// func TestProcessNewConnector(t *testing.T) {
+// 	ctx := createTestPublisher()
+// 	connector := Connector{Name: "testconnector", Namespace: "testnamespace"}
+
+// 	// Create a temporary directory for our test files
+// 	tempDir, err := os.MkdirTemp("", "connector-test")
+// 	assert.NoError(t, err)
+// 	defer os.RemoveAll(tempDir) // Clean up after the test
+
+// 	// Set up the directory structure
+// 	registryDir := filepath.Join(tempDir, "registry", connector.Namespace, connector.Name)
+// 	err = os.MkdirAll(registryDir, 0755)
+// 	assert.NoError(t, err)
+
+// 	// Create the metadata file
+// 	metadataFile := filepath.Join(registryDir, "metadata.json")
+// 	tempMetadata := []byte(`{"overview": {"title": "Test Connector", "description": "A test connector"}, "isVerified": true, "isHostedByHasura": false, "author": {"name": "Test Author", "supportEmail": "support@test.com", "homepage": "https://test.com"}}`)
+// 	err = os.WriteFile(metadataFile, tempMetadata, 0666)
+// 	assert.NoError(t, err)
+
+// 	// Create the README file
+// 	readmeFile := filepath.Join(registryDir, "README.md")
+// 	err = os.WriteFile(readmeFile, []byte("# Test Connector"), 0644)
+// 	assert.NoError(t, err)
+
+// 	// Mock the necessary functions and API calls
+// 	mockCloudinaryUploader := &MockCloudinaryUploader{}
+// 	mockCloudinaryUploader.On("Upload", mock.Anything, mock.Anything, mock.Anything).Return(&uploader.UploadResult{SecureURL: "https://res.cloudinary.com/demo/image/upload/logo.png"}, nil)
+
+// 	mockGraphQLClient := ctx.RegistryGQLClient.(*MockGraphQLClient)
+// 	mockGraphQLClient.On("Run", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+// 	// Run the function
+// 	connectorOverviewInsert, hubRegistryConnectorInsert, err := p.processNewConnector(connector, MetadataFile(metadataFile))
+
+// 	// Assert the results
+// 	assert.NoError(t, err)
+// 	assert.Equal(t, "testconnector", connectorOverviewInsert.Name)
+// 	assert.Equal(t, "testnamespace", connectorOverviewInsert.Namespace)
+// 	assert.Equal(t, "Test Connector", connectorOverviewInsert.Title)
+// 	assert.Equal(t, "A test connector", connectorOverviewInsert.Description)
+// 	assert.True(t, connectorOverviewInsert.IsVerified)
+// 	assert.False(t, connectorOverviewInsert.IsHosted)
+// 	assert.Equal(t, "Test Author", connectorOverviewInsert.Author.Data.Name)
+// 	assert.Equal(t, "support@test.com", connectorOverviewInsert.Author.Data.SupportEmail)
+// 	assert.Equal(t, "https://test.com", connectorOverviewInsert.Author.Data.Website)
+
+// 	assert.Equal(t, "testconnector", hubRegistryConnectorInsert.Name)
+// 	assert.Equal(t, "testnamespace", hubRegistryConnectorInsert.Namespace)
+// 	assert.Equal(t, "Test Connector", hubRegistryConnectorInsert.Title)
+
+// 	mockCloudinaryUploader.AssertExpectations(t)
+// 	mockGraphQLClient.AssertExpectations(t)
+// }
+
+// // Test uploadConnectorVersionPackage
+// func TestUploadConnectorVersionPackage(t *testing.T) {
+// 	ctx := createTestPublisher()
+// 	connector := Connector{Name: "testconnector", Namespace: "testnamespace"}
+// 	version := "v1.0.0"
+// 	changedConnectorVersionPath := "registry/testnamespace/testconnector/releases/v1.0.0/connector-packaging.json"
+// 	isNewConnector := true
+
+// 	// Mock necessary functions
+// 	mockStorageClient := ctx.StorageClient.(*MockStorageClient)
+// 	mockStorageClient.On("Bucket", mock.Anything).Return(&storage.BucketHandle{})
+
+// 	mockGraphQLClient := ctx.RegistryGQLClient.(*MockGraphQLClient)
+// 	mockGraphQLClient.On("Run", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+// 	// Create temporary files
+// 	err := os.MkdirAll("registry/testnamespace/testconnector/releases/v1.0.0", 0755)
+// 	assert.NoError(t, err)
+// 	defer os.RemoveAll("registry/testnamespace/testconnector")
+
+// 	packagingContent := []byte(`{"uri": "https://example.com/testconnector-v1.0.0.tgz"}`)
+// 	err = os.WriteFile(changedConnectorVersionPath, packagingContent, 0644)
+// 	assert.NoError(t, err)
+
+// 	// Run the function
+// 	connectorVersion, err := p.uploadConnectorVersionPackage(connector, version, changedConnectorVersionPath, isNewConnector)
+
+// 	// Assert the results
+// 	assert.NoError(t, err)
+// 	assert.Equal(t, "testconnector", connectorVersion.Name)
+// 	assert.Equal(t, "testnamespace", connectorVersion.Namespace)
+// 	assert.Equal(t, "v1.0.0", connectorVersion.Version)
+
+// 	mockStorageClient.AssertExpectations(t)
+// 	mockGraphQLClient.AssertExpectations(t)
+// }
+
+// // Test buildRegistryPayload
+// func TestBuildRegistryPayload(t *testing.T) {
+// 	ctx := createTestPublisher()
+// 	connectorNamespace := "testnamespace"
+// 	connectorName := "testconnector"
+// 	version := "v1.0.0"
+// 	connectorVersionMetadata := map[string]interface{}{
+// 		"packagingDefinition": map[string]interface{}{
+// 			"type": "ManagedDockerBuild",
+// 		},
+// 	}
+// 	uploadedConnectorDefinitionTgzUrl := "https://example.com/test.tgz"
+// 	isNewConnector := true
+
+// 	// Mock the GraphQL client
+// 	mockGraphQLClient := ctx.RegistryGQLClient.(*MockGraphQLClient)
+// 	mockGraphQLClient.On("Run", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+// 	// Run the function
+// 	connectorVersion, err := p.buildRegistryPayload(connectorNamespace, connectorName, version, connectorVersionMetadata, uploadedConnectorDefinitionTgzUrl, isNewConnector)
+
+// 	// Assert the results
+// 	assert.NoError(t, err)
+// 	assert.Equal(t, connectorNamespace, connectorVersion.Namespace)
+// 	assert.Equal(t, connectorName, connectorVersion.Name)
+// 	assert.Equal(t, version, connectorVersion.Version)
+// 	assert.Equal(t, uploadedConnectorDefinitionTgzUrl, connectorVersion.PackageDefinitionURL)
+// 	assert.Equal(t, "ManagedDockerBuild", connectorVersion.Type)
+// 	assert.False(t, connectorVersion.IsMultitenant)
+// 	assert.Nil(t, connectorVersion.Image)
+
+// 	mockGraphQLClient.AssertExpectations(t)
+// }
+
+func TestBuildPublicationPlanDryRun(t *testing.T) {
+	p, err := NewPublisher(Config{Env: "staging", DryRun: true}, Clients{})
+	assert.NoError(t, err)
+	connector := Connector{Name: "connector1", Namespace: "namespace1"}
+
+	tempDir := t.TempDir()
+	metadataFile := filepath.Join(tempDir, "metadata.json")
+	err = os.WriteFile(metadataFile, []byte(`{"overview": {"title": "Connector 1", "description": "A test connector", "latest_version": "v1.0.0"}}`), 0644)
+	assert.NoError(t, err)
+	readmeFile := filepath.Join(tempDir, "README.md")
+	err = os.WriteFile(readmeFile, []byte("# Connector 1"), 0644)
+	assert.NoError(t, err)
+	logoFile := filepath.Join(tempDir, "logo.png")
+
+	plan, err := p.buildPublicationPlan(ProcessedChangedFiles{
+		ModifiedConnectors: ModifiedMetadata{connector: MetadataFile(metadataFile)},
+		ModifiedReadmes:    ModifiedReadmes{connector: readmeFile},
+		ModifiedLogos:      ModifiedLogos{connector: {Path: logoFile, Extension: PNG}},
+	})
+	assert.NoError(t, err)
+
+	assert.Len(t, plan.ConnectorOverviewUpdates, 3)
+	assert.Equal(t, "v1.0.0", *plan.ConnectorOverviewUpdates[0].Set.LatestVersion)
+	assert.Equal(t, "# Connector 1", *plan.ConnectorOverviewUpdates[1].Set.Docs)
+	assert.Equal(t, "cloudinary://namespace1-connector1", *plan.ConnectorOverviewUpdates[2].Set.Logo)
+	assert.Equal(t, []LogoUpload{{Connector: connector, PublicID: "namespace1-connector1", Logo: Logo{Path: logoFile, Extension: PNG}}}, plan.LogoUploads)
+	assert.Empty(t, plan.PackageUploads)
+	// The overview updates are applied to the registry even though no connector version is published
+	assert.True(t, plan.requiresRegistryMutation())
+	emptyPlan := newPublicationPlan("staging")
+	assert.False(t, emptyPlan.requiresRegistryMutation())
+
+	plan.resolveLogoURL(connector, "https://res.cloudinary.com/demo/image/upload/namespace1-connector1.png")
+	assert.Equal(t, "https://res.cloudinary.com/demo/image/upload/namespace1-connector1.png", *plan.ConnectorOverviewUpdates[2].Set.Logo)
+}
+
+func TestProcessDeletedConnectorVersions(t *testing.T) {
+	connector := Connector{Name: "connector1", Namespace: "namespace1"}
+
+	tempDir := t.TempDir()
+	connectorDir := filepath.Join(tempDir, "registry", connector.Namespace, connector.Name)
+	err := os.MkdirAll(filepath.Join(connectorDir, "releases"), 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(connectorDir, "metadata.json"), []byte(`{"overview": {"latest_version": "v1.1.0"}}`), 0644)
+	assert.NoError(t, err)
+
+	deletedVersion := func(version string) DeletedConnectorVersions {
+		return DeletedConnectorVersions{connector: {version: filepath.Join(connectorDir, "releases", version, "connector-packaging.json")}}
+	}
+
+	t.Run("Withdraws a version that is not the latest version", func(t *testing.T) {
+		versionUpdates, packageDeletions, err := createTestPublisher().processDeletedConnectorVersions(deletedVersion("v1.0.0"), DeletedConnectors{})
+		assert.NoError(t, err)
+		assert.Equal(t, []ConnectorVersionUpdate{newConnectorVersionDeprecation(connector, "v1.0.0")}, versionUpdates)
+		assert.True(t, *versionUpdates[0].Set.IsDeprecated)
+		assert.Len(t, packageDeletions, 1)
+		assert.Equal(t, "packages/namespace1/connector1/v1.0.0/package.tgz", packageDeletions[0].ObjectName)
+	})
+
+	t.Run("Refuses to withdraw a version without the is_deprecated column", func(t *testing.T) {
+		p := createTestPublisher()
+		p.deprecationColumn = false
+		_, _, err := p.processDeletedConnectorVersions(deletedVersion("v1.0.0"), DeletedConnectors{})
+		assert.ErrorContains(t, err, "is_deprecated")
+	})
+
+	t.Run("Refuses to withdraw the latest version", func(t *testing.T) {
+		_, _, err := createTestPublisher().processDeletedConnectorVersions(deletedVersion("v1.1.0"), DeletedConnectors{})
+		assert.ErrorContains(t, err, "latest_version")
+	})
+
+	t.Run("Withdraws the latest version of a deleted connector", func(t *testing.T) {
+		deletedConnectors := DeletedConnectors{connector: MetadataFile(filepath.Join(connectorDir, "metadata.json"))}
+		versionUpdates, _, err := createTestPublisher().processDeletedConnectorVersions(deletedVersion("v1.1.0"), deletedConnectors)
+		assert.NoError(t, err)
+		assert.Len(t, versionUpdates, 1)
+	})
+}
+
+func TestCheckDeletedConnectorFiles(t *testing.T) {
+	connector := Connector{Name: "connector1", Namespace: "namespace1"}
+
+	err := checkDeletedConnectorFiles(ProcessedChangedFiles{
+		DeletedReadmes: DeletedReadmes{connector: "registry/namespace1/connector1/README.md"},
+	})
+	assert.ErrorContains(t, err, "README")
+
+	err = checkDeletedConnectorFiles(ProcessedChangedFiles{
+		DeletedLogos: DeletedLogos{connector: {Path: "registry/namespace1/connector1/logo.png", Extension: PNG}},
+		NewLogos:     NewLogos{connector: {Path: "registry/namespace1/connector1/logo.svg", Extension: SVG}},
+	})
+	assert.NoError(t, err)
+}
+
+type fakeJournalEntry struct {
+	name        string
+	compensated *[]string
+	err         error
+}
+
+func (e fakeJournalEntry) String() string {
+	return e.name
+}
+
+func (e fakeJournalEntry) compensate(p *Publisher) error {
+	*e.compensated = append(*e.compensated, e.name)
+	return e.err
+}
+
+func TestPublicationJournalRollback(t *testing.T) {
+	p := createTestPublisher()
+	mockCloudinary := p.cloudinary.(*MockCloudinary)
+	mockCloudinary.On("Destroy", mock.Anything, uploader.DestroyParams{PublicID: "namespace1-connector1"}).Return(&uploader.DestroyResult{Result: "ok"}, nil)
+
+	var compensated []string
+	journal := &publicationJournal{}
+	journal.record(fakeJournalEntry{name: "first", compensated: &compensated})
+	journal.record(cloudinaryUploadEntry{PublicID: "namespace1-connector1"})
+	journal.record(fakeJournalEntry{name: "second", compensated: &compensated, err: errors.New("compensation failed")})
+	journal.record(cloudinaryUploadEntry{PublicID: "namespace1-connector2", Overwritten: true})
+	journal.record(fakeJournalEntry{name: "third", compensated: &compensated})
+
+	err := journal.rollback(p)
+
+	// Every entry is compensated in reverse order, even after a compensation fails
+	assert.Equal(t, []string{"third", "second", "first"}, compensated)
+	assert.ErrorContains(t, err, "failed to roll back the second: compensation failed")
+	assert.ErrorContains(t, err, "upload of the cloudinary asset namespace1-connector2")
+	assert.Empty(t, journal.entries)
+	mockCloudinary.AssertExpectations(t)
+}
+
+func TestRenderPublicationPlanMarkdown(t *testing.T) {
+	image := "ghcr.io/hasura/ndc-connector1:v1.0.0"
+	plan := newPublicationPlan("staging")
+	connector := Connector{Name: "connector1", Namespace: "namespace1"}
+	packageUpload := newPackageUpload(connector, "v1.0.0", "test-bucket", "/tmp/package.tgz", "")
+	plan.PackageUploads = append(plan.PackageUploads, packageUpload)
+	plan.ConnectorVersions = append(plan.ConnectorVersions, ConnectorVersion{
+		Namespace:            connector.Namespace,
+		Name:                 connector.Name,
+		Version:              "v1.0.0",
+		Image:                &image,
+		PackageDefinitionURL: packageUpload.PublicURL,
+		Type:                 "PreBuiltDockerImage",
+	})
+
+	markdown := renderPublicationPlanMarkdown(plan)
+
+	assert.Contains(t, markdown, "## Hub registry publication plan (staging)")
+	assert.Contains(t, markdown, "| `namespace1/connector1` | `v1.0.0` | v1 | PreBuiltDockerImage | `ghcr.io/hasura/ndc-connector1:v1.0.0` | https://storage.googleapis.com/test-bucket/packages/namespace1/connector1/v1.0.0/package.tgz |")
+	assert.Contains(t, markdown, "| `test-bucket` | `packages/namespace1/connector1/v1.0.0/package.tgz` |")
+}
+
+func TestLinkAliasedConnectorVersionsDryRun(t *testing.T) {
+	parent := Connector{Name: "connector1", Namespace: "namespace1"}
+	alias := Connector{Name: "alias1", Namespace: "namespace1"}
+	connectorFolder := filepath.Join(t.TempDir(), "registry", parent.Namespace, parent.Name)
+	aliasMetadataFile := filepath.Join(connectorFolder, "aliased_connectors", alias.Name, "metadata.json")
+	writeTestFile(t, aliasMetadataFile, `{}`)
+	processedChangedFiles := ProcessedChangedFiles{
+		NewConnectors:     NewConnectors{alias: MetadataFile(aliasMetadataFile)},
+		AliasedConnectors: AliasedConnectors{alias: parent},
+	}
+
+	// Without a registry client, the links of the new aliased connector are listed as unresolved in the plan
+	p := createTestPublisher()
+	p.dryRun = true
+	p.registryClient = nil
+	plan := newPublicationPlan("staging")
+	assert.NoError(t, p.linkAliasedConnectorVersions(&plan, processedChangedFiles))
+	assert.Empty(t, plan.ConnectorVersions)
+	assert.Equal(t, []UnresolvedAliasLink{{Alias: alias, Parent: parent}}, plan.UnresolvedAliasLinks)
+	assert.Contains(t, renderPublicationPlanMarkdown(plan), "| `namespace1/alias1` | `namespace1/connector1` |")
+
+	// With a registry client, the versions of the parent are queried in dry-run mode too
+	p = createTestPublisher()
+	p.dryRun = true
+	mockGraphQLClient := p.registryClient.(*MockGraphQLClient)
+	mockGraphQLClient.On("Run", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		resp := args.Get(2).(*GetConnectorVersionsResponse)
+		resp.HubRegistryConnectorVersion = []ConnectorVersion{
+			{Namespace: "namespace1", Name: "connector1", Version: "v1.0.0", PackageDefinitionURL: "https://example.com/v1.0.0.tgz", Type: "ManagedDockerBuild"},
+		}
+	}).Return(nil)
+	plan = newPublicationPlan("staging")
+	assert.NoError(t, p.linkAliasedConnectorVersions(&plan, processedChangedFiles))
+	assert.Empty(t, plan.UnresolvedAliasLinks)
+	if assert.Len(t, plan.ConnectorVersions, 1) {
+		assert.Equal(t, "alias1", plan.ConnectorVersions[0].Name)
+		assert.Equal(t, "v1.0.0", plan.ConnectorVersions[0].Version)
+	}
+	mockGraphQLClient.AssertNumberOfCalls(t, "Run", 1)
+}
+
+func TestResolveImageDigests(t *testing.T) {
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	image := "ghcr.io/hasura/ndc-connector1:v1.0.0"
+	missingImage := "ghcr.io/hasura/ndc-connector2:v1.0.0"
+	amd64Image := "ghcr.io/hasura/ndc-connector4:v1.0.0"
+	mockImageResolver := &MockImageResolver{}
+	mockImageResolver.On("Inspect", image).Return(oci.Manifest{Digest: digest, Platforms: oci.RequiredPlatforms}, nil)
+	mockImageResolver.On("Inspect", missingImage).Return(oci.Manifest{}, oci.ErrManifestNotFound)
+	mockImageResolver.On("Inspect", amd64Image).Return(oci.Manifest{Digest: digest, Platforms: []oci.Platform{oci.LinuxAmd64}}, nil)
+
+	p := createTestPublisher()
+	p.imageResolver = mockImageResolver
+	connectorVersions := []ConnectorVersion{
+		{Namespace: "namespace1", Name: "connector1", Version: "v1.0.0", Image: &image, Type: "PreBuiltDockerImage"},
+		// The connector versions without image are not resolved
+		{Namespace: "namespace1", Name: "connector3", Version: "v1.0.0", Type: "ManagedDockerBuild"},
+	}
+
+	imageDigests, err := p.resolveImageDigests(connectorVersions)
+	assert.NoError(t, err)
+	assert.Equal(t, []ImageDigest{{
+		Connector:   Connector{Name: "connector1", Namespace: "namespace1"},
+		Version:     "v1.0.0",
+		Image:       image,
+		Digest:      digest,
+		PinnedImage: image + "@" + digest,
+	}}, imageDigests)
+
+	// The published connector versions are pinned to the digests of their images
+	pinnedConnectorVersions := append([]ConnectorVersion(nil), connectorVersions...)
+	pinImageDigests(pinnedConnectorVersions, imageDigests)
+	if assert.NotNil(t, pinnedConnectorVersions[0].Image) {
+		assert.Equal(t, image+"@"+digest, *pinnedConnectorVersions[0].Image)
+	}
+	assert.Nil(t, pinnedConnectorVersions[1].Image)
+	assert.Equal(t, image, *connectorVersions[0].Image)
+
+	plan := newPublicationPlan("staging")
+	plan.ConnectorVersions = connectorVersions[:1]
+	plan.ImageDigests = imageDigests
+	assert.Contains(t, renderPublicationPlanMarkdown(plan), "| `namespace1/connector1` | `v1.0.0` | `ghcr.io/hasura/ndc-connector1:v1.0.0` | `"+digest+"` |")
+	var summary bytes.Buffer
+	assert.NoError(t, WritePublicationSummary(&summary, plan))
+	assert.Contains(t, summary.String(), "Pinned 1 docker image(s):\n  - namespace1/connector1 v1.0.0: "+image+"@"+digest+"\n")
+
+	// A connector version whose image can't be resolved, or isn't multi-arch, fails the plan
+	connectorVersions = append(connectorVersions,
+		ConnectorVersion{Namespace: "namespace1", Name: "connector2", Version: "v1.0.0", Image: &missingImage, Type: "PreBuiltDockerImage"},
+		ConnectorVersion{Namespace: "namespace1", Name: "connector4", Version: "v1.0.0", Image: &amd64Image, Type: "PreBuiltDockerImage"},
+	)
+	_, err = p.resolveImageDigests(connectorVersions)
+	var connectorVersionErrors ConnectorVersionErrors
+	if assert.ErrorAs(t, err, &connectorVersionErrors) && assert.Len(t, connectorVersionErrors, 2) {
+		assert.ErrorIs(t, err, oci.ErrManifestNotFound)
+		var missingPlatformsErr *oci.MissingPlatformsError
+		if assert.ErrorAs(t, err, &missingPlatformsErr) {
+			assert.Equal(t, []oci.Platform{oci.LinuxArm64}, missingPlatformsErr.Missing)
+		}
+	}
+	mockImageResolver.AssertExpectations(t)
+}
+
+func TestForEachConcurrently(t *testing.T) {
+	items := make([]int, 20)
+	for i := range items {
+		items[i] = i
+	}
+
+	var running, maxRunning int32
+	var processed int32
+	forEachConcurrently(items, 3, func(_ int, _ int) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			observed := atomic.LoadInt32(&maxRunning)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxRunning, observed, current) {
+				break
+			}
+		}
+		atomic.AddInt32(&processed, 1)
+		atomic.AddInt32(&running, -1)
+	})
+
+	assert.Equal(t, int32(len(items)), processed)
+	assert.LessOrEqual(t, maxRunning, int32(3))
+}
+
+func TestProcessNewlyAddedConnectorVersionsAggregatesErrors(t *testing.T) {
+	tempDir := t.TempDir()
+	writePackaging := func(name string, content string) string {
+		path := filepath.Join(tempDir, name+".json")
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	connector1 := Connector{Name: "connector1", Namespace: "namespace1"}
+	connector2 := Connector{Name: "connector2", Namespace: "namespace1"}
+	newConnectorVersions := NewConnectorVersions{
+		connector1: {
+			"v1.0.0": writePackaging("connector1-v1.0.0", `{"version": "v1.0.0"}`),
+			"v1.0.1": filepath.Join(tempDir, "missing.json"),
+		},
+		connector2: {
+			"v0.1.0": writePackaging("connector2-v0.1.0", `{"version": "v0.1.0", "uri": ""}`),
+		},
+	}
+
+	p := createTestPublisher()
+	p.concurrency = 2
+	connectorVersions, packageUploads, err := p.processNewlyAddedConnectorVersions(newConnectorVersions, map[Connector]bool{})
+
+	assert.Nil(t, connectorVersions)
+	assert.Nil(t, packageUploads)
+
+	// All the failing versions are reported, not only the first one
+	var errs ConnectorVersionErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 3)
+	assert.ErrorContains(t, err, "3 connector version(s) failed")
+	assert.ErrorContains(t, err, "namespace1/connector1 v1.0.0: invalid or undefined TGZ URL")
+	assert.ErrorContains(t, err, "namespace1/connector1 v1.0.1: failed to read the connector packaging file")
+	assert.ErrorContains(t, err, "namespace1/connector2 v0.1.0: invalid or undefined TGZ URL")
+}
+
+func TestNewPublisher(t *testing.T) {
+	t.Run("Rejects an invalid publication environment", func(t *testing.T) {
+		_, err := NewPublisher(Config{Env: "prod", DryRun: true}, Clients{})
+		var configErr *ConfigError
+		assert.True(t, errors.As(err, &configErr))
+		assert.Equal(t, "publication environment", configErr.Setting)
+	})
+
+	t.Run("Requires the publication key and the clients outside of dry-run mode", func(t *testing.T) {
+		_, err := NewPublisher(Config{Env: "staging", BucketName: "test-bucket"}, Clients{})
+		var configErr *ConfigError
+		assert.True(t, errors.As(err, &configErr))
+		assert.Equal(t, "publication key", configErr.Setting)
+
+		_, err = NewPublisher(Config{Env: "staging", BucketName: "test-bucket", PublicationKey: "key"}, Clients{})
+		assert.True(t, errors.As(err, &configErr))
+		assert.Equal(t, "clients", configErr.Setting)
+	})
+
+	t.Run("Defaults the concurrency and the bucket name in dry-run mode", func(t *testing.T) {
+		p, err := NewPublisher(Config{Env: "production", DryRun: true}, Clients{})
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultConcurrency, p.concurrency)
+		assert.Equal(t, dryRunBucketName, p.bucketName)
+
+		plan := newPublicationPlan("production")
+		assert.ErrorIs(t, p.Apply(&plan), ErrDryRun)
+	})
+}
+
+func TestPublisherPlanReturnsConnectorErrors(t *testing.T) {
+	p, err := NewPublisher(Config{Env: "staging", DryRun: true}, Clients{})
+	assert.NoError(t, err)
+
+	_, err = p.Plan(ChangedFiles{
+		Deleted: []string{"registry/namespace1/connector1/README.md"},
+	})
+
+	var connectorErr *ConnectorError
+	assert.True(t, errors.As(err, &connectorErr))
+	assert.Equal(t, Connector{Name: "connector1", Namespace: "namespace1"}, connectorErr.Connector)
+}
+
+func TestApplyErrorIncludesRollbackFailures(t *testing.T) {
+	registryErr := &RegistryError{Err: errors.New("connection refused")}
+	rollbackErr := errors.New("failed to delete the package")
+
+	err := error(&ApplyError{Err: registryErr, RollbackErr: rollbackErr})
+	assert.ErrorIs(t, err, rollbackErr)
+	var target *RegistryError
+	assert.True(t, errors.As(err, &target))
+	assert.EqualError(t, err, "failed to update the registry: connection refused\nFailed to roll back the publication: failed to delete the package")
+
+	assert.EqualError(t, &ApplyError{Err: registryErr}, "failed to update the registry: connection refused")
+}
+
+func TestBuildRegistryPayloadFromPackagingSpec(t *testing.T) {
+	p, err := NewPublisher(Config{Env: "staging", DryRun: true}, Clients{})
+	assert.NoError(t, err)
+	dockerImage := "ghcr.io/hasura/ndc-test:v1.0.0"
+
+	connectorVersion, err := p.buildRegistryPayload("namespace1", "connector1", "v1.0.0", &ndchub.ConnectorMetadataDefinition{
+		PackagingDefinition: ndchub.PackagingDefinition{Type: ndchub.PrebuiltDockerImage, DockerImage: &dockerImage},
+	}, "https://example.com/package.tgz", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "PreBuiltDockerImage", connectorVersion.Type)
+	assert.Equal(t, &dockerImage, connectorVersion.Image)
+
+	connectorVersion, err = p.buildRegistryPayload("namespace1", "connector1", "v1.0.0", &ndchub.ConnectorMetadataDefinition{
+		PackagingDefinition: ndchub.PackagingDefinition{Type: ndchub.ManagedDockerBuild},
+	}, "https://example.com/package.tgz", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "ManagedDockerBuild", connectorVersion.Type)
+	assert.Nil(t, connectorVersion.Image)
+}
+
+func TestBuildRegistryPayloadForV2PackagingSpec(t *testing.T) {
+	p, err := NewPublisher(Config{Env: "staging", DryRun: true}, Clients{})
+	assert.NoError(t, err)
+	specVersion := ndchub.V2
+	ndcSpecGeneration := ndchub.V02
+
+	connectorVersion, err := p.buildRegistryPayload("namespace1", "connector1", "v2.0.0", &ndchub.ConnectorMetadataDefinition{
+		Version:             &specVersion,
+		NDCSpecGeneration:   &ndcSpecGeneration,
+		PackagingDefinition: ndchub.PackagingDefinition{Type: ndchub.ManagedDockerBuild},
+		CliPlugin: &ndchub.CliPluginDefinition{Binary: &ndchub.BinaryCliPluginDefinition{
+			Inline: &ndchub.BinaryInlineCliPluginDefinition{Type: ndchub.BinaryInlinePluginType},
+		}},
+	}, "https://example.com/package.tgz", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", connectorVersion.PackagingSpecVersion)
+	assert.Equal(t, "v0.2", *connectorVersion.NDCSpecGeneration)
+	assert.Equal(t, "BinaryInline", *connectorVersion.CLIPluginKind)
+
+	var summary bytes.Buffer
+	plan := newPublicationPlan("staging")
+	plan.ConnectorVersions = append(plan.ConnectorVersions, connectorVersion)
+	assert.NoError(t, WritePublicationSummary(&summary, plan))
+	assert.Equal(t, "Published 1 connector version(s):\n  - namespace1/connector1 v2.0.0: v2, ndc spec v0.2, BinaryInline CLI plugin\n", summary.String())
+}
+
+func TestLinkAliasedConnectorVersions(t *testing.T) {
+	parent := Connector{Name: "connector1", Namespace: "namespace1"}
+	alias1 := Connector{Name: "alias1", Namespace: "namespace1"}
+	alias2 := Connector{Name: "alias2", Namespace: "namespace1"}
+
+	connectorFolder := filepath.Join(t.TempDir(), "registry", parent.Namespace, parent.Name)
+	for _, alias := range []Connector{alias1, alias2} {
+		writeTestFile(t, filepath.Join(connectorFolder, "aliased_connectors", alias.Name, "metadata.json"), `{}`)
+	}
+	connectorVersionPath := func(version string) string {
+		return filepath.Join(connectorFolder, "releases", version, "connector-packaging.json")
+	}
+
+	p := createTestPublisher()
+	// alias2 is a new aliased connector, it is linked to the versions of its parent that are already in the registry
+	mockGraphQLClient := p.registryClient.(*MockGraphQLClient)
+	mockGraphQLClient.On("Run", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		resp := args.Get(2).(*GetConnectorVersionsResponse)
+		resp.HubRegistryConnectorVersion = []ConnectorVersion{
+			{Namespace: "namespace1", Name: "connector1", Version: "v1.0.0", PackageDefinitionURL: "https://example.com/v1.0.0.tgz", Type: "ManagedDockerBuild"},
+			{Namespace: "namespace1", Name: "connector1", Version: "v1.1.0", PackageDefinitionURL: "https://example.com/v1.1.0.tgz", Type: "ManagedDockerBuild"},
+		}
+	}).Return(nil)
+
+	plan := newPublicationPlan("staging")
+	plan.ConnectorVersions = []ConnectorVersion{
+		{Namespace: "namespace1", Name: "connector1", Version: "v1.1.0", PackageDefinitionURL: "https://example.com/v1.1.0.tgz", Type: "ManagedDockerBuild"},
+	}
+	plan.ConnectorVersionUpdates = []ConnectorVersionUpdate{newConnectorVersionDeprecation(parent, "v0.1.0")}
+
+	err := p.linkAliasedConnectorVersions(&plan, ProcessedChangedFiles{
+		NewConnectorVersions: NewConnectorVersions{parent: {"v1.1.0": connectorVersionPath("v1.1.0")}},
+		DeletedVersions:      DeletedConnectorVersions{parent: {"v0.1.0": connectorVersionPath("v0.1.0")}},
+		NewConnectors:        NewConnectors{alias2: MetadataFile(filepath.Join(connectorFolder, "aliased_connectors", "alias2", "metadata.json"))},
+		AliasedConnectors:    AliasedConnectors{alias2: parent},
+	})
+	assert.NoError(t, err)
+
+	var linkedVersions []string
+	for _, connectorVersion := range plan.ConnectorVersions {
+		linkedVersions = append(linkedVersions, connectorVersion.Name+" "+connectorVersion.Version+" "+connectorVersion.PackageDefinitionURL)
+	}
+	assert.Equal(t, []string{
+		"connector1 v1.1.0 https://example.com/v1.1.0.tgz",
+		"alias1 v1.1.0 https://example.com/v1.1.0.tgz",
+		"alias2 v1.1.0 https://example.com/v1.1.0.tgz",
+		"alias2 v1.0.0 https://example.com/v1.0.0.tgz",
+	}, linkedVersions)
+	assert.Equal(t, []ConnectorVersionUpdate{
+		newConnectorVersionDeprecation(parent, "v0.1.0"),
+		newConnectorVersionDeprecation(alias1, "v0.1.0"),
+		newConnectorVersionDeprecation(alias2, "v0.1.0"),
+	}, plan.ConnectorVersionUpdates)
+	mockGraphQLClient.AssertNumberOfCalls(t, "Run", 1)
+}
+
+func TestApplyPublicationPlanReportsEveryPackageDeletionFailure(t *testing.T) {
+	deletions := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deletions++
+		}
+		http.Error(w, `{"error": {"code": 403, "message": "forbidden"}}`, http.StatusForbidden)
+	}))
+	defer server.Close()
+	storageClient, err := storage.NewClient(context.Background(), option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	assert.NoError(t, err)
+	defer storageClient.Close()
+
+	p := createTestPublisher()
+	p.storageClient = storageClient
+	registryClient := p.registryClient.(*MockGraphQLClient)
+	registryClient.On("Run", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	connector := Connector{Name: "connector1", Namespace: "namespace1"}
+	plan := newPublicationPlan(StagingEnv)
+	for _, version := range []string{"v1.0.0", "v1.1.0"} {
+		plan.ConnectorVersionUpdates = append(plan.ConnectorVersionUpdates, newConnectorVersionDeprecation(connector, version))
+		plan.PackageDeletions = append(plan.PackageDeletions, PackageDeletion{Connector: connector, Version: version, Bucket: "test-bucket",
+			ObjectName: generateGCPObjectName(connector.Namespace, connector.Name, version)})
+	}
+
+	err = p.Apply(&plan)
+	var deletionErr *PackageDeletionError
+	assert.ErrorAs(t, err, &deletionErr)
+	assert.Len(t, deletionErr.Err, 2)
+	assert.ErrorContains(t, err, "the registry was updated")
+	// Every deletion is attempted after the registry mutation
+	assert.Equal(t, 2, deletions)
+	registryClient.AssertNumberOfCalls(t, "Run", 1)
+}
+
+func TestRegistryDbMutationPackagingSpecColumns(t *testing.T) {
+	generation, kind := "v0.2", "Binary"
+	connectorVersions := []ConnectorVersion{{Namespace: "namespace1", Name: "connector1", Version: "v1.0.0", NDCSpecGeneration: &generation, CLIPluginKind: &kind}}
+
+	// The registry may not have the packaging spec columns yet, so they are neither written nor updated
+	p := createTestPublisher()
+	assert.Equal(t, "image, package_definition_url, is_multitenant", p.connectorVersionUpdateColumns())
+	assert.Empty(t, p.packagingSpecColumnsSelection())
+	inserts := p.connectorVersionInserts(connectorVersions)
+	assert.Nil(t, inserts[0].NDCSpecGeneration)
+	assert.Nil(t, inserts[0].CLIPluginKind)
+	assert.Equal(t, &generation, connectorVersions[0].NDCSpecGeneration)
+
+	p.packagingSpecColumns = true
+	assert.Equal(t, "image, package_definition_url, is_multitenant, ndc_spec_generation, cli_plugin_kind", p.connectorVersionUpdateColumns())
+	assert.Contains(t, p.packagingSpecColumnsSelection(), "cli_plugin_kind")
+	assert.Equal(t, connectorVersions, p.connectorVersionInserts(connectorVersions))
+}
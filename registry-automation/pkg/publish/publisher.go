@@ -0,0 +1,178 @@
+// Package publish publishes the connectors of the registry folder to the hub registry: the connector
+// version packages are uploaded to Google Cloud Storage, the logos to Cloudinary, and the registry is
+// updated with the connectors and their versions.
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/storage"
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/machinebox/graphql"
+	"google.golang.org/api/option"
+)
+
+const (
+	StagingEnv    = "staging"
+	ProductionEnv = "production"
+)
+
+// dryRunBucketName is the bucket name used in the plan when no bucket is configured in dry-run mode
+const dryRunBucketName = "<GCP_BUCKET_NAME>"
+
+// Config is the configuration of a Publisher
+type Config struct {
+	// Env is the publication environment, staging or production
+	Env string
+	// PublicationKey authenticates the requests to the registry
+	PublicationKey string
+	// BucketName is the Google Cloud Storage bucket that the connector version packages are uploaded to
+	BucketName string
+	// Concurrency is the maximum number of connector versions processed at the same time, DefaultConcurrency if unset
+	Concurrency int
+	// DryRun is set when the publication plan is only computed, in which case none of the clients
+	// are required. The registry client is only used for the read-only queries of the plan if it is set.
+	DryRun bool
+	// ImageResolver resolves the docker images of the published connector versions to their digests, which are
+	// recorded in the plan. The images are not resolved if nil.
+	ImageResolver ImageResolver
+	// PackagingSpecColumns is set once the hub_registry_connector_version table of the registry has the
+	// ndc_spec_generation and cli_plugin_kind columns. The connector versions are published without them otherwise.
+	PackagingSpecColumns bool
+	// DeprecationColumn is set once the hub_registry_connector_version table of the registry has the is_deprecated
+	// column. The deleted connector versions can't be withdrawn otherwise.
+	DeprecationColumn bool
+}
+
+// Clients are the clients of the services that the connectors are published to
+type Clients struct {
+	Registry   GraphQLClientInterface
+	Storage    StorageClientInterface
+	Cloudinary CloudinaryInterface
+}
+
+// Credentials are the credentials used to build the clients of the services
+type Credentials struct {
+	RegistryGQLURL           string
+	GCPServiceAccountDetails string
+	CloudinaryURL            string
+}
+
+// NewClients builds the clients of the services from their credentials, the clients must be closed once
+// the publication is done.
+func NewClients(ctx context.Context, credentials Credentials) (Clients, error) {
+	var clients Clients
+
+	if credentials.RegistryGQLURL == "" {
+		return clients, &ConfigError{Setting: "registry GraphQL URL", Err: errors.New("not set")}
+	}
+	clients.Registry = graphql.NewClient(credentials.RegistryGQLURL)
+
+	if credentials.GCPServiceAccountDetails == "" {
+		return clients, &ConfigError{Setting: "GCP service account details", Err: errors.New("not set")}
+	}
+	storageClient, err := storage.NewClient(ctx, option.WithCredentialsJSON([]byte(credentials.GCPServiceAccountDetails)))
+	if err != nil {
+		return clients, &ConfigError{Setting: "GCP service account details", Err: fmt.Errorf("failed to create Google bucket client: %w", err)}
+	}
+	clients.Storage = &StorageClientWrapper{storageClient}
+
+	if credentials.CloudinaryURL == "" {
+		clients.Close()
+		return clients, &ConfigError{Setting: "cloudinary URL", Err: errors.New("not set")}
+	}
+	cloudinaryClient, err := cloudinary.NewFromURL(credentials.CloudinaryURL)
+	if err != nil {
+		clients.Close()
+		return clients, &ConfigError{Setting: "cloudinary URL", Err: fmt.Errorf("failed to create cloudinary client: %w", err)}
+	}
+	clients.Cloudinary = &CloudinaryWrapper{cloudinaryClient}
+
+	return clients, nil
+}
+
+// Close closes the Google Cloud Storage client, if it was built by NewClients
+func (c Clients) Close() error {
+	if storageWrapper, ok := c.Storage.(*StorageClientWrapper); ok {
+		return storageWrapper.Close()
+	}
+	return nil
+}
+
+// Publisher computes the publication plan of the changed files of a PR, and applies it
+type Publisher struct {
+	env            string
+	publicationKey string
+	bucketName     string
+	concurrency    int
+	dryRun         bool
+	imageResolver  ImageResolver
+	// packagingSpecColumns is set if the registry has the ndc_spec_generation and cli_plugin_kind columns
+	packagingSpecColumns bool
+	// deprecationColumn is set if the registry has the is_deprecated column
+	deprecationColumn bool
+	registryClient    GraphQLClientInterface
+	storageClient     StorageClientInterface
+	cloudinary        CloudinaryInterface
+}
+
+// NewPublisher builds a publisher, the clients are only required when the publisher is not in dry-run mode
+func NewPublisher(config Config, clients Clients) (*Publisher, error) {
+	if config.Env != StagingEnv && config.Env != ProductionEnv {
+		return nil, &ConfigError{Setting: "publication environment", Err: fmt.Errorf("invalid environment %q, expected %s or %s", config.Env, StagingEnv, ProductionEnv)}
+	}
+	if config.Concurrency < 1 {
+		config.Concurrency = DefaultConcurrency
+	}
+
+	if config.DryRun {
+		if config.BucketName == "" {
+			config.BucketName = dryRunBucketName
+		}
+	} else {
+		if config.PublicationKey == "" {
+			return nil, &ConfigError{Setting: "publication key", Err: errors.New("not set")}
+		}
+		if config.BucketName == "" {
+			return nil, &ConfigError{Setting: "bucket name", Err: errors.New("not set")}
+		}
+		if clients.Registry == nil || clients.Storage == nil || clients.Cloudinary == nil {
+			return nil, &ConfigError{Setting: "clients", Err: errors.New("the registry, storage and cloudinary clients are required")}
+		}
+	}
+
+	return &Publisher{
+		env:                  config.Env,
+		publicationKey:       config.PublicationKey,
+		bucketName:           config.BucketName,
+		concurrency:          config.Concurrency,
+		dryRun:               config.DryRun,
+		imageResolver:        config.ImageResolver,
+		packagingSpecColumns: config.PackagingSpecColumns,
+		deprecationColumn:    config.DeprecationColumn,
+		registryClient:       clients.Registry,
+		storageClient:        clients.Storage,
+		cloudinary:           clients.Cloudinary,
+	}, nil
+}
+
+// Plan computes all the uploads and the registry payloads required to publish the changed files. The
+// connector version packages are downloaded to inspect them, but nothing is uploaded and the registry
+// is not mutated.
+func (p *Publisher) Plan(changedFiles ChangedFiles) (PublicationPlan, error) {
+	return p.buildPublicationPlan(ProcessChangedFiles(changedFiles))
+}
+
+// Apply uploads the logos and the connector version packages of the plan, and updates the registry.
+// If any step fails, the uploads are rolled back and an *ApplyError is returned.
+func (p *Publisher) Apply(plan *PublicationPlan) error {
+	if p.dryRun {
+		return ErrDryRun
+	}
+	if plan.Env != p.env {
+		return &ConfigError{Setting: "publication environment", Err: fmt.Errorf("the plan was computed for %s, the publisher publishes to %s", plan.Env, p.env)}
+	}
+	return p.applyPublicationPlan(plan)
+}
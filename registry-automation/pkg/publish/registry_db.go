@@ -0,0 +1,374 @@
+package publish
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/machinebox/graphql"
+)
+
+type HubRegistryConnectorInsertInput struct {
+	Name      string `json:"name"`
+	Title     string `json:"title"`
+	Namespace string `json:"namespace"`
+}
+
+type NewConnectorsInsertInput struct {
+	HubRegistryConnectors []HubRegistryConnectorInsertInput `json:"hub_registry_connectors"`
+	ConnectorOverviews    []ConnectorOverviewInsert         `json:"connector_overviews"`
+}
+
+// struct to store the response of teh GetConnectorInfo query
+type GetConnectorInfoResponse struct {
+	HubRegistryConnector []struct {
+		Name                 string `json:"name"`
+		MultitenantConnector *struct {
+			ID string `json:"id"`
+		} `json:"multitenant_connector"`
+	} `json:"hub_registry_connector"`
+}
+
+func (p *Publisher) getConnectorInfoFromRegistry(connectorNamespace string, connectorName string) (GetConnectorInfoResponse, error) {
+	var respData GetConnectorInfoResponse
+
+	ctx := context.Background()
+
+	req := graphql.NewRequest(`
+query GetConnectorInfo ($name: String!, $namespace: String!) {
+  hub_registry_connector(where: {_and: [{name: {_eq: $name}}, {namespace: {_eq: $namespace}}]}) {
+    name
+    multitenant_connector {
+      id
+    }
+  }
+}`)
+	req.Var("name", connectorName)
+	req.Var("namespace", connectorNamespace)
+
+	req.Header.Set("x-hasura-role", "connector_publishing_automation")
+	req.Header.Set("x-connector-publication-key", p.publicationKey)
+
+	// Execute the GraphQL query and check the response.
+	if err := p.registryClient.Run(ctx, req, &respData); err != nil {
+		return respData, err
+	} else {
+		if len(respData.HubRegistryConnector) == 0 {
+			return respData, nil
+		}
+	}
+
+	return respData, nil
+}
+
+// GetConnectorVersionsResponse is the response of the GetConnectorVersions query
+type GetConnectorVersionsResponse struct {
+	HubRegistryConnectorVersion []ConnectorVersion `json:"hub_registry_connector_version"`
+}
+
+// getConnectorVersionsFromRegistry returns the versions of the connector that are not deprecated in the registry, or
+// all its versions if the registry doesn't have the is_deprecated column
+func (p *Publisher) getConnectorVersionsFromRegistry(connectorNamespace string, connectorName string) ([]ConnectorVersion, error) {
+	var respData GetConnectorVersionsResponse
+
+	ctx := context.Background()
+
+	notDeprecated := ""
+	if p.deprecationColumn {
+		notDeprecated = ", {is_deprecated: {_eq: false}}"
+	}
+
+	req := graphql.NewRequest(fmt.Sprintf(`
+query GetConnectorVersions ($name: String!, $namespace: String!) {
+  hub_registry_connector_version(where: {_and: [{name: {_eq: $name}}, {namespace: {_eq: $namespace}}%s]}) {
+    namespace
+    name
+    version
+    image
+    package_definition_url
+    is_multitenant
+    type
+%s
+  }
+}`, notDeprecated, p.packagingSpecColumnsSelection()))
+	req.Var("name", connectorName)
+	req.Var("namespace", connectorNamespace)
+
+	req.Header.Set("x-hasura-role", "connector_publishing_automation")
+	req.Header.Set("x-connector-publication-key", p.publicationKey)
+
+	// Execute the GraphQL query and check the response.
+	if err := p.registryClient.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+
+	return respData.HubRegistryConnectorVersion, nil
+}
+
+// RegistrySnapshot is the content of the registry tables that are published from the registry folder
+type RegistrySnapshot struct {
+	HubRegistryConnector        []HubRegistryConnector        `json:"hub_registry_connector"`
+	ConnectorOverview           []ConnectorOverview           `json:"connector_overview"`
+	HubRegistryConnectorVersion []HubRegistryConnectorVersion `json:"hub_registry_connector_version"`
+}
+
+type HubRegistryConnector struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type ConnectorOverview struct {
+	Name          string `json:"name"`
+	Namespace     string `json:"namespace"`
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	Docs          string `json:"docs"`
+	LatestVersion string `json:"latest_version"`
+}
+
+type HubRegistryConnectorVersion struct {
+	Name                 string `json:"name"`
+	Namespace            string `json:"namespace"`
+	Version              string `json:"version"`
+	PackageDefinitionURL string `json:"package_definition_url"`
+	IsDeprecated         bool   `json:"is_deprecated"`
+}
+
+// getRegistrySnapshot queries all the connectors, their overviews and their versions from the registry. The
+// deprecation of the versions is only queried if the registry has the is_deprecated column.
+func getRegistrySnapshot(client GraphQLClientInterface, publicationKey string, deprecationColumn bool) (RegistrySnapshot, error) {
+	var respData RegistrySnapshot
+
+	ctx := context.Background()
+
+	isDeprecated := ""
+	if deprecationColumn {
+		isDeprecated = "    is_deprecated"
+	}
+
+	req := graphql.NewRequest(fmt.Sprintf(`
+query GetRegistrySnapshot {
+  hub_registry_connector {
+    name
+    namespace
+  }
+  connector_overview {
+    name
+    namespace
+    title
+    description
+    docs
+    latest_version
+  }
+  hub_registry_connector_version {
+    name
+    namespace
+    version
+    package_definition_url
+%s
+  }
+}`, isDeprecated))
+
+	req.Header.Set("x-hasura-role", "connector_publishing_automation")
+	req.Header.Set("x-connector-publication-key", publicationKey)
+
+	// Execute the GraphQL query and check the response.
+	if err := client.Run(ctx, req, &respData); err != nil {
+		return respData, fmt.Errorf("failed to query the registry: %w", err)
+	}
+
+	return respData, nil
+}
+
+// reconcileDriftMutation updates the connector overviews and the connector versions that drifted from the registry folder
+const reconcileDriftMutation = `
+mutation ReconcileRegistryDrift (
+  $connector_overview_updates: [connector_overview_updates!]!,
+  $connector_version_updates: [hub_registry_connector_version_updates!]!
+){
+  update_connector_overview_many(updates: $connector_overview_updates) {
+    affected_rows
+  }
+
+  update_hub_registry_connector_version_many(updates: $connector_version_updates) {
+    affected_rows
+  }
+}
+`
+
+type ConnectorAuthorNestedInsertOnConflict struct {
+	Constraint string   `json:"constraint"`
+	UpdateCols []string `json:"update_columns,omitempty"`
+}
+
+type ConnectorAuthorNestedInsert struct {
+	Data       ConnectorAuthor                        `json:"data"`
+	OnConflict *ConnectorAuthorNestedInsertOnConflict `json:"on_conflict,omitempty"`
+}
+
+type ConnectorOverviewInsert struct {
+	Namespace     string                      `json:"namespace"`
+	Name          string                      `json:"name"`
+	Title         string                      `json:"title"`
+	Description   string                      `json:"description"`
+	Logo          string                      `json:"logo"`
+	Docs          string                      `json:"docs"`
+	IsVerified    bool                        `json:"is_verified"`
+	IsHosted      bool                        `json:"is_hosted_by_hasura"`
+	LatestVersion string                      `json:"latest_version"`
+	Author        ConnectorAuthorNestedInsert `json:"author"`
+}
+
+type ConnectorAuthor struct {
+	Name         string `json:"name"`
+	SupportEmail string `json:"support_email"`
+	Website      string `json:"website"`
+}
+
+// registryDbMutation is a function to insert data into the registry database, all the mutations are done in a single transaction.
+func (p *Publisher) registryDbMutation(newConnectors NewConnectorsInsertInput, connectorOverviewUpdates []ConnectorOverviewUpdate, connectorVersionInserts []ConnectorVersion, connectorVersionUpdates []ConnectorVersionUpdate) error {
+	var respData map[string]interface{}
+	ctx := context.Background()
+	mutationQuery := fmt.Sprintf(`
+mutation HubRegistryMutationRequest (
+  $hub_registry_connectors:[hub_registry_connector_insert_input!]!,
+  $connector_overview_inserts: [connector_overview_insert_input!]!,
+  $connector_overview_updates: [connector_overview_updates!]!,
+  $connector_version_inserts: [hub_registry_connector_version_insert_input!]!,
+  $connector_version_updates: [hub_registry_connector_version_updates!]!
+){
+
+  insert_hub_registry_connector(objects: $hub_registry_connectors) {
+affected_rows
+  }
+  insert_connector_overview(objects: $connector_overview_inserts) {
+    affected_rows
+  }
+  insert_hub_registry_connector_version(objects: $connector_version_inserts, on_conflict: {constraint: connector_version_namespace_name_version_key, update_columns: [%s]}) {
+    affected_rows
+  }
+
+  update_connector_overview_many(updates: $connector_overview_updates) {
+    affected_rows
+  }
+
+  update_hub_registry_connector_version_many(updates: $connector_version_updates) {
+    affected_rows
+  }
+}
+`, p.connectorVersionUpdateColumns())
+	req := graphql.NewRequest(mutationQuery)
+	req.Var("hub_registry_connectors", newConnectors.HubRegistryConnectors)
+	req.Var("connector_overview_inserts", newConnectors.ConnectorOverviews)
+	req.Var("connector_overview_updates", connectorOverviewUpdates)
+	req.Var("connector_version_inserts", p.connectorVersionInserts(connectorVersionInserts))
+	req.Var("connector_version_updates", connectorVersionUpdates)
+
+	req.Header.Set("x-hasura-role", "connector_publishing_automation")
+	req.Header.Set("x-connector-publication-key", p.publicationKey)
+
+	// Execute the GraphQL query and check the response.
+	if err := p.registryClient.Run(ctx, req, &respData); err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+// registryDbMutationStaging is a function to insert data into the staging registry database, all the mutations are done in a single transaction.
+func (p *Publisher) registryDbMutationStaging(newConnectors NewConnectorsInsertInput, connectorOverviewUpdates []ConnectorOverviewUpdate, connectorVersionInserts []ConnectorVersion, connectorVersionUpdates []ConnectorVersionUpdate) error {
+	var respData map[string]interface{}
+	ctx := context.Background()
+	mutationQuery := fmt.Sprintf(`
+mutation HubRegistryMutationRequest (
+  $hub_registry_connectors:[hub_registry_connector_insert_input!]!,
+  $connector_overview_inserts: [connector_overview_insert_input!]!,
+  $connector_overview_updates: [connector_overview_updates!]!,
+  $connector_version_inserts: [hub_registry_connector_version_insert_input!]!,
+  $connector_version_updates: [hub_registry_connector_version_updates!]!
+){
+
+  insert_hub_registry_connector(objects: $hub_registry_connectors, on_conflict: {constraint: connector_pkey}) {
+affected_rows
+  }
+  insert_connector_overview(objects: $connector_overview_inserts, on_conflict: {constraint: connector_overview_pkey, update_columns: [docs, logo]}) {
+    affected_rows
+  }
+  insert_hub_registry_connector_version(objects: $connector_version_inserts, on_conflict: {constraint: connector_version_namespace_name_version_key, update_columns: [%s]}) {
+    affected_rows
+  }
+
+  update_connector_overview_many(updates: $connector_overview_updates) {
+    affected_rows
+  }
+
+  update_hub_registry_connector_version_many(updates: $connector_version_updates) {
+    affected_rows
+  }
+}
+`, p.connectorVersionUpdateColumns())
+
+	// update newConnectors.ConnectorOverviews to have on_conflict
+	for i := range newConnectors.ConnectorOverviews {
+		newConnectors.ConnectorOverviews[i].Author.OnConflict = &ConnectorAuthorNestedInsertOnConflict{
+			Constraint: "connector_author_connector_title_key",
+			UpdateCols: []string{},
+		}
+	}
+
+	req := graphql.NewRequest(mutationQuery)
+	req.Var("hub_registry_connectors", newConnectors.HubRegistryConnectors)
+	req.Var("connector_overview_inserts", newConnectors.ConnectorOverviews)
+	req.Var("connector_overview_updates", connectorOverviewUpdates)
+	req.Var("connector_version_inserts", p.connectorVersionInserts(connectorVersionInserts))
+	req.Var("connector_version_updates", connectorVersionUpdates)
+
+	req.Header.Set("x-hasura-role", "connector_publishing_automation")
+	req.Header.Set("x-connector-publication-key", p.publicationKey)
+
+	// Execute the GraphQL query and check the response.
+	if err := p.registryClient.Run(ctx, req, &respData); err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+// packagingSpecColumns are the columns of hub_registry_connector_version that record the packaging spec of the
+// connector versions, they are only written and read once the registry has them
+var packagingSpecColumns = []string{"ndc_spec_generation", "cli_plugin_kind"}
+
+// connectorVersionUpdateColumns returns the columns of hub_registry_connector_version that are updated when a
+// connector version is published again
+func (p *Publisher) connectorVersionUpdateColumns() string {
+	columns := []string{"image", "package_definition_url", "is_multitenant"}
+	if p.packagingSpecColumns {
+		columns = append(columns, packagingSpecColumns...)
+	}
+	return strings.Join(columns, ", ")
+}
+
+// packagingSpecColumnsSelection returns the packaging spec columns selected by the queries of the connector versions
+func (p *Publisher) packagingSpecColumnsSelection() string {
+	if !p.packagingSpecColumns {
+		return ""
+	}
+	return "    " + strings.Join(packagingSpecColumns, "\n    ")
+}
+
+// connectorVersionInserts returns the connector versions to insert, without their packaging spec unless the registry
+// has the packaging spec columns
+func (p *Publisher) connectorVersionInserts(connectorVersions []ConnectorVersion) []ConnectorVersion {
+	if p.packagingSpecColumns {
+		return connectorVersions
+	}
+	inserts := make([]ConnectorVersion, len(connectorVersions))
+	for i, connectorVersion := range connectorVersions {
+		connectorVersion.NDCSpecGeneration = nil
+		connectorVersion.CLIPluginKind = nil
+		inserts[i] = connectorVersion
+	}
+	return inserts
+}
@@ -0,0 +1,160 @@
+package publish
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+)
+
+// SyncFilter restricts a sync to the connectors of a namespace, or to a single connector
+type SyncFilter struct {
+	Namespace string
+	Connector string
+}
+
+func (f SyncFilter) matches(connector Connector) bool {
+	return (f.Namespace == "" || f.Namespace == connector.Namespace) && (f.Connector == "" || f.Connector == connector.Name)
+}
+
+// ListRegistryFiles lists the files of the registry folder that are published, as if they were all newly
+// added. The paths are relative to the repo root, like the changed files of a PR.
+func ListRegistryFiles(repoRoot string, filter SyncFilter) (ChangedFiles, error) {
+	changedFiles := ChangedFiles{Added: make([]string, 0)}
+
+	connectorFolders, err := filepath.Glob(filepath.Join(repoRoot, "registry", "*", "*"))
+	if err != nil {
+		return changedFiles, fmt.Errorf("failed to list the connectors of the registry folder: %w", err)
+	}
+	sort.Strings(connectorFolders)
+
+	for _, connectorFolder := range connectorFolders {
+		connector := Connector{Name: filepath.Base(connectorFolder), Namespace: filepath.Base(filepath.Dir(connectorFolder))}
+		if !filter.matches(connector) {
+			continue
+		}
+
+		patterns := []string{"metadata.json", "README.md", "logo.png", "logo.svg", filepath.Join("releases", "*", "connector-packaging.json")}
+		// The aliased connectors are published along with the connector they alias
+		for _, aliasedConnectorFile := range []string{"metadata.json", "README.md", "logo.png", "logo.svg"} {
+			patterns = append(patterns, filepath.Join(ndchub.AliasedConnectorsFolder, "*", aliasedConnectorFile))
+		}
+		for _, pattern := range patterns {
+			files, err := filepath.Glob(filepath.Join(connectorFolder, pattern))
+			if err != nil {
+				return changedFiles, fmt.Errorf("failed to list the files of the connector %s/%s: %w", connector.Namespace, connector.Name, err)
+			}
+			sort.Strings(files)
+			for _, file := range files {
+				relativePath, err := filepath.Rel(repoRoot, file)
+				if err != nil {
+					return changedFiles, err
+				}
+				changedFiles.Added = append(changedFiles.Added, filepath.ToSlash(relativePath))
+			}
+		}
+	}
+
+	return changedFiles, nil
+}
+
+// PlanSync computes the plan that republishes the listed registry files. Unlike Plan, the connectors that
+// already exist in the registry are updated instead of being inserted, and the packages that are already
+// uploaded with the same checksum are not uploaded again, so that a sync can be run repeatedly.
+func (p *Publisher) PlanSync(registryFiles ChangedFiles) (PublicationPlan, error) {
+	processed := ProcessChangedFiles(registryFiles)
+
+	// The registry is not queried in dry-run mode, every connector is planned as a new connector
+	existingConnectors := make(map[Connector]MetadataFile)
+	if !p.dryRun {
+		for connector, metadataFile := range processed.NewConnectors {
+			connectorInfo, err := p.getConnectorInfoFromRegistry(connector.Namespace, connector.Name)
+			if err != nil {
+				return newPublicationPlan(p.env), &ConnectorError{Connector: connector, Err: fmt.Errorf("Failed to get the connector info from the registry: %w", err)}
+			}
+			if len(connectorInfo.HubRegistryConnector) > 0 {
+				existingConnectors[connector] = metadataFile
+				delete(processed.NewConnectors, connector)
+			}
+		}
+	}
+
+	plan, err := p.buildPublicationPlan(processed)
+	if err != nil {
+		return plan, err
+	}
+
+	for _, connector := range sortedConnectors(existingConnectors) {
+		connectorOverviewUpdate, err := p.processResyncedConnector(connector, existingConnectors[connector])
+		if err != nil {
+			return plan, &ConnectorError{Connector: connector, Err: fmt.Errorf("Failed to process the existing connector: %w", err)}
+		}
+		plan.ConnectorOverviewUpdates = append(plan.ConnectorOverviewUpdates, connectorOverviewUpdate)
+		if logo, ok := processed.NewLogos[connector]; ok {
+			plan.LogoUploads = append(plan.LogoUploads, newLogoUpload(connector, logo))
+		}
+	}
+
+	if !p.dryRun {
+		plan.PackageUploads, err = p.skipUploadedPackages(plan.PackageUploads)
+		if err != nil {
+			return plan, err
+		}
+	}
+
+	return plan, nil
+}
+
+// processResyncedConnector updates every field of the overview of a connector that already exists in the registry
+func (p *Publisher) processResyncedConnector(connector Connector, metadataFile MetadataFile) (ConnectorOverviewUpdate, error) {
+	connectorOverviewUpdate, err := processModifiedConnector(metadataFile, connector)
+	if err != nil {
+		return connectorOverviewUpdate, err
+	}
+
+	docs, err := readFile(filepath.Join(filepath.Dir(string(metadataFile)), "README.md"))
+	if err != nil {
+		return connectorOverviewUpdate, fmt.Errorf("Failed to read the README file of the connector: %s : %v", connector.Name, err)
+	}
+	docsContent := string(docs)
+	connectorOverviewUpdate.Set.Docs = &docsContent
+	logo := plannedLogoURL(connector)
+	connectorOverviewUpdate.Set.Logo = &logo
+
+	return connectorOverviewUpdate, nil
+}
+
+// skipUploadedPackages drops the package uploads whose object already exists in the bucket with the same checksum.
+// The objects uploaded before their sha256 digest was stored in their metadata are uploaded again.
+func (p *Publisher) skipUploadedPackages(packageUploads []PackageUpload) ([]PackageUpload, error) {
+	remaining := make([]PackageUpload, 0, len(packageUploads))
+	for _, packageUpload := range packageUploads {
+		uploaded, err := objectMatchesFile(p.storageClient, packageUpload.Bucket, packageUpload.ObjectName, packageUpload.LocalPath, packageUpload.objectMetadata())
+		if err != nil {
+			return nil, &ConnectorVersionError{Connector: packageUpload.Connector, Version: packageUpload.Version, Err: fmt.Errorf("failed to compare the package with the uploaded package: %w", err)}
+		}
+		if uploaded {
+			fmt.Fprintf(os.Stderr, "Skipping the upload of the unchanged package: %s\n", packageUpload.ObjectName)
+			continue
+		}
+		remaining = append(remaining, packageUpload)
+	}
+	return remaining, nil
+}
+
+// sortedConnectors returns the connectors of the map, sorted to process them in a stable order
+func sortedConnectors[T any](connectors map[Connector]T) []Connector {
+	sorted := make([]Connector, 0, len(connectors))
+	for connector := range connectors {
+		sorted = append(sorted, connector)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
@@ -0,0 +1,54 @@
+package publish
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListRegistryFiles(t *testing.T) {
+	repoRoot := t.TempDir()
+	for _, file := range []string{
+		"registry/namespace1/connector1/metadata.json",
+		"registry/namespace1/connector1/README.md",
+		"registry/namespace1/connector1/logo.svg",
+		"registry/namespace1/connector1/releases/v1.0.0/connector-packaging.json",
+		"registry/namespace1/connector1/releases/v1.0.0/test-config.json",
+		"registry/namespace1/connector1/aliased_connectors/alias1/metadata.json",
+		"registry/namespace1/connector2/metadata.json",
+		"registry/namespace2/connector1/metadata.json",
+	} {
+		writeTestFile(t, filepath.Join(repoRoot, file), "{}")
+	}
+
+	t.Run("Lists the published files of every connector", func(t *testing.T) {
+		changedFiles, err := ListRegistryFiles(repoRoot, SyncFilter{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"registry/namespace1/connector1/metadata.json",
+			"registry/namespace1/connector1/README.md",
+			"registry/namespace1/connector1/logo.svg",
+			"registry/namespace1/connector1/releases/v1.0.0/connector-packaging.json",
+			"registry/namespace1/connector1/aliased_connectors/alias1/metadata.json",
+			"registry/namespace1/connector2/metadata.json",
+			"registry/namespace2/connector1/metadata.json",
+		}, changedFiles.Added)
+
+		// Every listed file is processed as a newly added file
+		processed := ProcessChangedFiles(changedFiles)
+		assert.Len(t, processed.NewConnectors, 4)
+		assert.Len(t, processed.NewConnectorVersions, 1)
+		assert.Equal(t, AliasedConnectors{{Name: "alias1", Namespace: "namespace1"}: {Name: "connector1", Namespace: "namespace1"}}, processed.AliasedConnectors)
+	})
+
+	t.Run("Filters by namespace and connector", func(t *testing.T) {
+		changedFiles, err := ListRegistryFiles(repoRoot, SyncFilter{Namespace: "namespace1", Connector: "connector2"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"registry/namespace1/connector2/metadata.json"}, changedFiles.Added)
+
+		changedFiles, err = ListRegistryFiles(repoRoot, SyncFilter{Connector: "connector1"})
+		assert.NoError(t, err)
+		assert.Len(t, changedFiles.Added, 6)
+	})
+}
@@ -0,0 +1,71 @@
+package publish
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+)
+
+func generateGCPObjectName(namespace, connectorName, version string) string {
+	return fmt.Sprintf("packages/%s/%s/%s/package.tgz", namespace, connectorName, version)
+}
+
+// Reads a JSON file and attempts to parse the content of the file
+// into the type T.
+// Note: The location is relative to the root of the repository
+func readJSONFile[T any](location string) (T, error) {
+	// Read the file
+	var result T
+	fileBytes, err := readFile(location)
+	if err != nil {
+		return result, err
+	}
+
+	if err := json.Unmarshal(fileBytes, &result); err != nil {
+		return result, fmt.Errorf("error parsing JSON: %v", err)
+	}
+
+	return result, nil
+}
+
+// Note: The location is relative to the root of the repository
+func readFile(location string) ([]byte, error) {
+	// Read the file
+	var fileBytes []byte
+	var err error
+
+	fileBytes, err = os.ReadFile(repoPath(location))
+	if err != nil {
+		return fileBytes, fmt.Errorf("error reading file at location: %s %v", location, err)
+	}
+
+	return fileBytes, nil
+}
+
+// repoPath returns the path of a location relative to the root of the repository, from the registry-automation folder
+func repoPath(location string) string {
+	if filepath.IsAbs(location) {
+		return location
+	}
+	return "../" + location // previous behavior; written for backwards compatibility
+}
+
+// listAliasedConnectors lists the aliased connectors in the aliased_connectors folder of the connector
+// Note: The location of the connector folder is relative to the root of the repository
+func listAliasedConnectors(connector Connector, connectorFolder string) ([]Connector, error) {
+	metadataFiles, err := filepath.Glob(filepath.Join(repoPath(connectorFolder), ndchub.AliasedConnectorsFolder, "*", ndchub.MetadataJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the aliased connectors: %w", err)
+	}
+	sort.Strings(metadataFiles)
+
+	var aliases []Connector
+	for _, metadataFile := range metadataFiles {
+		aliases = append(aliases, Connector{Name: filepath.Base(filepath.Dir(metadataFile)), Namespace: connector.Namespace})
+	}
+	return aliases, nil
+}
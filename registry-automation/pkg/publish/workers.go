@@ -0,0 +1,58 @@
+package publish
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultConcurrency is the default number of connector versions that are processed at the same time
+const DefaultConcurrency = 4
+
+// forEachConcurrently calls fn for every item, with at most `concurrency` calls running at the same
+// time, and waits for all the calls to return.
+func forEachConcurrently[T any](items []T, concurrency int, fn func(index int, item T)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	semaphore := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, item T) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			fn(i, item)
+		}(i, item)
+	}
+	wg.Wait()
+}
+
+// connectorVersionJob is a connector version that is processed by a worker
+type connectorVersionJob struct {
+	Connector Connector
+	Version   string
+	// Path of the connector-packaging.json file of the connector version
+	Path string
+}
+
+// connectorVersionJobs flattens the connector versions into jobs, sorted to process them in a stable order
+func connectorVersionJobs(connectorVersions map[Connector]map[string]string) []connectorVersionJob {
+	var jobs []connectorVersionJob
+	for connector, versions := range connectorVersions {
+		for version, path := range versions {
+			jobs = append(jobs, connectorVersionJob{Connector: connector, Version: version, Path: path})
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].Connector.Namespace != jobs[j].Connector.Namespace {
+			return jobs[i].Connector.Namespace < jobs[j].Connector.Namespace
+		}
+		if jobs[i].Connector.Name != jobs[j].Connector.Name {
+			return jobs[i].Connector.Name < jobs[j].Connector.Name
+		}
+		return jobs[i].Version < jobs[j].Version
+	})
+	return jobs
+}
@@ -0,0 +1,251 @@
+package pkg
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ConnectorMetadataPath is the path of the connector metadata in the connector package
+const ConnectorMetadataPath = ".hasura-connector/connector-metadata.yaml"
+
+// ExtractLimits bounds the content read from an untrusted archive
+type ExtractLimits struct {
+	// MaxUncompressedSize is the maximum total size of the entries of the archive
+	MaxUncompressedSize int64
+	// MaxFiles is the maximum number of entries of the archive
+	MaxFiles int
+}
+
+// DefaultExtractLimits are the limits applied to the connector packages
+var DefaultExtractLimits = ExtractLimits{
+	MaxUncompressedSize: 512 << 20,
+	MaxFiles:            10000,
+}
+
+// ErrArchiveLimitExceeded is returned when an archive exceeds the extract limits
+var ErrArchiveLimitExceeded = errors.New("archive exceeds the extract limits")
+
+// UnsafeEntryError is returned when an archive contains an entry that would be written outside of the
+// destination folder, or an entry of an unsupported type
+type UnsafeEntryError struct {
+	Name   string
+	Reason string
+}
+
+func (e *UnsafeEntryError) Error() string {
+	return fmt.Sprintf("unsafe archive entry %q: %s", e.Name, e.Reason)
+}
+
+// tarGzReader iterates over the entries of a tar.gz archive while enforcing the extract limits
+type tarGzReader struct {
+	tarReader *tar.Reader
+	limits    ExtractLimits
+	files     int
+	size      int64
+}
+
+func newTarGzReader(r io.Reader, limits ExtractLimits) (*tarGzReader, io.Closer, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading the gzip archive: %w", err)
+	}
+	return &tarGzReader{tarReader: tar.NewReader(gzipReader), limits: limits}, gzipReader, nil
+}
+
+// next returns the next entry of the archive with its cleaned name, or io.EOF at the end of the archive
+func (r *tarGzReader) next() (*tar.Header, string, error) {
+	for {
+		header, err := r.tarReader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, "", io.EOF
+			}
+			return nil, "", fmt.Errorf("error reading the tar archive: %w", err)
+		}
+		// The global headers of the archive are not entries
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		r.files++
+		if r.files > r.limits.MaxFiles {
+			return nil, "", fmt.Errorf("%w: more than %d entries", ErrArchiveLimitExceeded, r.limits.MaxFiles)
+		}
+		r.size += header.Size
+		if header.Size < 0 || r.size > r.limits.MaxUncompressedSize {
+			return nil, "", fmt.Errorf("%w: more than %d bytes", ErrArchiveLimitExceeded, r.limits.MaxUncompressedSize)
+		}
+
+		name, err := cleanEntryName(header.Name)
+		if err != nil {
+			return nil, "", err
+		}
+		return header, name, nil
+	}
+}
+
+// cleanEntryName returns the slash-separated name of the entry relative to the root of the archive, the
+// absolute names and the names that escape the root of the archive are rejected
+func cleanEntryName(name string) (string, error) {
+	if name == "" {
+		return "", &UnsafeEntryError{Name: name, Reason: "empty name"}
+	}
+	if path.IsAbs(name) || filepath.IsAbs(name) || strings.Contains(name, `\`) {
+		return "", &UnsafeEntryError{Name: name, Reason: "absolute path"}
+	}
+	cleaned := path.Clean(name)
+	if !filepath.IsLocal(filepath.FromSlash(cleaned)) {
+		return "", &UnsafeEntryError{Name: name, Reason: "path escapes the destination folder"}
+	}
+	return cleaned, nil
+}
+
+// ExtractTarGz extracts the tar.gz archive at src into the dest folder. Only regular files, folders and symlinks
+// pointing below their own folder, without parent references, are extracted, the other entries are rejected with an
+// *UnsafeEntryError.
+func ExtractTarGz(src, dest string, limits ExtractLimits) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("error opening the archive: %w", err)
+	}
+	defer file.Close()
+
+	reader, closer, err := newTarGzReader(file, limits)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return fmt.Errorf("error creating destination directory: %v", err)
+	}
+
+	// Entries are never written through the symlinks of the archive, so the symlink targets are
+	// always resolved relative to a real folder
+	symlinks := make(map[string]bool)
+
+	for {
+		header, name, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			continue
+		}
+		if symlinks[name] {
+			return &UnsafeEntryError{Name: header.Name, Reason: "entry overwrites a symlink"}
+		}
+		for parent := path.Dir(name); parent != "."; parent = path.Dir(parent) {
+			if symlinks[parent] {
+				return &UnsafeEntryError{Name: header.Name, Reason: "entry is written through a symlink"}
+			}
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("error creating directory %s: %w", name, err)
+			}
+		case tar.TypeReg:
+			if err := writeEntry(reader.tarReader, target, header); err != nil {
+				return fmt.Errorf("error extracting %s: %w", name, err)
+			}
+		case tar.TypeSymlink:
+			linkTarget := header.Linkname
+			if path.IsAbs(linkTarget) || filepath.IsAbs(linkTarget) {
+				return &UnsafeEntryError{Name: header.Name, Reason: "symlink to an absolute path"}
+			}
+			if !filepath.IsLocal(filepath.FromSlash(path.Join(path.Dir(name), linkTarget))) {
+				return &UnsafeEntryError{Name: header.Name, Reason: "symlink escapes the destination folder"}
+			}
+			// The target is resolved through the symlinks extracted before or after this one, e.g. c/../secret.txt
+			// climbs out of the folder that the c symlink points to. Without parent references, every symlink points
+			// below its own folder, and so does a chain of symlinks.
+			if slices.Contains(strings.Split(linkTarget, "/"), "..") {
+				return &UnsafeEntryError{Name: header.Name, Reason: "symlink target refers to a parent folder"}
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("error creating directory for %s: %w", name, err)
+			}
+			// A previous extraction of the archive may have left the symlink behind
+			if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("error replacing %s: %w", name, err)
+			}
+			if err := os.Symlink(linkTarget, target); err != nil {
+				return fmt.Errorf("error creating symlink %s: %w", name, err)
+			}
+			symlinks[name] = true
+		default:
+			return &UnsafeEntryError{Name: header.Name, Reason: fmt.Sprintf("unsupported entry type %q", string(header.Typeflag))}
+		}
+	}
+}
+
+func writeEntry(r io.Reader, target string, header *tar.Header) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	// Never follow a symlink left behind by a previous extraction of the archive
+	if info, err := os.Lstat(target); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	outFile, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fs.FileMode(header.Mode).Perm()|0600)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	_, err = io.CopyN(outFile, r, header.Size)
+	return err
+}
+
+// ReadFileFromTarGz reads a single regular file of the tar.gz archive at src, without extracting the archive.
+// The error wraps fs.ErrNotExist if the archive doesn't contain the file.
+func ReadFileFromTarGz(src, name string, limits ExtractLimits) ([]byte, error) {
+	file, err := os.Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("error opening the archive: %w", err)
+	}
+	defer file.Close()
+
+	reader, closer, err := newTarGzReader(file, limits)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	name = path.Clean(name)
+	for {
+		header, entryName, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s not found in the archive: %w", name, fs.ErrNotExist)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entryName != name {
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, &UnsafeEntryError{Name: header.Name, Reason: "not a regular file"}
+		}
+		return io.ReadAll(io.LimitReader(reader.tarReader, header.Size))
+	}
+}
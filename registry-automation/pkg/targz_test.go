@@ -0,0 +1,142 @@
+package pkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testEntry struct {
+	header  tar.Header
+	content string
+}
+
+func tarFile(name, content string) testEntry {
+	return testEntry{header: tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}, content: content}
+}
+
+func tarSymlink(name, target string) testEntry {
+	return testEntry{header: tar.Header{Name: name, Typeflag: tar.TypeSymlink, Linkname: target}}
+}
+
+func writeTestArchive(t *testing.T, entries ...testEntry) string {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, entry := range entries {
+		header := entry.header
+		assert.NoError(t, tarWriter.WriteHeader(&header))
+		_, err := tarWriter.Write([]byte(entry.content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tarWriter.Close())
+	assert.NoError(t, gzipWriter.Close())
+
+	archivePath := filepath.Join(t.TempDir(), "package.tar.gz")
+	assert.NoError(t, os.WriteFile(archivePath, buf.Bytes(), 0644))
+	return archivePath
+}
+
+func TestExtractTarGz(t *testing.T) {
+	archivePath := writeTestArchive(t,
+		testEntry{header: tar.Header{Name: "./.hasura-connector/", Typeflag: tar.TypeDir, Mode: 0755}},
+		tarFile("./.hasura-connector/connector-metadata.yaml", "packagingDefinition:\n  type: PrebuiltDockerImage\n"),
+		tarSymlink("./.hasura-connector/metadata.yaml", "connector-metadata.yaml"),
+	)
+	dest := t.TempDir()
+
+	assert.NoError(t, ExtractTarGz(archivePath, dest, DefaultExtractLimits))
+	content, err := os.ReadFile(filepath.Join(dest, ".hasura-connector", "metadata.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "packagingDefinition:\n  type: PrebuiltDockerImage\n", string(content))
+
+	// Extracting the same archive twice overwrites the previous extraction
+	assert.NoError(t, ExtractTarGz(archivePath, dest, DefaultExtractLimits))
+}
+
+func TestExtractTarGzRejectsUnsafeEntries(t *testing.T) {
+	testCases := []struct {
+		name    string
+		entries []testEntry
+	}{
+		{"Parent directory", []testEntry{tarFile("../evil.sh", "evil")}},
+		{"Nested parent directory", []testEntry{tarFile("a/../../evil.sh", "evil")}},
+		{"Absolute path", []testEntry{tarFile("/tmp/evil.sh", "evil")}},
+		{"Symlink escape", []testEntry{tarSymlink("link", "../../etc")}},
+		{"Absolute symlink", []testEntry{tarSymlink("link", "/etc/passwd")}},
+		{"Symlink to a parent folder", []testEntry{tarSymlink("a/link", "../b")}},
+		{"Write through a symlink", []testEntry{tarSymlink("link", "a"), tarFile("link/evil.sh", "evil")}},
+		{"Overwrite a symlink", []testEntry{tarSymlink("link", "a"), tarFile("link", "evil")}},
+		{"Hard link", []testEntry{{header: tar.Header{Name: "link", Typeflag: tar.TypeLink, Linkname: "/etc/passwd"}}}},
+		{"Device", []testEntry{{header: tar.Header{Name: "dev", Typeflag: tar.TypeChar}}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			archivePath := writeTestArchive(t, tc.entries...)
+			err := ExtractTarGz(archivePath, filepath.Join(t.TempDir(), "dest"), DefaultExtractLimits)
+			var unsafeEntryErr *UnsafeEntryError
+			assert.ErrorAs(t, err, &unsafeEntryErr)
+		})
+	}
+}
+
+func TestExtractTarGzRejectsChainedSymlinks(t *testing.T) {
+	// Each symlink points inside the archive on its own, but the chain resolves to a file outside of it
+	archivePath := writeTestArchive(t,
+		tarSymlink("sub/c", ".."),
+		tarSymlink(".hasura-connector/c", "../sub/c"),
+		tarSymlink(ConnectorMetadataPath, "c/../secret.txt"),
+	)
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0644))
+	dest := filepath.Join(root, "dest")
+
+	err := ExtractTarGz(archivePath, dest, DefaultExtractLimits)
+	var unsafeEntryErr *UnsafeEntryError
+	assert.ErrorAs(t, err, &unsafeEntryErr)
+	_, err = os.ReadFile(filepath.Join(dest, filepath.FromSlash(ConnectorMetadataPath)))
+	assert.Error(t, err)
+
+	// The metadata file is only read as a regular file of the archive
+	_, err = ReadFileFromTarGz(archivePath, ConnectorMetadataPath, DefaultExtractLimits)
+	assert.ErrorAs(t, err, &unsafeEntryErr)
+}
+
+func TestExtractTarGzLimits(t *testing.T) {
+	archivePath := writeTestArchive(t, tarFile("a", "0123456789"), tarFile("b", "0123456789"))
+
+	err := ExtractTarGz(archivePath, t.TempDir(), ExtractLimits{MaxUncompressedSize: 15, MaxFiles: 10})
+	assert.ErrorIs(t, err, ErrArchiveLimitExceeded)
+
+	err = ExtractTarGz(archivePath, t.TempDir(), ExtractLimits{MaxUncompressedSize: 100, MaxFiles: 1})
+	assert.ErrorIs(t, err, ErrArchiveLimitExceeded)
+
+	assert.NoError(t, ExtractTarGz(archivePath, t.TempDir(), ExtractLimits{MaxUncompressedSize: 20, MaxFiles: 2}))
+}
+
+func TestReadFileFromTarGz(t *testing.T) {
+	archivePath := writeTestArchive(t,
+		tarFile("./README.md", "# Connector"),
+		tarFile("./.hasura-connector/connector-metadata.yaml", "version: v2\n"),
+	)
+
+	content, err := ReadFileFromTarGz(archivePath, ConnectorMetadataPath, DefaultExtractLimits)
+	assert.NoError(t, err)
+	assert.Equal(t, "version: v2\n", string(content))
+
+	_, err = ReadFileFromTarGz(archivePath, "missing.yaml", DefaultExtractLimits)
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	unsafeArchivePath := writeTestArchive(t, tarFile("../evil.sh", "evil"), tarFile(ConnectorMetadataPath, "version: v2\n"))
+	_, err = ReadFileFromTarGz(unsafeArchivePath, ConnectorMetadataPath, DefaultExtractLimits)
+	var unsafeEntryErr *UnsafeEntryError
+	assert.ErrorAs(t, err, &unsafeEntryErr)
+}
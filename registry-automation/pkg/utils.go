@@ -1,39 +1,38 @@
 package pkg
 
 import (
-	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Downloads the TGZ File from the URL specified by `tgzUrl`, extracts the TGZ file and returns the content of the
-// connector-definition.yaml present in the .hasura-connector folder.
-func GetConnectorVersionMetadata(tgzUrl string, namespace, name,
+// connector-definition.yaml present in the .hasura-connector folder. The TGZ file is verified against the checksum
+// and shared through the package cache, unless the checksum is empty.
+func GetConnectorVersionMetadata(tgzUrl string, checksum Checksum, namespace, name,
 	connectorVersion string) (connectorVersionMetadata map[string]interface{}, tgzPath string, extractedTargzPath string, err error) {
-	tgzPath, err = getTempFilePath("extracted_tgz")
-	if err != nil {
-		return connectorVersionMetadata, "", "", fmt.Errorf("failed to get the temp file path: %v", err)
+	if checksum.Value == "" {
+		tgzPath, err = downloadConnectorPackage(tgzUrl)
+	} else {
+		tgzPath, _, err = DownloadConnectorPackage(tgzUrl, checksum)
 	}
-	err = DownloadFile(tgzUrl, tgzPath, map[string]string{})
-
 	if err != nil {
-		return connectorVersionMetadata, "", "", fmt.Errorf("failed to download the connector version metadata file from the URL: %v - err: %v", tgzUrl, err)
+		return connectorVersionMetadata, "", "", err
 	}
 
 	extractedTgzFolderPath := "extracted_tgz"
 
-	if _, err := os.Stat(extractedTgzFolderPath); os.IsNotExist(err) {
-		err := os.Mkdir(extractedTgzFolderPath, 0755)
-		if err != nil {
-			return connectorVersionMetadata, "", "", fmt.Errorf("failed to read the connector version metadata file: %v", err)
-		}
+	// MkdirAll doesn't fail if the folder was created concurrently while processing another connector version
+	if err := os.MkdirAll(extractedTgzFolderPath, 0755); err != nil {
+		return connectorVersionMetadata, "", "", fmt.Errorf("failed to read the connector version metadata file: %v", err)
 	}
 
 	extractedTargzPath, err = filepath.Abs(filepath.Join(extractedTgzFolderPath, namespace, name, connectorVersion))
@@ -42,66 +41,64 @@ func GetConnectorVersionMetadata(tgzUrl string, namespace, name,
 	}
 
 	log.Printf("Extracting the connector version metadata file from the TGZ file at: %s\n", extractedTargzPath)
-	connectorVersionMetadataYamlFilePath, err := extractTarGz(tgzPath, extractedTargzPath)
-	if err != nil {
-		return connectorVersionMetadata, "", "", fmt.Errorf("failed to read the connector version metadata file: %v", err)
-	} else {
-		log.Println("Extracted metadata file at :", connectorVersionMetadataYamlFilePath)
+	if err := ExtractTarGz(tgzPath, extractedTargzPath, DefaultExtractLimits); err != nil {
+		return connectorVersionMetadata, "", "", fmt.Errorf("failed to extract the TGZ file: %w", err)
 	}
-
-	connectorVersionMetadata, err = readYAMLFile(connectorVersionMetadataYamlFilePath)
+	// The metadata file is read from the archive rather than from the extracted folder, so that it is always a
+	// regular file of the package and never a symlink to a file outside of it
+	connectorVersionMetadataYaml, err := ReadFileFromTarGz(tgzPath, ConnectorMetadataPath, DefaultExtractLimits)
 	if err != nil {
-		return connectorVersionMetadata, "", "", fmt.Errorf("failed to read the connector version metadata file: %v", err)
+		return connectorVersionMetadata, "", "", fmt.Errorf("failed to read the connector version metadata file: %w", err)
 	}
-	return connectorVersionMetadata, tgzPath, extractedTargzPath,  nil
+	if err := yaml.Unmarshal(connectorVersionMetadataYaml, &connectorVersionMetadata); err != nil {
+		return connectorVersionMetadata, "", "", fmt.Errorf("failed to read the connector version metadata file: failed to unmarshal YAML: %w", err)
+	}
+	return connectorVersionMetadata, tgzPath, extractedTargzPath, nil
 }
 
-func extractTarGz(src, dest string) (string, error) {
-	filePath := dest
-
-	if err := os.MkdirAll(filePath, 0755); err != nil {
-		return "", fmt.Errorf("error creating destination directory: %v", err)
+// DownloadConnectorPackage downloads the TGZ file from the URL specified by `tgzUrl` and verifies it against the
+// checksum of connector-packaging.json. The TGZ file is read from the package cache if it was already downloaded,
+// and stored in the package cache otherwise. It returns the path of the TGZ file and its sha256 digest, the TGZ file
+// is pinned in the cache so that it is not evicted before the end of the run, e.g. before it is uploaded.
+func DownloadConnectorPackage(tgzUrl string, checksum Checksum) (tgzPath string, tgzSHA256 string, err error) {
+	packageCache := getPackageCache()
+	if packageCache != nil && checksum.Type == SHA256ChecksumType {
+		if cachedPath, ok := packageCache.LookupPinned(checksum.Value); ok {
+			log.Printf("Using the cached TGZ file for %s at %s", tgzUrl, cachedPath)
+			return cachedPath, strings.ToLower(checksum.Value), nil
+		}
 	}
-	var stdout, stderr bytes.Buffer
-	// Run the tar command with the -xvzf options
-	cmd := exec.Command("tar", "-xvzf", src, "-C", dest)
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
 
-	// Execute the command
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("error extracting file:\nerror: %v\nstdout: %s\nstderr: %s",
-			err, stdout.String(), stderr.String())
+	tgzPath, err = getTempFilePath("extracted_tgz")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get the temp file path: %v", err)
 	}
-
-	return filepath.Join(filePath, ".hasura-connector", "connector-metadata.yaml"), nil
-}
-
-// Write a function that accepts a file path to a YAML file and returns
-// the contents of the file as a map[string]interface{}.
-// readYAMLFile accepts a file path to a YAML file and returns the contents of the file as a map[string]interface{}.
-func readYAMLFile(filePath string) (map[string]interface{}, error) {
-	// Open the file
-	file, err := os.Open(filePath)
+	tgzSHA256, err = DownloadVerifiedFile(tgzUrl, tgzPath, map[string]string{}, checksum)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return "", "", fmt.Errorf("failed to download the connector version metadata file from the URL: %v - err: %w", tgzUrl, err)
 	}
-	defer file.Close()
 
-	// Read the file contents
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+	if packageCache != nil {
+		cachedPath, err := packageCache.StorePinned(tgzSHA256, tgzPath)
+		if err != nil {
+			// The downloaded TGZ file is still usable
+			log.Printf("Failed to cache the TGZ file for %s: %v", tgzUrl, err)
+			return tgzPath, tgzSHA256, nil
+		}
+		tgzPath = cachedPath
 	}
+	return tgzPath, tgzSHA256, nil
+}
 
-	// Unmarshal the YAML contents into a map
-	var result map[string]interface{}
-	err = yaml.Unmarshal(data, &result)
+func downloadConnectorPackage(tgzUrl string) (string, error) {
+	tgzPath, err := getTempFilePath("extracted_tgz")
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
+		return "", fmt.Errorf("failed to get the temp file path: %v", err)
 	}
-
-	return result, nil
+	if err := DownloadFile(tgzUrl, tgzPath, map[string]string{}); err != nil {
+		return "", fmt.Errorf("failed to download the connector version metadata file from the URL: %v - err: %v", tgzUrl, err)
+	}
+	return tgzPath, nil
 }
 
 // getTempFilePath generates a random file name in the specified directory.
@@ -126,6 +123,32 @@ func getTempFilePath(directory string) (string, error) {
 }
 
 func DownloadFile(sourceURL, destination string, headers map[string]string) error {
+	return downloadFile(sourceURL, destination, headers, io.Discard)
+}
+
+// DownloadVerifiedFile downloads the file like DownloadFile, and hashes it while it is written to the destination.
+// The destination file is removed and a *ChecksumMismatchError is returned if the checksum of the file doesn't
+// match the expected checksum. It returns the sha256 digest of the file, hex encoded.
+func DownloadVerifiedFile(sourceURL, destination string, headers map[string]string, expected Checksum) (string, error) {
+	checksumHash, err := newChecksumHash(expected)
+	if err != nil {
+		return "", err
+	}
+	sha256Hash := sha256.New()
+
+	if err := downloadFile(sourceURL, destination, headers, io.MultiWriter(checksumHash, sha256Hash)); err != nil {
+		return "", err
+	}
+
+	if actual := hex.EncodeToString(checksumHash.Sum(nil)); !strings.EqualFold(actual, expected.Value) {
+		os.Remove(destination)
+		return "", &ChecksumMismatchError{URL: sourceURL, Type: expected.Type, Expected: expected.Value, Actual: actual}
+	}
+	return hex.EncodeToString(sha256Hash.Sum(nil)), nil
+}
+
+// downloadFile downloads the file to the destination, the content of the file is also written to w
+func downloadFile(sourceURL, destination string, headers map[string]string, w io.Writer) error {
 	// Create a new HTTP client
 	client := &http.Client{
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
@@ -170,7 +193,7 @@ func DownloadFile(sourceURL, destination string, headers map[string]string) erro
 	defer outFile.Close()
 
 	// Write the response body to the file
-	_, err = io.Copy(outFile, resp.Body)
+	_, err = io.Copy(io.MultiWriter(outFile, w), resp.Body)
 	if err != nil {
 		return fmt.Errorf("error writing to file: %v", err)
 	}
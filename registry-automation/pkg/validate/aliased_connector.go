@@ -0,0 +1,113 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+)
+
+// validateAliasParent checks that the aliased connector is in the folder of a connector of the hub
+func validateAliasParent(parent *ndchub.ConnectorMetadata) error {
+	if parent == nil {
+		return fmt.Errorf("the aliased connector is not in the %s folder of a connector with a metadata.json", ndchub.AliasedConnectorsFolder)
+	}
+	return nil
+}
+
+// validateAliasLatestVersion checks that the aliased connector is published with the latest release of its parent
+func validateAliasLatestVersion(ac *ndchub.AliasedConnector, parent *ndchub.ConnectorMetadata) error {
+	if parent == nil {
+		return nil
+	}
+	if ac.Metadata.Overview.LatestVersion != parent.Overview.LatestVersion {
+		return fmt.Errorf("latest_version in metadata.json (%s) does not match the latest_version of the parent connector %s (%s)",
+			ac.Metadata.Overview.LatestVersion, ac.ParentName, parent.Overview.LatestVersion)
+	}
+	return nil
+}
+
+// validateAliasSourceCodeVersions checks that the aliased connector lists the same tags and commits as its parent in
+// source_code.version
+func validateAliasSourceCodeVersions(ac *ndchub.AliasedConnector, parent *ndchub.ConnectorMetadata) error {
+	if parent == nil {
+		return nil
+	}
+
+	aliasHashes := make(map[string]string)
+	for _, sourceCodeVersion := range ac.Metadata.SourceCode.Version {
+		aliasHashes[sourceCodeVersion.Tag] = sourceCodeVersion.Hash
+	}
+	parentTags := make(map[string]bool)
+
+	var errs []error
+	for _, sourceCodeVersion := range parent.SourceCode.Version {
+		parentTags[sourceCodeVersion.Tag] = true
+		hash, ok := aliasHashes[sourceCodeVersion.Tag]
+		if !ok {
+			errs = append(errs, fmt.Errorf("source_code.version in metadata.json has no tag %s, unlike the parent connector %s",
+				sourceCodeVersion.Tag, ac.ParentName))
+			continue
+		}
+		if !sameCommit(hash, sourceCodeVersion.Hash) {
+			errs = append(errs, fmt.Errorf("source_code.version in metadata.json has the hash %s for the tag %s, but the parent connector %s has the hash %s",
+				hash, sourceCodeVersion.Tag, ac.ParentName, sourceCodeVersion.Hash))
+		}
+	}
+	for _, sourceCodeVersion := range ac.Metadata.SourceCode.Version {
+		if !parentTags[sourceCodeVersion.Tag] {
+			errs = append(errs, fmt.Errorf("source_code.version in metadata.json has the tag %s, but the parent connector %s doesn't",
+				sourceCodeVersion.Tag, ac.ParentName))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateAliasFiles checks that the logo of metadata.json and the README of the aliased connector exist in its folder
+func validateAliasFiles(ac *ndchub.AliasedConnector) error {
+	var errs []error
+	if ac.Metadata.Overview.Logo == "" {
+		errs = append(errs, fmt.Errorf("overview.logo in metadata.json is required"))
+	} else if err := fileExists(filepath.Join(ac.Folder(), ac.Metadata.Overview.Logo)); err != nil {
+		errs = append(errs, fmt.Errorf("the logo of the aliased connector is missing: %w", err))
+	}
+	if err := fileExists(filepath.Join(ac.Folder(), "README.md")); err != nil {
+		errs = append(errs, fmt.Errorf("the README of the aliased connector is missing: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+func fileExists(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return nil
+}
+
+// ConnectorTitle is the title of a connector or of an aliased connector (namespace/name) of the hub
+type ConnectorTitle struct {
+	Connector string
+	Title     string
+}
+
+// validateUniqueTitle checks that no other connector of the hub has the title, regardless of the case
+func validateUniqueTitle(title string, otherTitles []ConnectorTitle) error {
+	var connectors []string
+	for _, other := range otherTitles {
+		if strings.EqualFold(strings.TrimSpace(other.Title), strings.TrimSpace(title)) {
+			connectors = append(connectors, other.Connector)
+		}
+	}
+	if len(connectors) > 0 {
+		return fmt.Errorf("the title %q is also the title of %s", title, strings.Join(connectors, ", "))
+	}
+	return nil
+}
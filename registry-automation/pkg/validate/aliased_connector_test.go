@@ -0,0 +1,94 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeAliasedConnector(t *testing.T, files ...string) *ndchub.AliasedConnector {
+	folder := filepath.Join(t.TempDir(), "hasura", "postgres", ndchub.AliasedConnectorsFolder, "neon")
+	assert.NoError(t, os.MkdirAll(folder, 0755))
+	for _, file := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(folder, file), []byte("content"), 0644))
+	}
+
+	ac := &ndchub.AliasedConnector{Namespace: "hasura", Name: "neon", ParentName: "postgres", Path: filepath.Join(folder, ndchub.MetadataJSON), Metadata: &ndchub.ConnectorMetadata{}}
+	ac.Metadata.Overview.Logo = "logo.svg"
+	ac.Metadata.Overview.LatestVersion = "v3.1.0"
+	ac.Metadata.SourceCode.Version = []ndchub.SourceCodeVersion{
+		{Tag: "v3.1.0", Hash: "5ea4370"},
+		{Tag: "v3.0.0", Hash: "e654dd3dc823afb8fff4bd4a2add8e8bf9a3b45e"},
+	}
+	return ac
+}
+
+func TestCheckAliasedConnector(t *testing.T) {
+	parent := &ndchub.ConnectorMetadata{}
+	parent.Overview.LatestVersion = "v3.1.0"
+	parent.SourceCode.Version = []ndchub.SourceCodeVersion{
+		{Tag: "v3.1.0", Hash: "5ea4370a7ed4bb68a0136bf73447b0c92c0db5cc"},
+		{Tag: "v3.0.0", Hash: "e654dd3dc823afb8fff4bd4a2add8e8bf9a3b45e"},
+	}
+
+	testCases := []struct {
+		name         string
+		files        []string
+		update       func(ac *ndchub.AliasedConnector)
+		parent       *ndchub.ConnectorMetadata
+		wantFindings []string
+	}{
+		{"Valid aliased connector", []string{"logo.svg", "README.md"}, nil, parent, nil},
+		{"Missing parent", []string{"logo.svg", "README.md"}, nil, nil,
+			[]string{AliasedConnectorParentRule}},
+		{"Missing logo and README", nil, nil, parent,
+			[]string{AliasedConnectorFilesRule, AliasedConnectorFilesRule}},
+		{"Stale latest version", []string{"logo.svg", "README.md"}, func(ac *ndchub.AliasedConnector) {
+			ac.Metadata.Overview.LatestVersion = "v3.0.0"
+		}, parent, []string{AliasedConnectorLatestVersionRule}},
+		{"Different source code versions", []string{"logo.svg", "README.md"}, func(ac *ndchub.AliasedConnector) {
+			ac.Metadata.SourceCode.Version = []ndchub.SourceCodeVersion{
+				{Tag: "v3.1.0", Hash: "0c6fbad"},
+				{Tag: "v2.1.1", Hash: "c86413916521c0823c73193382e4e171a9efcffa"},
+			}
+		}, parent, []string{AliasedConnectorSourceCodeVersionRule, AliasedConnectorSourceCodeVersionRule, AliasedConnectorSourceCodeVersionRule}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ac := writeAliasedConnector(t, tc.files...)
+			if tc.update != nil {
+				tc.update(ac)
+			}
+			var ruleIDs []string
+			for _, finding := range NewValidator(DefaultRuleRegistry()).CheckAliasedConnector(ac, tc.parent) {
+				assert.Equal(t, "hasura/neon", finding.Connector)
+				ruleIDs = append(ruleIDs, finding.RuleID)
+			}
+			assert.Equal(t, tc.wantFindings, ruleIDs)
+		})
+	}
+}
+
+func TestCheckTitle(t *testing.T) {
+	titles := []ConnectorTitle{
+		{Connector: "hasura/postgres", Title: "PostgreSQL"},
+		{Connector: "hasura/neon", Title: "Neon PostgreSQL"},
+		{Connector: "community/neon", Title: "neon postgresql "},
+	}
+	validator := NewValidator(DefaultRuleRegistry())
+
+	assert.Empty(t, validator.CheckTitle("postgres/metadata.json", "hasura/postgres", "PostgreSQL", titles))
+	findings := validator.CheckTitle("neon/metadata.json", "hasura/neon", "Neon PostgreSQL", titles)
+	assert.Equal(t, []Finding{{
+		File:      "neon/metadata.json",
+		Path:      "/overview/title",
+		RuleID:    ConnectorTitleRule,
+		Severity:  ErrorSeverity,
+		Message:   `the title "Neon PostgreSQL" is also the title of community/neon`,
+		Connector: "hasura/neon",
+	}}, findings)
+}
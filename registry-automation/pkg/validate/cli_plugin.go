@@ -0,0 +1,138 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+)
+
+// cliPluginBinary is the binary of a CLI plugin for a platform, from the manifest of an external CLI plugin or from
+// the platforms of an inline CLI plugin
+type cliPluginBinary struct {
+	selector string
+	uri      string
+	sha256   string
+	bin      string
+	files    []ndchub.FilePair
+}
+
+// cliPluginBinaries returns the binaries of the CLI plugin of the packaging spec, the manifest is nil unless the CLI
+// plugin is an external binary
+func cliPluginBinaries(spec *ndchub.ConnectorMetadataDefinition, manifest *ndchub.PluginManifest) []cliPluginBinary {
+	var binaries []cliPluginBinary
+	switch {
+	case manifest != nil:
+		for _, platform := range manifest.Platforms {
+			binaries = append(binaries, cliPluginBinary{selector: platform.Selector, uri: platform.URI, sha256: platform.SHA256, bin: platform.Bin, files: platform.Files})
+		}
+	case spec.CliPlugin != nil && spec.CliPlugin.Kind() == ndchub.BinaryInlinePluginType:
+		for _, platform := range spec.CliPlugin.Binary.Inline.Platforms {
+			binaries = append(binaries, cliPluginBinary{selector: string(platform.Selector), uri: platform.URI, sha256: platform.SHA256, bin: platform.Bin})
+		}
+	}
+	return binaries
+}
+
+// validateCliPluginManifest checks the manifest of an external CLI plugin against the packaging spec
+func validateCliPluginManifest(spec *ndchub.ConnectorMetadataDefinition, manifest *ndchub.PluginManifest) error {
+	if manifest == nil {
+		return nil
+	}
+	return manifest.Validate(spec.CliPlugin.Binary.External)
+}
+
+// validateCliPluginBinaries downloads the binaries of the CLI plugin, and checks them against their sha256 and the
+// files that are installed from them
+func validateCliPluginBinaries(binaries []cliPluginBinary) error {
+	downloadDir, err := os.MkdirTemp("", "cli-plugin-*")
+	if err != nil {
+		return fmt.Errorf("error creating the download folder of the cli plugin binaries: %w", err)
+	}
+	defer os.RemoveAll(downloadDir)
+
+	var errs []error
+	for i, binary := range binaries {
+		for _, err := range validateCliPluginBinary(binary, filepath.Join(downloadDir, fmt.Sprintf("binary-%d", i))) {
+			errs = append(errs, fmt.Errorf("cli plugin binary for %s: %w", binary.selector, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateCliPluginBinary downloads the binary to downloadPath, and returns the errors of the binary
+func validateCliPluginBinary(binary cliPluginBinary, downloadPath string) []error {
+	if binary.uri == "" || binary.sha256 == "" {
+		return []error{fmt.Errorf("the uri and the sha256 of the binary are required")}
+	}
+	checksum := pkg.Checksum{Type: pkg.SHA256ChecksumType, Value: binary.sha256}
+	if _, err := pkg.DownloadVerifiedFile(binary.uri, downloadPath, map[string]string{}, checksum); err != nil {
+		return []error{err}
+	}
+
+	entries, err := pkg.ListArchiveEntries(downloadPath, pkg.DefaultExtractLimits)
+	if errors.Is(err, pkg.ErrNotAnArchive) {
+		// A binary that isn't an archive is installed as the bin of the platform
+		return nil
+	}
+	if err != nil {
+		return []error{err}
+	}
+
+	installed, errs := installedFiles(entries, binary.files)
+	if binary.bin != "" && !slices.Contains(installed, path.Clean(binary.bin)) {
+		errs = append(errs, fmt.Errorf("the bin %s is not installed from the archive %s", binary.bin, archiveName(binary.uri)))
+	}
+	return errs
+}
+
+// installedFiles returns the files that are installed from the entries of an archive, like krew does. The from
+// pattern of a file pair selects entries of the archive, an entry that is the from path is installed at the to path
+// and the entries under a matched folder or matched by a glob are installed under the to folder. Without file pairs,
+// every entry of the archive is installed.
+func installedFiles(entries []string, files []ndchub.FilePair) ([]string, []error) {
+	if len(files) == 0 {
+		return entries, nil
+	}
+
+	var installed []string
+	var errs []error
+	for _, file := range files {
+		from := path.Clean(strings.TrimPrefix(file.From, "/"))
+		to := path.Clean(file.To)
+		matched := false
+		for _, entry := range entries {
+			switch {
+			case entry == from:
+				installed = append(installed, to)
+			case strings.HasPrefix(entry, from+"/") || from == ".":
+				installed = append(installed, path.Join(to, strings.TrimPrefix(entry, from+"/")))
+			default:
+				if ok, _ := path.Match(from, entry); !ok {
+					continue
+				}
+				installed = append(installed, path.Join(to, path.Base(entry)))
+			}
+			matched = true
+		}
+		if !matched {
+			errs = append(errs, fmt.Errorf("the files entry %s is not in the archive", file.From))
+		}
+	}
+	return installed, errs
+}
+
+// archiveName returns the file name of the archive at the URI, for the error messages
+func archiveName(uri string) string {
+	if parsedURL, err := url.Parse(uri); err == nil && parsedURL.Path != "" {
+		return path.Base(parsedURL.Path)
+	}
+	return uri
+}
@@ -0,0 +1,180 @@
+package validate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/stretchr/testify/assert"
+)
+
+func tarGzArchive(t *testing.T, files ...string) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, file := range files {
+		assert.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: file, Typeflag: tar.TypeReg, Mode: 0755, Size: int64(len("binary"))}))
+		_, err := tarWriter.Write([]byte("binary"))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tarWriter.Close())
+	assert.NoError(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+// cliPluginServer serves the binaries of a CLI plugin, and returns the URL and the sha256 of each path
+func cliPluginServer(t *testing.T, binaries map[string][]byte) (urls map[string]string, sha256s map[string]string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := binaries[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(content)
+	}))
+	t.Cleanup(server.Close)
+
+	urls = make(map[string]string)
+	sha256s = make(map[string]string)
+	for binaryPath, content := range binaries {
+		urls[binaryPath] = server.URL + binaryPath
+		sha256s[binaryPath] = fmt.Sprintf("%x", sha256.Sum256(content))
+	}
+	return urls, sha256s
+}
+
+func TestCheckCliPlugin(t *testing.T) {
+	urls, sha256s := cliPluginServer(t, map[string][]byte{
+		"/ndc-test-cli.tar.gz": tarGzArchive(t, "./ndc-test-cli"),
+		"/ndc-test-cli":        []byte("\x7fELF binary"),
+	})
+
+	spec := &ndchub.ConnectorMetadataDefinition{CliPlugin: &ndchub.CliPluginDefinition{Binary: &ndchub.BinaryCliPluginDefinition{
+		Inline: &ndchub.BinaryInlineCliPluginDefinition{Type: ndchub.BinaryInlinePluginType, Platforms: []ndchub.BinaryCliPluginPlatform{
+			{Selector: ndchub.PlatformLinuxAmd64, URI: urls["/ndc-test-cli.tar.gz"], SHA256: sha256s["/ndc-test-cli.tar.gz"], Bin: "ndc-test-cli"},
+			// A binary that isn't an archive is installed as the bin
+			{Selector: ndchub.PlatformDarwinArm64, URI: urls["/ndc-test-cli"], SHA256: sha256s["/ndc-test-cli"], Bin: "hasura-ndc-test"},
+			{Selector: ndchub.PlatformWindowsAmd64, URI: urls["/ndc-test-cli.tar.gz"], SHA256: sha256s["/ndc-test-cli"], Bin: "ndc-test-cli"},
+			{Selector: ndchub.PlatformLinuxArm64, URI: urls["/ndc-test-cli.tar.gz"], SHA256: sha256s["/ndc-test-cli.tar.gz"], Bin: "ndc-test-cli.exe"},
+		}},
+	}}}
+	cp := &ndchub.ConnectorPackaging{Namespace: "hasura", Name: "test", Version: "v1.0.0", Path: "connector-packaging.json"}
+
+	findings := NewValidator(DefaultRuleRegistry()).CheckCliPlugin(cp, spec)
+	if assert.Len(t, findings, 2) {
+		assert.Equal(t, CliPluginBinaryRule, findings[0].RuleID)
+		assert.Contains(t, findings[0].Message, "cli plugin binary for windows-amd64: checksum mismatch")
+		assert.Equal(t, CliPluginBinaryRule, findings[1].RuleID)
+		assert.Equal(t, "cli plugin binary for linux-arm64: the bin ndc-test-cli.exe is not installed from the archive ndc-test-cli.tar.gz", findings[1].Message)
+		assert.Equal(t, "v1.0.0", findings[1].Version)
+	}
+
+	// The Docker CLI plugins have no binaries to check
+	dockerSpec := &ndchub.ConnectorMetadataDefinition{CliPlugin: &ndchub.CliPluginDefinition{Docker: &ndchub.DockerCliPluginDefinition{DockerImage: "ghcr.io/hasura/ndc-test-cli:v1.0.0"}}}
+	assert.Empty(t, NewValidator(DefaultRuleRegistry()).CheckCliPlugin(cp, dockerSpec))
+}
+
+func TestCheckExternalCliPlugin(t *testing.T) {
+	urls, sha256s := cliPluginServer(t, map[string][]byte{
+		"/ndc-test-cli.tar.gz": tarGzArchive(t, "./ndc-test-cli"),
+	})
+	// The manifest is resolved offline from a local CLI plugins index
+	var manifest strings.Builder
+	manifest.WriteString("name: ndc-test\nversion: v1.0.0\nplatforms:\n")
+	for _, selector := range ndchub.PlatformSelectors[1:] {
+		fmt.Fprintf(&manifest, "  - selector: %s\n    uri: %s\n    sha256: %s\n    bin: hasura-ndc-test\n    files:\n      - from: ./ndc-test-cli\n        to: hasura-ndc-test\n",
+			selector, urls["/ndc-test-cli.tar.gz"], sha256s["/ndc-test-cli.tar.gz"])
+	}
+	indexDir := t.TempDir()
+	manifestDir := filepath.Join(indexDir, "plugins", "ndc-test", "v1.0.0")
+	assert.NoError(t, os.MkdirAll(manifestDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(manifestDir, "manifest.yaml"), []byte(manifest.String()), 0644))
+	index, err := ndchub.NewLocalPluginIndex(indexDir)
+	assert.NoError(t, err)
+	ndchub.SetPluginIndex(index)
+	t.Cleanup(func() { ndchub.SetPluginIndex(nil) })
+
+	cp := &ndchub.ConnectorPackaging{Namespace: "hasura", Name: "test", Version: "v1.0.0", Path: "connector-packaging.json"}
+	spec := func(version string) *ndchub.ConnectorMetadataDefinition {
+		external := &ndchub.BinaryExternalCliPluginDefinition{Name: "ndc-test", Version: version}
+		return &ndchub.ConnectorMetadataDefinition{CliPlugin: &ndchub.CliPluginDefinition{Binary: &ndchub.BinaryCliPluginDefinition{External: external}}}
+	}
+
+	findings := NewValidator(DefaultRuleRegistry()).CheckCliPlugin(cp, spec("v1.0.0"))
+	if assert.Len(t, findings, 1) {
+		assert.Equal(t, CliPluginManifestRule, findings[0].RuleID)
+		assert.Equal(t, `no binary for the platform "darwin-arm64" (/platforms in the manifest of the CLI plugins index)`, findings[0].Message)
+	}
+
+	findings = NewValidator(DefaultRuleRegistry()).CheckCliPlugin(cp, spec("v2.0.0"))
+	if assert.Len(t, findings, 1) {
+		assert.Equal(t, CliPluginManifestRule, findings[0].RuleID)
+		assert.Contains(t, findings[0].Message, "plugin manifest not found")
+	}
+}
+
+func TestValidateCliPluginBinaries(t *testing.T) {
+	urls, sha256s := cliPluginServer(t, map[string][]byte{
+		"/ndc-test-cli.tar.gz": tarGzArchive(t, "./bin/ndc-test-cli", "./LICENSE"),
+	})
+	binary := func(bin string, files ...ndchub.FilePair) cliPluginBinary {
+		return cliPluginBinary{selector: string(ndchub.PlatformLinuxAmd64), uri: urls["/ndc-test-cli.tar.gz"], sha256: sha256s["/ndc-test-cli.tar.gz"], bin: bin, files: files}
+	}
+
+	testCases := []struct {
+		name       string
+		binary     cliPluginBinary
+		wantErrors []string
+	}{
+		{"Renamed file", binary("hasura-ndc-test", ndchub.FilePair{From: "./bin/ndc-test-cli", To: "hasura-ndc-test"}), nil},
+		{"Folder", binary("cli/ndc-test-cli", ndchub.FilePair{From: "bin", To: "cli"}), nil},
+		{"Glob", binary("ndc-test-cli", ndchub.FilePair{From: "bin/*", To: "."}), nil},
+		{"Without files", binary("bin/ndc-test-cli"), nil},
+		{"Missing files entry", binary("hasura-ndc-test", ndchub.FilePair{From: "./ndc-test-cli", To: "hasura-ndc-test"}), []string{
+			"cli plugin binary for linux-amd64: the files entry ./ndc-test-cli is not in the archive",
+			"cli plugin binary for linux-amd64: the bin hasura-ndc-test is not installed from the archive ndc-test-cli.tar.gz",
+		}},
+		{"Missing download", cliPluginBinary{selector: string(ndchub.PlatformLinuxAmd64), uri: urls["/ndc-test-cli.tar.gz"] + ".missing", sha256: "abc"}, []string{
+			"cli plugin binary for linux-amd64: Error downloading file: 404 Not Found. HINT: Make sure that the tarball can be downloaded without any authentication",
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var messages []string
+			for _, finding := range errorFindings("connector-packaging.json", CliPluginBinaryRule, validateCliPluginBinaries([]cliPluginBinary{tc.binary})) {
+				messages = append(messages, finding.Message)
+			}
+			assert.Equal(t, tc.wantErrors, messages)
+		})
+	}
+}
+
+func TestValidateCliPluginManifest(t *testing.T) {
+	external := &ndchub.BinaryExternalCliPluginDefinition{Name: "ndc-test", Version: "v1.0.0"}
+	spec := &ndchub.ConnectorMetadataDefinition{CliPlugin: &ndchub.CliPluginDefinition{Binary: &ndchub.BinaryCliPluginDefinition{External: external}}}
+	manifest := &ndchub.PluginManifest{Name: "ndc-test", Version: "v1.0.1"}
+	for _, selector := range ndchub.PlatformSelectors {
+		manifest.Platforms = append(manifest.Platforms, ndchub.Platform{Selector: string(selector), URI: "https://example.com/ndc-test-cli", SHA256: "abc", Bin: "hasura-ndc-test"})
+	}
+
+	rule, ok := DefaultRuleRegistry().Rule(CliPluginManifestRule)
+	assert.True(t, ok)
+	findings := rule.Check(Target{Kind: CliPluginTarget, Path: "connector-packaging.json", PackagingSpec: spec, CliPluginManifest: manifest})
+	if assert.Len(t, findings, 1) {
+		assert.Equal(t, `"v1.0.1" does not match the version "v1.0.0" of the cli plugin of the packaging spec (/version in the manifest of the CLI plugins index)`, findings[0].Message)
+		assert.Equal(t, "", findings[0].Path)
+	}
+
+	// The inline CLI plugins have no manifest
+	assert.Empty(t, rule.Check(Target{Kind: CliPluginTarget, Path: "connector-packaging.json", PackagingSpec: spec}))
+}
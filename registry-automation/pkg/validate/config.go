@@ -0,0 +1,99 @@
+package validate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConnectorConfigFile is the file, next to the metadata.json of a connector, that overrides the severity of the
+// rules for the connector, so that the legacy connectors can be grandfathered without disabling the rules for everyone
+const ConnectorConfigFile = ".hub-validate.yaml"
+
+// suppressedSeverity is the severity of the overrides that suppress the findings of a rule
+const suppressedSeverity = "off"
+
+// ConnectorConfigRule is the ID of the findings of the invalid .hub-validate.yaml files, they can't be overridden
+const ConnectorConfigRule = "connector-config"
+
+const connectorConfigRuleDescription = "The .hub-validate.yaml file of the connector is valid"
+
+// ConnectorConfig is the content of a .hub-validate.yaml file
+type ConnectorConfig struct {
+	Path      string         `yaml:"-"`
+	Overrides []RuleOverride `yaml:"overrides"`
+}
+
+// RuleOverride changes the severity of the findings of a rule, or suppresses them with the "off" severity
+type RuleOverride struct {
+	Rule     string `yaml:"rule"`
+	Severity string `yaml:"severity"`
+	// Versions restricts the override to the findings of the releases of the connector, by default it applies to
+	// every finding of the rule
+	Versions []string `yaml:"versions,omitempty"`
+	// Reason is the reason of the override, it is required to review the overrides
+	Reason string `yaml:"reason"`
+}
+
+// LoadConnectorConfig reads a .hub-validate.yaml file, the rules of the overrides must be in the registry
+func LoadConnectorConfig(path string, rules *RuleRegistry) (*ConnectorConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := ConnectorConfig{Path: path}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var errs []error
+	for i, override := range config.Overrides {
+		field := fmt.Sprintf("overrides[%d]", i)
+		if _, ok := rules.Rule(override.Rule); !ok {
+			errs = append(errs, fmt.Errorf("%s.rule: unknown rule %q", field, override.Rule))
+		}
+		switch Severity(override.Severity) {
+		case ErrorSeverity, WarningSeverity, NoteSeverity, suppressedSeverity:
+		default:
+			errs = append(errs, fmt.Errorf("%s.severity: unsupported severity %q, expected %q, %q, %q or %q",
+				field, override.Severity, ErrorSeverity, WarningSeverity, NoteSeverity, suppressedSeverity))
+		}
+		if override.Reason == "" {
+			errs = append(errs, fmt.Errorf("%s.reason: is required", field))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &config, nil
+}
+
+// apply overrides the severity of the finding, it returns false if the finding is suppressed. The first override of
+// the rule that matches the finding applies.
+func (c *ConnectorConfig) apply(finding *Finding) bool {
+	if c == nil {
+		return true
+	}
+	for _, override := range c.Overrides {
+		if override.Rule != finding.RuleID {
+			continue
+		}
+		if len(override.Versions) > 0 && !slices.Contains(override.Versions, finding.Version) {
+			continue
+		}
+		if override.Severity == suppressedSeverity {
+			return false
+		}
+		finding.Severity = Severity(override.Severity)
+		return true
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConnectorConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), ConnectorConfigFile)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadConnectorConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		errContains []string
+	}{
+		{"Valid config", `overrides:
+  - rule: metadata-source-code-version
+    severity: warning
+    reason: The releases published before source_code.version was tracked have no tag
+  - rule: connector-tarball
+    severity: "off"
+    versions: [v0.1.0]
+    reason: The package of the release was deleted
+`, nil},
+		{"Empty config", ``, nil},
+		{"Unknown rule", `overrides:
+  - rule: metadata-latest-versions
+    severity: warning
+    reason: Typo in the rule
+`, []string{`overrides[0].rule: unknown rule "metadata-latest-versions"`}},
+		{"Invalid severity and missing reason", `overrides:
+  - rule: metadata-latest-version
+    severity: ignore
+`, []string{`overrides[0].severity: unsupported severity "ignore"`, "overrides[0].reason: is required"}},
+		{"Unknown key", `overrides:
+  - rule: metadata-latest-version
+    severity: warning
+    reason: Legacy connector
+    until: v2.0.0
+`, []string{"field until not found"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadConnectorConfig(writeConnectorConfig(t, tc.content), DefaultRuleRegistry())
+			if len(tc.errContains) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, errContains := range tc.errContains {
+				assert.ErrorContains(t, err, errContains)
+			}
+		})
+	}
+}
+
+func TestConnectorConfigApply(t *testing.T) {
+	config := &ConnectorConfig{Overrides: []RuleOverride{
+		{Rule: ConnectorTarballRule, Severity: suppressedSeverity, Versions: []string{"v0.1.0"}, Reason: "deleted package"},
+		{Rule: ConnectorTarballRule, Severity: string(NoteSeverity), Reason: "flaky downloads"},
+		{Rule: MetadataSourceCodeVersionRule, Severity: string(WarningSeverity), Reason: "legacy releases"},
+	}}
+
+	testCases := []struct {
+		name         string
+		finding      Finding
+		wantKept     bool
+		wantSeverity Severity
+	}{
+		{"Suppressed release", Finding{RuleID: ConnectorTarballRule, Version: "v0.1.0", Severity: ErrorSeverity}, false, ErrorSeverity},
+		{"Other release", Finding{RuleID: ConnectorTarballRule, Version: "v0.2.0", Severity: ErrorSeverity}, true, NoteSeverity},
+		{"Downgraded rule", Finding{RuleID: MetadataSourceCodeVersionRule, Severity: ErrorSeverity}, true, WarningSeverity},
+		{"Rule without override", Finding{RuleID: MetadataLatestVersionRule, Severity: ErrorSeverity}, true, ErrorSeverity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			finding := tc.finding
+			assert.Equal(t, tc.wantKept, config.apply(&finding))
+			assert.Equal(t, tc.wantSeverity, finding.Severity)
+		})
+	}
+
+	// Without config, the findings are kept as is
+	var noConfig *ConnectorConfig
+	finding := Finding{RuleID: MetadataLatestVersionRule, Severity: ErrorSeverity}
+	assert.True(t, noConfig.apply(&finding))
+	assert.Equal(t, ErrorSeverity, finding.Severity)
+}
@@ -1,26 +1,100 @@
 package validate
 
 import (
+	"errors"
 	"fmt"
 	"sort"
+	"strings"
 
 	semver "github.com/Masterminds/semver/v3"
 	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
 )
 
 func Metadata(cm *ndchub.ConnectorMetadata, connPkgs []ndchub.ConnectorPackaging) error {
+	releases := connectorReleases(cm, connPkgs)
 	var connectorVersions []string
-	for _, connPkg := range connPkgs {
-		if connPkg.Namespace == cm.Overview.Namespace && connPkg.Name == cm.Overview.Name {
-			connectorVersions = append(connectorVersions, connPkg.Version)
-		}
+	for _, release := range releases {
+		connectorVersions = append(connectorVersions, release.Version)
 	}
 	if err := validateLatestVersion(cm.Overview.LatestVersion, connectorVersions); err != nil {
 		return err
 	}
+	if err := validateSourceCodeVersions(cm.SourceCode.Version, releases); err != nil {
+		return err
+	}
 	return nil
 }
 
+// connectorReleases returns the connector-packaging.json files of the connector of metadata.json
+func connectorReleases(cm *ndchub.ConnectorMetadata, connPkgs []ndchub.ConnectorPackaging) []ndchub.ConnectorPackaging {
+	var releases []ndchub.ConnectorPackaging
+	for _, connPkg := range connPkgs {
+		if connPkg.Namespace == cm.Overview.Namespace && connPkg.Name == cm.Overview.Name {
+			releases = append(releases, connPkg)
+		}
+	}
+	return releases
+}
+
+// validateSourceCodeVersions checks that the source_code.version entries of metadata.json match the releases of
+// the connector: every release has a tag, with the same commit as the source.hash of its connector-packaging.json,
+// and every tag has a release. The connectors that don't list their source code versions are not checked.
+func validateSourceCodeVersions(sourceCodeVersions []ndchub.SourceCodeVersion, releases []ndchub.ConnectorPackaging) error {
+	if len(sourceCodeVersions) == 0 {
+		return nil
+	}
+
+	var errs []error
+	sourceCodeVersionsByVersion := make(map[string]ndchub.SourceCodeVersion)
+	for _, sourceCodeVersion := range sourceCodeVersions {
+		version := tagVersion(sourceCodeVersion.Tag)
+		if _, ok := sourceCodeVersionsByVersion[version]; ok {
+			errs = append(errs, fmt.Errorf("source_code.version in metadata.json has more than one tag for %s", version))
+			continue
+		}
+		sourceCodeVersionsByVersion[version] = sourceCodeVersion
+	}
+
+	releaseVersions := make(map[string]bool)
+	for _, release := range releases {
+		releaseVersions[release.Version] = true
+		sourceCodeVersion, ok := sourceCodeVersionsByVersion[release.Version]
+		if !ok {
+			errs = append(errs, fmt.Errorf("source_code.version in metadata.json has no tag for the release %s", release.Version))
+			continue
+		}
+		if !sameCommit(sourceCodeVersion.Hash, release.Source.Hash) {
+			errs = append(errs, fmt.Errorf("source_code.version in metadata.json has the hash %s for the tag %s, but the source.hash of the release %s is %s",
+				sourceCodeVersion.Hash, sourceCodeVersion.Tag, release.Version, release.Source.Hash))
+		}
+	}
+
+	for _, sourceCodeVersion := range sourceCodeVersions {
+		if !releaseVersions[tagVersion(sourceCodeVersion.Tag)] {
+			errs = append(errs, fmt.Errorf("source_code.version in metadata.json has the tag %s, but there is no release %s",
+				sourceCodeVersion.Tag, tagVersion(sourceCodeVersion.Tag)))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// tagVersion returns the version of a git tag, the tags of the connectors of a monorepo are prefixed with the name of
+// the connector, e.g. "mysql/v1.0.8"
+func tagVersion(tag string) string {
+	return tag[strings.LastIndex(tag, "/")+1:]
+}
+
+// sameCommit reports whether two commit hashes refer to the same commit, a short hash matches the full hash it
+// is a prefix of
+func sameCommit(hash1, hash2 string) bool {
+	hash1, hash2 = strings.ToLower(hash1), strings.ToLower(hash2)
+	if hash1 == "" || hash2 == "" {
+		return false
+	}
+	return strings.HasPrefix(hash1, hash2) || strings.HasPrefix(hash2, hash1)
+}
+
 func validateLatestVersion(latestVersion string, allVersions []string) error {
 	var stableVersions semver.Collection
 	for _, version := range allVersions {
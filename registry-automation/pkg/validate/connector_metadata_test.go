@@ -3,6 +3,8 @@ package validate
 import (
 	"strings"
 	"testing"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
 )
 
 func TestValidateLatestVersion(t *testing.T) {
@@ -124,3 +126,94 @@ func TestValidateLatestVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSourceCodeVersions(t *testing.T) {
+	releases := []ndchub.ConnectorPackaging{
+		{Version: "v1.0.0", Source: ndchub.Source{Hash: "4af168d2a3c1e4b0f6a7d9e8c5b4a3f2e1d0c9b8"}},
+		{Version: "v1.1.0", Source: ndchub.Source{Hash: "a57c0b5"}},
+	}
+
+	tests := []struct {
+		name               string
+		sourceCodeVersions []ndchub.SourceCodeVersion
+		errContains        []string
+	}{
+		{
+			name:               "no source code versions",
+			sourceCodeVersions: nil,
+		},
+		{
+			name: "short and full hashes",
+			sourceCodeVersions: []ndchub.SourceCodeVersion{
+				{Tag: "v1.0.0", Hash: "4af168d"},
+				{Tag: "v1.1.0", Hash: "a57c0b5e6d7c8b9a0f1e2d3c4b5a6f7e8d9c0b1a"},
+			},
+		},
+		{
+			name: "tags prefixed with the connector name",
+			sourceCodeVersions: []ndchub.SourceCodeVersion{
+				{Tag: "mysql/v1.0.0", Hash: "4af168d2a3c1e4b0f6a7d9e8c5b4a3f2e1d0c9b8"},
+				{Tag: "mysql/v1.1.0", Hash: "a57c0b5"},
+			},
+		},
+		{
+			name: "missing tag",
+			sourceCodeVersions: []ndchub.SourceCodeVersion{
+				{Tag: "v1.0.0", Hash: "4af168d"},
+			},
+			errContains: []string{"has no tag for the release v1.1.0"},
+		},
+		{
+			name: "mismatching hash",
+			sourceCodeVersions: []ndchub.SourceCodeVersion{
+				{Tag: "v1.0.0", Hash: "4af168e"},
+				{Tag: "v1.1.0", Hash: "a57c0b5"},
+			},
+			errContains: []string{"has the hash 4af168e for the tag v1.0.0, but the source.hash of the release v1.0.0 is 4af168d2a3c1e4b0f6a7d9e8c5b4a3f2e1d0c9b8"},
+		},
+		{
+			name: "tag without release",
+			sourceCodeVersions: []ndchub.SourceCodeVersion{
+				{Tag: "v1.0.0", Hash: "4af168d"},
+				{Tag: "v1.1.0", Hash: "a57c0b5"},
+				{Tag: "v1.2.0", Hash: "e3f7a53"},
+			},
+			errContains: []string{"has the tag v1.2.0, but there is no release v1.2.0"},
+		},
+		{
+			name: "every error is reported",
+			sourceCodeVersions: []ndchub.SourceCodeVersion{
+				{Tag: "v1.0.0", Hash: "4af168d"},
+				{Tag: "v1.0.0", Hash: "4af168d"},
+				{Tag: "v0.9.0", Hash: "e3f7a53"},
+			},
+			errContains: []string{
+				"has more than one tag for v1.0.0",
+				"has no tag for the release v1.1.0",
+				"has the tag v0.9.0, but there is no release v0.9.0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSourceCodeVersions(tt.sourceCodeVersions, releases)
+
+			if len(tt.errContains) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Error("expected error but got none")
+				return
+			}
+			for _, errContains := range tt.errContains {
+				if !strings.Contains(err.Error(), errContains) {
+					t.Errorf("error = %v, want error containing %v", err, errContains)
+				}
+			}
+		})
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"hash"
 	"io"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
 
 	semver "github.com/Masterminds/semver/v3"
+	"github.com/hasura/ndc-hub/registry-automation/pkg"
 	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
 )
 
@@ -28,7 +30,15 @@ func ConnectorPackaging(cp *ndchub.ConnectorPackaging, is_validate_connector_tar
 
 	// validate test config if provided
 	if cp.Test.TestConfigPath != "" {
-		testConfig, err := ndchub.GetTestConfig(filepath.Join(filepath.Dir(cp.Path), cp.Test.TestConfigPath))
+		testConfigPath := filepath.Join(filepath.Dir(cp.Path), cp.Test.TestConfigPath)
+		testConfigContent, err := os.ReadFile(testConfigPath)
+		if err != nil {
+			return err
+		}
+		if err := TestConfigFile(testConfigContent); err != nil {
+			return fmt.Errorf("invalid test config %s: %w", testConfigPath, err)
+		}
+		testConfig, err := ndchub.GetTestConfig(testConfigPath)
 		if err != nil {
 			return err
 		}
@@ -52,6 +62,13 @@ func checkVersion(version string) error {
 }
 
 func checkConnectorTarball(cp *ndchub.ConnectorPackaging) error {
+	// The tarball is verified while it is downloaded into the package cache, so that the validation of the
+	// packaging spec doesn't download it again
+	if pkg.PackageCacheEnabled() {
+		_, _, err := pkg.DownloadConnectorPackage(cp.URI, pkg.Checksum{Type: cp.Checksum.Type, Value: cp.Checksum.Value})
+		return err
+	}
+
 	var checksumFuncs map[string]hash.Hash = map[string]hash.Hash{
 		"sha256": sha256.New(),
 	}
@@ -0,0 +1,160 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/oci"
+)
+
+// ImageResolver fetches the manifests of the image references, along with their digest and their platforms, e.g. an
+// *oci.Resolver
+type ImageResolver interface {
+	Inspect(ref oci.Reference) (oci.Manifest, error)
+}
+
+// imageInspection is the result of the inspection of an image reference
+type imageInspection struct {
+	manifest oci.Manifest
+	err      error
+}
+
+// cachedImageResolver inspects each image reference once, the digest and the platforms rules are derived from the
+// same manifest, and the images shared by the connector versions are not fetched again
+type cachedImageResolver struct {
+	resolver    ImageResolver
+	inspections map[string]imageInspection
+}
+
+func (r cachedImageResolver) Inspect(ref oci.Reference) (oci.Manifest, error) {
+	inspection, ok := r.inspections[ref.String()]
+	if !ok {
+		inspection.manifest, inspection.err = r.resolver.Inspect(ref)
+		r.inspections[ref.String()] = inspection
+	}
+	return inspection.manifest, inspection.err
+}
+
+// connectorImageField is the field of the docker image of the connector, in the PrebuiltDockerImage packaging specs
+const connectorImageField = "packagingDefinition.dockerImage"
+
+// dockerImageField is a docker image of a packaging spec, with the path of its field
+type dockerImageField struct {
+	field string
+	image string
+}
+
+// dockerImageFields returns the docker images of the packaging spec: the image of the connector, the images of the
+// dockerized commands and the image of the Docker CLI plugin
+func dockerImageFields(spec *ndchub.ConnectorMetadataDefinition) []dockerImageField {
+	var fields []dockerImageField
+	if spec.PackagingDefinition.Type == ndchub.PrebuiltDockerImage && spec.PackagingDefinition.DockerImage != nil {
+		fields = append(fields, dockerImageField{field: connectorImageField, image: *spec.PackagingDefinition.DockerImage})
+	}
+	commands := []struct {
+		name    string
+		command *ndchub.Command
+	}{
+		{"update", spec.Commands.Update},
+		{"watch", spec.Commands.Watch},
+		{"printSchemaAndCapabilities", spec.Commands.PrintSchemaAndCapabilities},
+		{"upgradeConfiguration", spec.Commands.UpgradeConfiguration},
+	}
+	for _, command := range commands {
+		if command.command != nil && command.command.DockerizedCommand != nil {
+			fields = append(fields, dockerImageField{field: "commands." + command.name + ".dockerImage", image: command.command.DockerizedCommand.DockerImage})
+		}
+	}
+	if spec.CliPlugin != nil && spec.CliPlugin.Docker != nil {
+		fields = append(fields, dockerImageField{field: "cliPlugin.dockerImage", image: spec.CliPlugin.Docker.DockerImage})
+	}
+	return fields
+}
+
+// checkDockerImages parses the docker images of the packaging spec, and checks the references that are valid. The
+// invalid references are only reported by validateDockerImageReferences.
+func checkDockerImages(spec *ndchub.ConnectorMetadataDefinition, check func(field dockerImageField, ref oci.Reference) string) error {
+	var errs ndchub.ValidationErrors
+	for _, field := range dockerImageFields(spec) {
+		ref, err := oci.ParseReference(field.image)
+		if err != nil {
+			continue
+		}
+		if message := check(field, ref); message != "" {
+			errs = append(errs, ndchub.FieldError{Field: field.field, Message: message})
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateDockerImageReferences checks that the docker images of the packaging spec are valid image references
+func validateDockerImageReferences(spec *ndchub.ConnectorMetadataDefinition) error {
+	var errs ndchub.ValidationErrors
+	for _, field := range dockerImageFields(spec) {
+		if _, err := oci.ParseReference(field.image); err != nil {
+			errs = append(errs, ndchub.FieldError{Field: field.field, Message: err.Error()})
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateDockerImageTags checks that the docker images of the packaging spec are not referenced by a mutable tag,
+// like latest, since the release would then change when the tag is moved
+func validateDockerImageTags(spec *ndchub.ConnectorMetadataDefinition) error {
+	return checkDockerImages(spec, func(field dockerImageField, ref oci.Reference) string {
+		if !ref.MutableTag() {
+			return ""
+		}
+		if ref.Tag == "" {
+			return fmt.Sprintf("%s has no tag, it references the mutable latest tag", field.image)
+		}
+		return fmt.Sprintf("%s references the mutable tag %s", field.image, ref.Tag)
+	})
+}
+
+// validateConnectorImageVersion checks that the tag of the docker image of the connector is the version of the
+// release, with or without its v prefix. The images referenced by digest only are not checked.
+func validateConnectorImageVersion(spec *ndchub.ConnectorMetadataDefinition, version string) error {
+	return checkDockerImages(spec, func(field dockerImageField, ref oci.Reference) string {
+		if field.field != connectorImageField || ref.Tag == "" {
+			return ""
+		}
+		if ref.Tag == version || ref.Tag == strings.TrimPrefix(version, "v") {
+			return ""
+		}
+		return fmt.Sprintf("the tag %s of the connector image does not match the version %s of the release", ref.Tag, version)
+	})
+}
+
+// validateDockerImageDigests checks that the docker images of the packaging spec can be inspected, and so resolved to
+// a digest
+func validateDockerImageDigests(spec *ndchub.ConnectorMetadataDefinition, resolver ImageResolver) error {
+	return checkDockerImages(spec, func(field dockerImageField, ref oci.Reference) string {
+		if _, err := resolver.Inspect(ref); err != nil {
+			return err.Error()
+		}
+		return ""
+	})
+}
+
+// validateDockerImagePlatforms checks that the docker images of the packaging spec are available for the platforms
+// that the connectors are run on. The images that can't be inspected are only reported by validateDockerImageDigests.
+func validateDockerImagePlatforms(spec *ndchub.ConnectorMetadataDefinition, resolver ImageResolver) error {
+	return checkDockerImages(spec, func(field dockerImageField, ref oci.Reference) string {
+		manifest, err := resolver.Inspect(ref)
+		if err != nil {
+			return ""
+		}
+		if err := manifest.CheckPlatforms(ref, oci.RequiredPlatforms); err != nil {
+			return err.Error()
+		}
+		return ""
+	})
+}
@@ -0,0 +1,124 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/oci"
+	"github.com/stretchr/testify/assert"
+)
+
+const sha256Hex = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// fakeImageResolver inspects the references of its manifests, by the normalized reference
+type fakeImageResolver map[string]oci.Manifest
+
+func (r fakeImageResolver) Inspect(ref oci.Reference) (oci.Manifest, error) {
+	manifest, ok := r[ref.String()]
+	if !ok {
+		return manifest, oci.ErrManifestNotFound
+	}
+	return manifest, nil
+}
+
+// countingImageResolver counts the inspections of each reference
+type countingImageResolver struct {
+	resolver    ImageResolver
+	inspections map[string]int
+}
+
+func (r *countingImageResolver) Inspect(ref oci.Reference) (oci.Manifest, error) {
+	r.inspections[ref.String()]++
+	return r.resolver.Inspect(ref)
+}
+
+func dockerImageSpec(connectorImage string, commandImage string) *ndchub.ConnectorMetadataDefinition {
+	spec := &ndchub.ConnectorMetadataDefinition{
+		PackagingDefinition: ndchub.PackagingDefinition{Type: ndchub.PrebuiltDockerImage, DockerImage: &connectorImage},
+	}
+	spec.Commands.Update = &ndchub.Command{DockerizedCommand: &ndchub.DockerizedCommand{Type: ndchub.DockerizedCommandType, DockerImage: commandImage}}
+	return spec
+}
+
+func TestCheckDockerImageRules(t *testing.T) {
+	testCases := []struct {
+		name         string
+		spec         *ndchub.ConnectorMetadataDefinition
+		wantFindings []string
+	}{
+		{"Pinned release images", dockerImageSpec("ghcr.io/hasura/ndc-test:v1.0.0", "ghcr.io/hasura/ndc-test-cli:1.0.0"), nil},
+		{"Release tag without v prefix", dockerImageSpec("ghcr.io/hasura/ndc-test:1.0.0", "ghcr.io/hasura/ndc-test-cli:1.0.0"), nil},
+		{"Digest only", dockerImageSpec("ghcr.io/hasura/ndc-test@sha256:"+sha256Hex, "ghcr.io/hasura/ndc-test-cli:1.0.0"), nil},
+		{"Invalid reference", dockerImageSpec("ghcr.io/hasura/NDC-test:v1.0.0", "ghcr.io/hasura/ndc-test-cli:1.0.0"), []string{
+			`docker-image-reference: invalid reference "ghcr.io/hasura/NDC-test:v1.0.0": the repository must be lowercase (/packagingDefinition/dockerImage in .hasura-connector/connector-metadata.yaml)`,
+		}},
+		{"Mutable tags", dockerImageSpec("ghcr.io/hasura/ndc-test:latest", "ghcr.io/hasura/ndc-test-cli"), []string{
+			"docker-image-tag: ghcr.io/hasura/ndc-test:latest references the mutable tag latest (/packagingDefinition/dockerImage in .hasura-connector/connector-metadata.yaml)",
+			"docker-image-tag: ghcr.io/hasura/ndc-test-cli has no tag, it references the mutable latest tag (/commands/update/dockerImage in .hasura-connector/connector-metadata.yaml)",
+			"connector-image-version: the tag latest of the connector image does not match the version v1.0.0 of the release (/packagingDefinition/dockerImage in .hasura-connector/connector-metadata.yaml)",
+		}},
+		{"Version mismatch", dockerImageSpec("ghcr.io/hasura/ndc-test:v0.9.0", "ghcr.io/hasura/ndc-test-cli:1.0.0"), []string{
+			"connector-image-version: the tag v0.9.0 of the connector image does not match the version v1.0.0 of the release (/packagingDefinition/dockerImage in .hasura-connector/connector-metadata.yaml)",
+		}},
+	}
+
+	cp := &ndchub.ConnectorPackaging{Namespace: "hasura", Name: "test", Version: "v1.0.0", Path: "connector-packaging.json"}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var messages []string
+			for _, ruleID := range []string{DockerImageReferenceRule, DockerImageTagRule, ConnectorImageVersionRule} {
+				rule, ok := DefaultRuleRegistry().Rule(ruleID)
+				assert.True(t, ok)
+				for _, finding := range rule.Check(Target{Kind: PackagingSpecTarget, Path: cp.Path, ConnectorPackaging: cp, PackagingSpec: tc.spec}) {
+					messages = append(messages, finding.RuleID+": "+finding.Message)
+				}
+			}
+			assert.Equal(t, tc.wantFindings, messages)
+		})
+	}
+}
+
+func TestCheckDockerImages(t *testing.T) {
+	resolver := fakeImageResolver{
+		"ghcr.io/hasura/ndc-test:v1.0.0":     {Digest: "sha256:" + sha256Hex, Platforms: oci.RequiredPlatforms},
+		"ghcr.io/hasura/ndc-test-cli:v1.0.0": {Digest: "sha256:" + sha256Hex, Platforms: []oci.Platform{oci.LinuxAmd64}},
+	}
+	cp := &ndchub.ConnectorPackaging{Namespace: "hasura", Name: "test", Version: "v1.0.0", Path: "connector-packaging.json"}
+
+	findings := NewValidator(DefaultRuleRegistry()).CheckDockerImages(cp, dockerImageSpec("ghcr.io/hasura/ndc-test:v1.0.0", "ghcr.io/hasura/ndc-test-cli:v1.0.0"), resolver)
+	if assert.Len(t, findings, 1) {
+		assert.Equal(t, DockerImagePlatformsRule, findings[0].RuleID)
+		assert.Equal(t, "ghcr.io/hasura/ndc-test-cli:v1.0.0 has no image for linux/arm64, it must be available for linux/amd64, linux/arm64 (/commands/update/dockerImage in .hasura-connector/connector-metadata.yaml)", findings[0].Message)
+	}
+
+	// The images that don't exist are only reported by the digest rule
+	findings = NewValidator(DefaultRuleRegistry()).CheckDockerImages(cp, dockerImageSpec("ghcr.io/hasura/ndc-test:v1.0.0", "ghcr.io/hasura/ndc-test-cli:v2.0.0"), resolver)
+	if assert.Len(t, findings, 1) {
+		assert.Equal(t, DockerImageDigestRule, findings[0].RuleID)
+		assert.Equal(t, "manifest not found (/commands/update/dockerImage in .hasura-connector/connector-metadata.yaml)", findings[0].Message)
+		assert.Equal(t, "hasura/test", findings[0].Connector)
+		assert.Equal(t, "v1.0.0", findings[0].Version)
+	}
+}
+
+func TestCheckDockerImagesInspectsEachImageOnce(t *testing.T) {
+	resolver := &countingImageResolver{
+		resolver: fakeImageResolver{
+			"ghcr.io/hasura/ndc-test:v1.0.0":     {Digest: "sha256:" + sha256Hex, Platforms: oci.RequiredPlatforms},
+			"ghcr.io/hasura/ndc-test-cli:v1.0.0": {Digest: "sha256:" + sha256Hex, Platforms: oci.RequiredPlatforms},
+		},
+		inspections: make(map[string]int),
+	}
+	validator := NewValidator(DefaultRuleRegistry())
+
+	// The CLI image is shared by both versions, and the image of v2.0.0 doesn't exist
+	for _, version := range []string{"v1.0.0", "v2.0.0"} {
+		cp := &ndchub.ConnectorPackaging{Namespace: "hasura", Name: "test", Version: version, Path: "connector-packaging.json"}
+		validator.CheckDockerImages(cp, dockerImageSpec("ghcr.io/hasura/ndc-test:"+version, "ghcr.io/hasura/ndc-test-cli:v1.0.0"), resolver)
+	}
+	assert.Equal(t, map[string]int{
+		"ghcr.io/hasura/ndc-test:v1.0.0":     1,
+		"ghcr.io/hasura/ndc-test:v2.0.0":     1,
+		"ghcr.io/hasura/ndc-test-cli:v1.0.0": 1,
+	}, resolver.inspections)
+}
@@ -0,0 +1,90 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+)
+
+// Severity is the severity of a finding, the values are the levels of SARIF
+type Severity string
+
+const (
+	ErrorSeverity   Severity = "error"
+	WarningSeverity Severity = "warning"
+	NoteSeverity    Severity = "note"
+)
+
+// Finding is a problem found in a file of the registry folder
+type Finding struct {
+	// File is the path of the file, relative to the root of the repository in the reports
+	File string `json:"file"`
+	// Path is the JSON pointer of the invalid value in the file, e.g. "/source_code/version/0"
+	Path     string   `json:"path,omitempty"`
+	RuleID   string   `json:"rule_id"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// Connector is the namespace/name of the connector of the file, and Version the release of the connector
+	Connector string `json:"connector,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+func (f Finding) String() string {
+	location := f.File
+	if f.Path != "" {
+		location += "#" + f.Path
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", f.Severity, location, f.Message, f.RuleID)
+}
+
+// errorFindings converts the error of a check into findings. The schema violations and the invalid fields of a
+// packaging spec are reported one finding each, with their path.
+func errorFindings(file, ruleID string, err error) []Finding {
+	if err == nil {
+		return nil
+	}
+
+	var violations SchemaViolations
+	if errors.As(err, &violations) {
+		findings := make([]Finding, 0, len(violations))
+		for _, violation := range violations {
+			findings = append(findings, Finding{File: file, Path: violation.Pointer, RuleID: ruleID, Severity: ErrorSeverity, Message: violation.Message})
+		}
+		return findings
+	}
+
+	var fieldErrs ndchub.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		findings := make([]Finding, 0, len(fieldErrs))
+		for _, fieldErr := range fieldErrs {
+			findings = append(findings, Finding{File: file, Path: fieldPointer(fieldErr.Field), RuleID: ruleID, Severity: ErrorSeverity, Message: fieldErr.Message})
+		}
+		return findings
+	}
+
+	// The checks that report several errors join them
+	if joinedErr, ok := err.(interface{ Unwrap() []error }); ok {
+		var findings []Finding
+		for _, err := range joinedErr.Unwrap() {
+			findings = append(findings, errorFindings(file, ruleID, err)...)
+		}
+		return findings
+	}
+
+	return []Finding{{File: file, RuleID: ruleID, Severity: ErrorSeverity, Message: err.Error()}}
+}
+
+var fieldIndexRegex = regexp.MustCompile(`\[(\d+)\]`)
+
+// fieldPointer converts the path of a field of a packaging spec, e.g. "supportedEnvironmentVariables[0].name",
+// into a JSON pointer
+func fieldPointer(field string) string {
+	if field == "" {
+		return ""
+	}
+	field = fieldIndexRegex.ReplaceAllString(field, ".$1")
+	return jsonPointer(strings.Split(field, "."))
+}
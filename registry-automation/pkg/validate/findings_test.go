@@ -0,0 +1,85 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorFindings(t *testing.T) {
+	const file = "registry/hasura/test/metadata.json"
+
+	testCases := []struct {
+		name         string
+		err          error
+		wantFindings []Finding
+	}{
+		{"No error", nil, nil},
+		{"Plain error", fmt.Errorf("invalid semantic version: 1.0"), []Finding{
+			{File: file, RuleID: "test-rule", Severity: ErrorSeverity, Message: "invalid semantic version: 1.0"},
+		}},
+		{"Schema violations", SchemaViolations{
+			{Pointer: "/overview", Message: "missing property 'title'"},
+			{Pointer: "/source_code/version/0", Message: "missing property 'hash'"},
+		}, []Finding{
+			{File: file, Path: "/overview", RuleID: "test-rule", Severity: ErrorSeverity, Message: "missing property 'title'"},
+			{File: file, Path: "/source_code/version/0", RuleID: "test-rule", Severity: ErrorSeverity, Message: "missing property 'hash'"},
+		}},
+		{"Invalid fields of a packaging spec", ndchub.ValidationErrors{
+			{Field: "packagingDefinition.dockerImage", Message: "is required for the PrebuiltDockerImage packaging type"},
+			{Field: "supportedEnvironmentVariables[1].name", Message: "is required"},
+		}, []Finding{
+			{File: file, Path: "/packagingDefinition/dockerImage", RuleID: "test-rule", Severity: ErrorSeverity, Message: "is required for the PrebuiltDockerImage packaging type"},
+			{File: file, Path: "/supportedEnvironmentVariables/1/name", RuleID: "test-rule", Severity: ErrorSeverity, Message: "is required"},
+		}},
+		{"Joined errors", errors.Join(fmt.Errorf("first error"), fmt.Errorf("second error")), []Finding{
+			{File: file, RuleID: "test-rule", Severity: ErrorSeverity, Message: "first error"},
+			{File: file, RuleID: "test-rule", Severity: ErrorSeverity, Message: "second error"},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantFindings, errorFindings(file, "test-rule", tc.err))
+		})
+	}
+}
+
+func TestValidatorCheckMetadata(t *testing.T) {
+	cm := &ndchub.ConnectorMetadata{}
+	cm.Overview.Namespace = "hasura"
+	cm.Overview.Name = "test"
+	cm.Overview.LatestVersion = "v1.0.0"
+	cm.SourceCode.Version = []ndchub.SourceCodeVersion{{Tag: "v1.0.0", Hash: "4af168d"}}
+
+	connPkgs := []ndchub.ConnectorPackaging{
+		{Namespace: "hasura", Name: "test", Version: "v1.0.0", Source: ndchub.Source{Hash: "4af168d2a3c1e4b0"}},
+		{Namespace: "hasura", Name: "test", Version: "v1.1.0", Source: ndchub.Source{Hash: "a57c0b5"}},
+		{Namespace: "hasura", Name: "other", Version: "v2.0.0", Source: ndchub.Source{Hash: "e3f7a53"}},
+	}
+
+	validator := NewValidator(DefaultRuleRegistry())
+	findings := validator.CheckMetadata("registry/hasura/test/metadata.json", cm, connPkgs)
+	assert.Equal(t, []Finding{
+		{
+			File:      "registry/hasura/test/metadata.json",
+			Path:      "/overview/latest_version",
+			RuleID:    MetadataLatestVersionRule,
+			Severity:  ErrorSeverity,
+			Message:   "latest_version in metadata.json (v1.0.0) does not match actual latest version (1.1.0)",
+			Connector: "hasura/test",
+		},
+		{
+			File:      "registry/hasura/test/metadata.json",
+			Path:      "/source_code/version",
+			RuleID:    MetadataSourceCodeVersionRule,
+			Severity:  WarningSeverity,
+			Message:   "source_code.version in metadata.json has no tag for the release v1.1.0",
+			Connector: "hasura/test",
+		},
+	}, findings)
+	assert.Equal(t, findings, validator.Report().Findings)
+}
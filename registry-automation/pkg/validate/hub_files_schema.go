@@ -0,0 +1,120 @@
+package validate
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// The JSON schemas of the files of the registry folder, they are also the reference of the file formats for the
+// connector authors
+var (
+	//go:embed schemas/metadata.schema.json
+	metadataSchema []byte
+	//go:embed schemas/connector-packaging.schema.json
+	connectorPackagingSchema []byte
+	//go:embed schemas/test-config.schema.json
+	testConfigSchema []byte
+)
+
+const (
+	metadataSchemaURL           = "metadata.schema.json"
+	connectorPackagingSchemaURL = "connector-packaging.schema.json"
+	testConfigSchemaURL         = "test-config.schema.json"
+)
+
+var (
+	compileHubFileSchemasOnce sync.Once
+	compiledHubFileSchemas    map[string]*jsonschema.Schema
+	compileHubFileSchemasErr  error
+)
+
+// getHubFileSchemas compiles the embedded schemas once, keyed by their URL. The schemas are compiled together as
+// metadata.json reuses the definitions of connector-packaging.json.
+func getHubFileSchemas() (map[string]*jsonschema.Schema, error) {
+	compileHubFileSchemasOnce.Do(func() {
+		compiledHubFileSchemas, compileHubFileSchemasErr = compileHubFileSchemas()
+	})
+	return compiledHubFileSchemas, compileHubFileSchemasErr
+}
+
+func compileHubFileSchemas() (map[string]*jsonschema.Schema, error) {
+	resources := map[string][]byte{
+		metadataSchemaURL:           metadataSchema,
+		connectorPackagingSchemaURL: connectorPackagingSchema,
+		testConfigSchemaURL:         testConfigSchema,
+	}
+
+	compiler := jsonschema.NewCompiler()
+	for url, content := range resources {
+		schema, err := jsonschema.UnmarshalJSON(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the schema %s: %w", url, err)
+		}
+		if err := compiler.AddResource(url, schema); err != nil {
+			return nil, fmt.Errorf("failed to load the schema %s: %w", url, err)
+		}
+	}
+
+	schemas := make(map[string]*jsonschema.Schema, len(resources))
+	for url := range resources {
+		schema, err := compiler.Compile(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile the schema %s: %w", url, err)
+		}
+		schemas[url] = schema
+	}
+	return schemas, nil
+}
+
+// MetadataFile validates the content of a metadata.json file against its JSON schema, and checks that every field
+// of the file is decoded into ndchub.ConnectorMetadata
+func MetadataFile(content []byte) error {
+	return hubFile(metadataSchemaURL, content, &ndchub.ConnectorMetadata{})
+}
+
+// ConnectorPackagingFile validates the content of a connector-packaging.json file against its JSON schema, and
+// checks that every field of the file is decoded into ndchub.ConnectorPackaging
+func ConnectorPackagingFile(content []byte) error {
+	return hubFile(connectorPackagingSchemaURL, content, &ndchub.ConnectorPackaging{})
+}
+
+// TestConfigFile validates the content of a test-config.json file against its JSON schema, and checks that every
+// field of the file is decoded into ndchub.TestConfig
+func TestConfigFile(content []byte) error {
+	return hubFile(testConfigSchemaURL, content, &ndchub.TestConfig{})
+}
+
+// hubFile reports the schema violations of the file if any. Otherwise the file is decoded strictly into v, so that
+// a field allowed by the schema but missing from the Go struct is not dropped silently.
+func hubFile(schemaURL string, content []byte, v any) error {
+	schemas, err := getHubFileSchemas()
+	if err != nil {
+		return err
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse the file: %w", err)
+	}
+	if err := validateInstance(schemas[schemaURL], instance); err != nil {
+		return err
+	}
+
+	if err := decodeStrict(content, v); err != nil {
+		return fmt.Errorf("failed to decode the file: %w", err)
+	}
+	return nil
+}
+
+// decodeStrict decodes the JSON content into v, and fails on the fields that are not defined in v
+func decodeStrict(content []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
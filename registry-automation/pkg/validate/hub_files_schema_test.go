@@ -0,0 +1,150 @@
+package validate
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubFileSchemas(t *testing.T) {
+	testCases := []struct {
+		name           string
+		validateFile   func([]byte) error
+		content        string
+		wantViolations []SchemaViolation
+	}{
+		{"Valid connector-packaging.json", ConnectorPackagingFile, `{
+  "version": "v1.0.0",
+  "uri": "https://example.com/package.tar.gz",
+  "checksum": {"type": "sha256", "value": "0123456789abcdef"},
+  "source": {"hash": "0123456789abcdef"},
+  "test": {"test_config_path": "../../tests/test-config.json"}
+}`, nil},
+		{"Misspelled field in connector-packaging.json", ConnectorPackagingFile, `{
+  "version": "v1.0.0",
+  "uri": "https://example.com/package.tar.gz",
+  "checksum": {"type": "sha256", "value": "0123456789abcdef"},
+  "source": {"hash": "0123456789abcdef"},
+  "test": {"test_config": "../../tests/test-config.json"}
+}`, []SchemaViolation{
+			{Pointer: "/test", Message: "additional properties 'test_config' not allowed"},
+		}},
+		{"Unsupported checksum type", ConnectorPackagingFile, `{
+  "version": "v1.0.0",
+  "uri": "https://example.com/package.tar.gz",
+  "checksum": {"type": "md5", "value": "0123456789abcdef"},
+  "source": {"hash": "0123456789abcdef"}
+}`, []SchemaViolation{
+			{Pointer: "/checksum/type", Message: "value must be 'sha256'"},
+		}},
+		{"Valid metadata.json", MetadataFile, `{
+  "overview": {"namespace": "hasura", "description": "Test connector", "title": "Test", "logo": "logo.png", "tags": [], "latest_version": "v1.0.0"},
+  "author": {"support_email": "support@hasura.io", "homepage": "https://hasura.io", "name": "Hasura"},
+  "is_verified": true,
+  "is_hosted_by_hasura": false,
+  "source_code": {
+    "is_open_source": true,
+    "repository": "https://github.com/hasura/ndc-test",
+    "version": [{"tag": "v1.0.0", "hash": "0123456", "is_verified": true}]
+  }
+}`, nil},
+		{"Invalid source code versions in metadata.json", MetadataFile, `{
+  "overview": {"namespace": "hasura", "description": "Test connector", "title": "Test", "tags": [], "latest_version": "v1.0.0"},
+  "author": {"support_email": "support@hasura.io", "homepage": "https://hasura.io", "name": "Hasura"},
+  "is_verified": true,
+  "is_hosted_by_hasura": false,
+  "source_code": {
+    "is_open_source": true,
+    "repository": "https://github.com/hasura/ndc-test",
+    "version": [{"tag": "v1.0.0", "commit": "0123456"}]
+  }
+}`, []SchemaViolation{
+			{Pointer: "/source_code/version/0", Message: "missing property 'hash'"},
+			{Pointer: "/source_code/version/0", Message: "additional properties 'commit' not allowed"},
+		}},
+		{"Valid test-config.json", TestConfigFile, `{
+  "hub_id": "hasura/test",
+  "port": 8080,
+  "envs": ["CONNECTION_URI=postgres://localhost"],
+  "ddn_workspace": {"enabled": true, "envs": ["CONNECTION_URI=postgres://postgres"]},
+  "snapshots_dir": "snapshots"
+}`, nil},
+		{"Invalid test-config.json", TestConfigFile, `{
+  "hub_id": "test",
+  "envs": ["CONNECTION_URI"],
+  "ddn_workspace": {"enable": true},
+  "snapshots_dir": "snapshots"
+}`, []SchemaViolation{
+			{Pointer: "/ddn_workspace", Message: "missing property 'enabled'"},
+			{Pointer: "/ddn_workspace", Message: "additional properties 'enable' not allowed"},
+			{Pointer: "/envs/0", Message: "'CONNECTION_URI' does not match pattern '^[^=]+='"},
+			{Pointer: "/hub_id", Message: "'test' does not match pattern '^[^/]+/[^/]+$'"},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.validateFile([]byte(tc.content))
+			if tc.wantViolations == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var violations SchemaViolations
+			assert.True(t, errors.As(err, &violations), "unexpected error: %v", err)
+			assert.Equal(t, SchemaViolations(tc.wantViolations), violations)
+		})
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	var testConfig ndchub.TestConfig
+	err := decodeStrict([]byte(`{"hub_id": "hasura/test", "snapshots_dir": "snapshots", "ddn_workspace": {"enabled": true}}`), &testConfig)
+	assert.NoError(t, err)
+	assert.True(t, testConfig.DDNWorkspace.Enabled)
+
+	err = decodeStrict([]byte(`{"hub_id": "hasura/test", "snapshots_dir": "snapshots", "run_e2e_tests": true}`), &testConfig)
+	assert.ErrorContains(t, err, `unknown field "run_e2e_tests"`)
+}
+
+func TestRegistryHubFiles(t *testing.T) {
+	// Every file of the registry folder matches its schema, and is decoded without dropping any field
+	registryFolder := "../../../registry"
+	if _, err := os.Stat(registryFolder); os.IsNotExist(err) {
+		t.Skip("the registry folder is not available")
+	}
+
+	testConfigPaths := make(map[string]bool)
+	err := filepath.WalkDir(registryFolder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch filepath.Base(path) {
+		case ndchub.MetadataJSON:
+			content, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.NoError(t, MetadataFile(content), path)
+		case ndchub.ConnectorPackagingJSON:
+			content, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.NoError(t, ConnectorPackagingFile(content), path)
+			cp, err := ndchub.GetConnectorPackaging(path)
+			assert.NoError(t, err)
+			if testConfigPath := cp.GetTestConfigPath(); testConfigPath != "" {
+				testConfigPaths[testConfigPath] = true
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+
+	for testConfigPath := range testConfigPaths {
+		content, err := os.ReadFile(testConfigPath)
+		assert.NoError(t, err)
+		assert.NoError(t, TestConfigFile(content), testConfigPath)
+	}
+}
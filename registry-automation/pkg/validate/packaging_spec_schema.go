@@ -0,0 +1,263 @@
+package validate
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+// packagingSpecSchema is a copy of connector-metadata-types/schema.json, the JSON schema of connector-metadata.yaml
+//
+//go:embed schemas/connector-metadata.schema.json
+var packagingSpecSchema []byte
+
+const packagingSpecSchemaURL = "connector-metadata.schema.json"
+
+// SchemaViolation is a violation of a JSON schema, Pointer is the JSON pointer of the invalid value in the
+// validated document, e.g. "/packagingDefinition/dockerImage"
+type SchemaViolation struct {
+	Pointer string
+	Message string
+}
+
+func (v SchemaViolation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return fmt.Sprintf("%s: %s", pointer, v.Message)
+}
+
+// SchemaViolations is returned when a document doesn't match its JSON schema
+type SchemaViolations []SchemaViolation
+
+func (e SchemaViolations) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, violation := range e {
+		messages = append(messages, violation.String())
+	}
+	return fmt.Sprintf("%d schema violation(s): %s", len(e), strings.Join(messages, "; "))
+}
+
+// packagingSpecSchemas holds the compiled schema, along with the schema of each version of the packaging spec
+type packagingSpecSchemas struct {
+	all      *jsonschema.Schema
+	versions map[string]*jsonschema.Schema
+}
+
+var (
+	compilePackagingSpecSchemaOnce sync.Once
+	compiledPackagingSpecSchemas   packagingSpecSchemas
+	compilePackagingSpecSchemaErr  error
+)
+
+// getPackagingSpecSchemas compiles the embedded schema once. The schema is generated without `additionalProperties`,
+// so every object with properties is made strict before compiling it, to report the unknown keys. The schema is an
+// anyOf of the versions of the packaging spec, each version is also compiled on its own, so that a packaging spec
+// is only reported with the violations of its own version.
+func getPackagingSpecSchemas() (packagingSpecSchemas, error) {
+	compilePackagingSpecSchemaOnce.Do(func() {
+		compiledPackagingSpecSchemas, compilePackagingSpecSchemaErr = compilePackagingSpecSchemas()
+	})
+	return compiledPackagingSpecSchemas, compilePackagingSpecSchemaErr
+}
+
+func compilePackagingSpecSchemas() (packagingSpecSchemas, error) {
+	schemas := packagingSpecSchemas{versions: make(map[string]*jsonschema.Schema)}
+
+	schema, err := jsonschema.UnmarshalJSON(bytes.NewReader(packagingSpecSchema))
+	if err != nil {
+		return schemas, fmt.Errorf("failed to parse the packaging spec schema: %w", err)
+	}
+	disallowAdditionalProperties(schema)
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(packagingSpecSchemaURL, schema); err != nil {
+		return schemas, fmt.Errorf("failed to load the packaging spec schema: %w", err)
+	}
+	if schemas.all, err = compiler.Compile(packagingSpecSchemaURL); err != nil {
+		return schemas, fmt.Errorf("failed to compile the packaging spec schema: %w", err)
+	}
+
+	branches, _ := schema.(map[string]any)["anyOf"].([]any)
+	for i, branch := range branches {
+		version, ok := constVersion(branch)
+		if !ok {
+			continue
+		}
+		branchSchema, err := compiler.Compile(fmt.Sprintf("%s#/anyOf/%d", packagingSpecSchemaURL, i))
+		if err != nil {
+			return schemas, fmt.Errorf("failed to compile the packaging spec schema of %s: %w", version, err)
+		}
+		schemas.versions[version] = branchSchema
+	}
+	return schemas, nil
+}
+
+// constVersion returns the const value of the version property of a branch of the schema
+func constVersion(branch any) (string, bool) {
+	properties, _ := branch.(map[string]any)["properties"].(map[string]any)
+	version, _ := properties["version"].(map[string]any)
+	constValue, ok := version["const"].(string)
+	return constValue, ok
+}
+
+// schemaFor returns the schema of the version of the packaging spec, the packaging specs without version are v1
+func (s packagingSpecSchemas) schemaFor(document any) *jsonschema.Schema {
+	version := "v1"
+	if object, ok := document.(map[string]any); ok {
+		if value, ok := object["version"]; ok {
+			versionStr, ok := value.(string)
+			if !ok {
+				return s.all
+			}
+			version = versionStr
+		}
+	}
+	if schema, ok := s.versions[version]; ok {
+		return schema
+	}
+	return s.all
+}
+
+func disallowAdditionalProperties(schema any) {
+	switch s := schema.(type) {
+	case map[string]any:
+		if _, ok := s["properties"]; ok {
+			if _, ok := s["additionalProperties"]; !ok {
+				s["additionalProperties"] = false
+			}
+		}
+		for _, value := range s {
+			disallowAdditionalProperties(value)
+		}
+	case []any:
+		for _, value := range s {
+			disallowAdditionalProperties(value)
+		}
+	}
+}
+
+// PackagingSpecSchema validates the content of a connector-metadata.yaml file against the JSON schema of the
+// packaging spec. Every violation is reported in the returned SchemaViolations.
+func PackagingSpecSchema(connectorMetadataYAML []byte) error {
+	schemas, err := getPackagingSpecSchemas()
+	if err != nil {
+		return err
+	}
+
+	var document any
+	if err := yaml.Unmarshal(connectorMetadataYAML, &document); err != nil {
+		return fmt.Errorf("failed to parse connector-metadata.yaml: %w", err)
+	}
+	// The YAML document is converted to the JSON data model that the schema validates
+	documentJSON, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("failed to convert connector-metadata.yaml to JSON: %w", err)
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(documentJSON))
+	if err != nil {
+		return fmt.Errorf("failed to convert connector-metadata.yaml to JSON: %w", err)
+	}
+
+	return validateInstance(schemas.schemaFor(instance), instance)
+}
+
+// validateInstance validates the document against the schema, every violation is reported in the returned SchemaViolations
+func validateInstance(schema *jsonschema.Schema, instance any) error {
+	err := schema.Validate(instance)
+	if err == nil {
+		return nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+	return schemaViolations(validationErr)
+}
+
+var schemaMessagePrinter = message.NewPrinter(language.English)
+
+// schemaViolations flattens the validation error into the violations of its leaves. The branches of an anyOf
+// that are discriminated out, by the type of the value or by the const value of one of its properties, are not
+// reported, so that a value that almost matches a branch is reported with the errors of that branch only.
+func schemaViolations(validationErr *jsonschema.ValidationError) SchemaViolations {
+	seen := make(map[SchemaViolation]bool)
+	var violations SchemaViolations
+
+	var walk func(err *jsonschema.ValidationError)
+	walk = func(err *jsonschema.ValidationError) {
+		causes := err.Causes
+		if _, ok := err.ErrorKind.(*kind.AnyOf); ok {
+			causes = matchingBranches(err)
+		}
+		if len(causes) == 0 {
+			violation := SchemaViolation{Pointer: jsonPointer(err.InstanceLocation), Message: err.ErrorKind.LocalizedString(schemaMessagePrinter)}
+			if !seen[violation] {
+				seen[violation] = true
+				violations = append(violations, violation)
+			}
+			return
+		}
+		for _, cause := range causes {
+			walk(cause)
+		}
+	}
+	walk(validationErr)
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Pointer < violations[j].Pointer
+	})
+	return violations
+}
+
+// matchingBranches returns the branches of a failed anyOf that are not discriminated out. If every branch is
+// discriminated out, none are returned and the anyOf itself is reported.
+func matchingBranches(anyOfErr *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	var branches []*jsonschema.ValidationError
+	for _, branch := range anyOfErr.Causes {
+		if !isDiscriminatedOut(branch, len(anyOfErr.InstanceLocation)) {
+			branches = append(branches, branch)
+		}
+	}
+	return branches
+}
+
+func isDiscriminatedOut(err *jsonschema.ValidationError, depth int) bool {
+	switch err.ErrorKind.(type) {
+	case *kind.Type:
+		if len(err.InstanceLocation) == depth {
+			return true
+		}
+	case *kind.Const:
+		if len(err.InstanceLocation) == depth+1 {
+			return true
+		}
+	}
+	for _, cause := range err.Causes {
+		if isDiscriminatedOut(cause, depth) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonPointer encodes the location of a value as a JSON pointer (RFC 6901)
+func jsonPointer(location []string) string {
+	var sb strings.Builder
+	for _, token := range location {
+		sb.WriteByte('/')
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return sb.String()
+}
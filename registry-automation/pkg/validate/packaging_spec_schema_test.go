@@ -0,0 +1,106 @@
+package validate
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const validPackagingSpec = `packagingDefinition:
+  type: PrebuiltDockerImage
+  dockerImage: ghcr.io/hasura/ndc-test:v1.0.0
+supportedEnvironmentVariables:
+  - name: CONNECTION_URI
+    description: The connection URI
+commands:
+  update: hasura-ndc-test update
+cliPlugin:
+  name: ndc-test
+  version: v1.0.0
+dockerComposeWatch: []
+`
+
+func TestPackagingSpecSchema(t *testing.T) {
+	testCases := []struct {
+		name           string
+		spec           string
+		wantViolations []SchemaViolation
+	}{
+		{"Valid v1 packaging spec", validPackagingSpec, nil},
+		{"Valid v2 packaging spec", `version: v2
+ndcSpecGeneration: v0.2
+packagingDefinition:
+  type: ManagedDockerBuild
+supportedEnvironmentVariables: []
+commands: {}
+dockerComposeWatch: []
+`, nil},
+		{"Unknown keys and typos", validPackagingSpec + "documentationPages: https://example.com\n", []SchemaViolation{
+			{Pointer: "", Message: "additional properties 'documentationPages' not allowed"},
+		}},
+		{"Typo in an environment variable", `packagingDefinition:
+  type: ManagedDockerBuild
+supportedEnvironmentVariables:
+  - nme: CONNECTION_URI
+    description: The connection URI
+commands: {}
+dockerComposeWatch: []
+`, []SchemaViolation{
+			{Pointer: "/supportedEnvironmentVariables/0", Message: "missing property 'name'"},
+			{Pointer: "/supportedEnvironmentVariables/0", Message: "additional properties 'nme' not allowed"},
+		}},
+		{"Wrong command shape", `packagingDefinition:
+  type: ManagedDockerBuild
+supportedEnvironmentVariables: []
+commands:
+  update:
+    type: Dockerized
+    dockerImage: ghcr.io/hasura/ndc-test:v1.0.0
+dockerComposeWatch: []
+`, []SchemaViolation{
+			{Pointer: "/commands/update", Message: "missing property 'commandArgs'"},
+		}},
+		{"Bad platform selector", `version: v2
+ndcSpecGeneration: v0.2
+packagingDefinition:
+  type: ManagedDockerBuild
+supportedEnvironmentVariables: []
+commands: {}
+dockerComposeWatch: []
+cliPlugin:
+  type: BinaryInline
+  platforms:
+    - selector: linux-x86_64
+      uri: https://example.com/ndc-test
+      sha256: 0123456789abcdef
+      bin: ndc-test
+`, []SchemaViolation{
+			{Pointer: "/cliPlugin/platforms/0/selector", Message: "value must be one of 'darwin-amd64', 'darwin-arm64', 'linux-amd64', 'linux-arm64', 'windows-amd64'"},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := PackagingSpecSchema([]byte(tc.spec))
+			if tc.wantViolations == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var violations SchemaViolations
+			assert.True(t, errors.As(err, &violations), "unexpected error: %v", err)
+			assert.Equal(t, SchemaViolations(tc.wantViolations), violations)
+		})
+	}
+}
+
+func TestPackagingSpecSchemaIsUpToDate(t *testing.T) {
+	// The embedded schema is a copy of the schema generated in the connector-metadata-types folder
+	generatedSchema, err := os.ReadFile("../../../connector-metadata-types/schema.json")
+	if os.IsNotExist(err) {
+		t.Skip("the connector-metadata-types folder is not available")
+	}
+	assert.NoError(t, err)
+	assert.Equal(t, string(generatedSchema), string(packagingSpecSchema), "copy connector-metadata-types/schema.json to pkg/validate/schemas/connector-metadata.schema.json")
+}
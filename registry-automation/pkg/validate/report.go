@@ -0,0 +1,347 @@
+package validate
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ReportFormat is the format that a validation report is written in
+type ReportFormat string
+
+const (
+	TextReportFormat  ReportFormat = "text"
+	JSONReportFormat  ReportFormat = "json"
+	JUnitReportFormat ReportFormat = "junit"
+	SARIFReportFormat ReportFormat = "sarif"
+)
+
+func (f ReportFormat) IsValid() bool {
+	return f == TextReportFormat || f == JSONReportFormat || f == JUnitReportFormat || f == SARIFReportFormat
+}
+
+// Report is the result of the validation of the registry folder
+type Report struct {
+	// Connectors are the namespace/name of the validated connectors, the connectors without findings are reported too
+	Connectors []string  `json:"connectors"`
+	Findings   []Finding `json:"findings"`
+	// Suppressed are the findings suppressed by the .hub-validate.yaml files of the connectors
+	Suppressed []Finding `json:"suppressed,omitempty"`
+
+	// rules are the rules of the findings, the default rules if not set
+	rules *RuleRegistry
+}
+
+// AddConnector records a validated connector
+func (r *Report) AddConnector(connector string) {
+	if slices.Contains(r.Connectors, connector) {
+		return
+	}
+	r.Connectors = append(r.Connectors, connector)
+}
+
+// Add records findings, the findings of the files that are checked several times are only recorded once
+func (r *Report) Add(findings ...Finding) {
+	for _, finding := range findings {
+		if !slices.Contains(r.Findings, finding) {
+			r.Findings = append(r.Findings, finding)
+		}
+	}
+}
+
+func (r *Report) addSuppressed(finding Finding) {
+	if !slices.Contains(r.Suppressed, finding) {
+		r.Suppressed = append(r.Suppressed, finding)
+	}
+}
+
+// HasErrors reports whether any of the findings of the report is an error
+func (r *Report) HasErrors() bool {
+	for _, finding := range r.Findings {
+		if finding.Severity == ErrorSeverity {
+			return true
+		}
+	}
+	return false
+}
+
+// RelativizePaths makes the files of the findings relative to the root of the repository, with forward slashes, so
+// that they can be resolved by code scanning and by the test dashboards
+func (r *Report) RelativizePaths(root string) {
+	for i, finding := range r.Findings {
+		relPath, err := filepath.Rel(root, finding.File)
+		if err != nil || strings.HasPrefix(relPath, "..") {
+			continue
+		}
+		r.Findings[i].File = filepath.ToSlash(relPath)
+	}
+}
+
+// summary counts the findings of each severity
+func (r *Report) summary() map[Severity]int {
+	counts := map[Severity]int{ErrorSeverity: 0, WarningSeverity: 0, NoteSeverity: 0}
+	for _, finding := range r.Findings {
+		counts[finding.Severity]++
+	}
+	return counts
+}
+
+// WriteReport writes the report in the format
+func WriteReport(w io.Writer, report Report, format ReportFormat) error {
+	switch format {
+	case TextReportFormat:
+		return writeTextReport(w, report)
+	case JSONReportFormat:
+		return writeJSONReport(w, report)
+	case JUnitReportFormat:
+		return writeJUnitReport(w, report)
+	case SARIFReportFormat:
+		return writeSARIFReport(w, report)
+	default:
+		return fmt.Errorf("unsupported report format: %s", format)
+	}
+}
+
+func writeTextReport(w io.Writer, report Report) error {
+	for _, finding := range report.Findings {
+		if _, err := fmt.Fprintln(w, finding.String()); err != nil {
+			return err
+		}
+	}
+	counts := report.summary()
+	_, err := fmt.Fprintf(w, "%d connector(s) validated: %d error(s), %d warning(s), %d note(s), %d suppressed\n",
+		len(report.Connectors), counts[ErrorSeverity], counts[WarningSeverity], counts[NoteSeverity], len(report.Suppressed))
+	return err
+}
+
+func writeJSONReport(w io.Writer, report Report) error {
+	counts := report.summary()
+	jsonReport := struct {
+		Report
+		Summary struct {
+			Errors   int `json:"errors"`
+			Warnings int `json:"warnings"`
+			Notes    int `json:"notes"`
+		} `json:"summary"`
+	}{Report: report}
+	if jsonReport.Connectors == nil {
+		jsonReport.Connectors = []string{}
+	}
+	if jsonReport.Findings == nil {
+		jsonReport.Findings = []Finding{}
+	}
+	jsonReport.Summary.Errors = counts[ErrorSeverity]
+	jsonReport.Summary.Warnings = counts[WarningSeverity]
+	jsonReport.Summary.Notes = counts[NoteSeverity]
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonReport)
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+// junitRegistryTestSuite is the test suite of the findings that don't belong to a connector
+const junitRegistryTestSuite = "registry"
+
+// writeJUnitReport writes a test suite per connector, with a test case per release of the connector and one for the
+// files of the connector itself. The errors fail the test cases, the warnings and the notes are in their output.
+func writeJUnitReport(w io.Writer, report Report) error {
+	findingsByTestCase := make(map[string]map[string][]Finding)
+	addTestCase := func(connector, testCase string) {
+		if _, ok := findingsByTestCase[connector]; !ok {
+			findingsByTestCase[connector] = make(map[string][]Finding)
+		}
+		if _, ok := findingsByTestCase[connector][testCase]; !ok {
+			findingsByTestCase[connector][testCase] = nil
+		}
+	}
+	for _, connector := range report.Connectors {
+		addTestCase(connector, connector)
+	}
+	for _, finding := range report.Findings {
+		connector, testCase := finding.Connector, finding.Connector
+		if connector == "" {
+			connector, testCase = junitRegistryTestSuite, finding.File
+		} else if finding.Version != "" {
+			testCase = connector + "@" + finding.Version
+		}
+		addTestCase(connector, testCase)
+		findingsByTestCase[connector][testCase] = append(findingsByTestCase[connector][testCase], finding)
+	}
+
+	testSuites := junitTestSuites{Name: "validate"}
+	for _, connector := range sortedKeys(findingsByTestCase) {
+		testSuite := junitTestSuite{Name: connector}
+		for _, testCaseName := range sortedKeys(findingsByTestCase[connector]) {
+			testCase := junitTestCase{Name: testCaseName, ClassName: connector}
+			var failures, output []string
+			for _, finding := range findingsByTestCase[connector][testCaseName] {
+				if finding.Severity == ErrorSeverity {
+					failures = append(failures, finding.String())
+				} else {
+					output = append(output, finding.String())
+				}
+			}
+			if len(failures) > 0 {
+				testCase.Failure = &junitFailure{
+					Message: fmt.Sprintf("%d error(s)", len(failures)),
+					Type:    string(ErrorSeverity),
+					Content: strings.Join(failures, "\n"),
+				}
+				testSuite.Failures++
+			}
+			testCase.SystemOut = strings.Join(output, "\n")
+			testSuite.TestCases = append(testSuite.TestCases, testCase)
+			testSuite.Tests++
+		}
+		testSuites.TestSuites = append(testSuites.TestSuites, testSuite)
+		testSuites.Tests += testSuite.Tests
+		testSuites.Failures += testSuite.Failures
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(testSuites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// writeSARIFReport writes the findings as the results of a SARIF log, for GitHub code scanning. The JSON pointer of
+// a finding is its logical location.
+func writeSARIFReport(w io.Writer, report Report) error {
+	rules := report.rules
+	if rules == nil {
+		rules = DefaultRuleRegistry()
+	}
+	driver := sarifDriver{Name: "ndc-hub-validate", Rules: []sarifRule{}}
+	for _, rule := range rules.Rules() {
+		driver.Rules = append(driver.Rules, sarifRule{ID: rule.ID(), ShortDescription: sarifMessage{Text: rule.Description()}})
+	}
+	driver.Rules = append(driver.Rules, sarifRule{ID: ConnectorConfigRule, ShortDescription: sarifMessage{Text: connectorConfigRuleDescription}})
+
+	results := []sarifResult{}
+	for _, finding := range report.Findings {
+		location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: finding.File}}}
+		if finding.Path != "" {
+			location.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: finding.Path}}
+		}
+		results = append(results, sarifResult{
+			RuleID:    finding.RuleID,
+			Level:     string(finding.Severity),
+			Message:   sarifMessage{Text: finding.Message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
@@ -0,0 +1,132 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testReport() Report {
+	report := Report{}
+	report.AddConnector("hasura/test")
+	report.AddConnector("hasura/other")
+	report.Add(
+		Finding{File: "registry/hasura/test/metadata.json", Path: "/overview/latest_version", RuleID: MetadataLatestVersionRule, Severity: ErrorSeverity, Message: "latest_version mismatch", Connector: "hasura/test"},
+		Finding{File: "registry/hasura/test/releases/v1.0.0/connector-packaging.json", Path: "/version", RuleID: ConnectorPackagingVersionRule, Severity: WarningSeverity, Message: "invalid version", Connector: "hasura/test", Version: "v1.0.0"},
+	)
+	// The findings of the files that are checked several times are only recorded once
+	report.Add(Finding{File: "registry/hasura/test/metadata.json", Path: "/overview/latest_version", RuleID: MetadataLatestVersionRule, Severity: ErrorSeverity, Message: "latest_version mismatch", Connector: "hasura/test"})
+	return report
+}
+
+func TestReport(t *testing.T) {
+	report := testReport()
+	assert.Len(t, report.Findings, 2)
+	assert.True(t, report.HasErrors())
+	assert.False(t, (&Report{Findings: report.Findings[1:]}).HasErrors())
+
+	report = Report{Findings: []Finding{{File: filepath.Join("/repo", "registry", "hasura", "test", "metadata.json")}, {File: "/elsewhere/metadata.json"}}}
+	report.RelativizePaths("/repo")
+	assert.Equal(t, "registry/hasura/test/metadata.json", report.Findings[0].File)
+	assert.Equal(t, "/elsewhere/metadata.json", report.Findings[1].File)
+}
+
+func TestWriteTextReport(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteReport(&buf, testReport(), TextReportFormat))
+	assert.Equal(t, `error: registry/hasura/test/metadata.json#/overview/latest_version: latest_version mismatch [metadata-latest-version]
+warning: registry/hasura/test/releases/v1.0.0/connector-packaging.json#/version: invalid version [connector-packaging-version]
+2 connector(s) validated: 1 error(s), 1 warning(s), 0 note(s), 0 suppressed
+`, buf.String())
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteReport(&buf, testReport(), JSONReportFormat))
+
+	var jsonReport struct {
+		Connectors []string  `json:"connectors"`
+		Findings   []Finding `json:"findings"`
+		Summary    struct {
+			Errors   int `json:"errors"`
+			Warnings int `json:"warnings"`
+		} `json:"summary"`
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &jsonReport))
+	assert.Equal(t, []string{"hasura/test", "hasura/other"}, jsonReport.Connectors)
+	assert.Equal(t, testReport().Findings, jsonReport.Findings)
+	assert.Equal(t, 1, jsonReport.Summary.Errors)
+	assert.Equal(t, 1, jsonReport.Summary.Warnings)
+
+	buf.Reset()
+	assert.NoError(t, WriteReport(&buf, Report{}, JSONReportFormat))
+	assert.Contains(t, buf.String(), `"findings": []`)
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteReport(&buf, testReport(), JUnitReportFormat))
+
+	var testSuites junitTestSuites
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &testSuites))
+	assert.Equal(t, 3, testSuites.Tests)
+	assert.Equal(t, 1, testSuites.Failures)
+	assert.Len(t, testSuites.TestSuites, 2)
+
+	// A connector without findings is a passing test suite
+	assert.Equal(t, "hasura/other", testSuites.TestSuites[0].Name)
+	assert.Equal(t, 1, testSuites.TestSuites[0].Tests)
+	assert.Nil(t, testSuites.TestSuites[0].TestCases[0].Failure)
+
+	testCases := testSuites.TestSuites[1].TestCases
+	assert.Equal(t, "hasura/test", testCases[0].Name)
+	assert.NotNil(t, testCases[0].Failure)
+	assert.Contains(t, testCases[0].Failure.Content, "latest_version mismatch")
+	// The warnings don't fail the test case of the release
+	assert.Equal(t, "hasura/test@v1.0.0", testCases[1].Name)
+	assert.Nil(t, testCases[1].Failure)
+	assert.Contains(t, testCases[1].SystemOut, "invalid version")
+}
+
+func TestWriteSARIFReport(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteReport(&buf, testReport(), SARIFReportFormat))
+
+	var log sarifLog
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Len(t, log.Runs, 1)
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, len(DefaultRules())+1)
+	assert.Equal(t, []sarifResult{
+		{
+			RuleID:  MetadataLatestVersionRule,
+			Level:   "error",
+			Message: sarifMessage{Text: "latest_version mismatch"},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: "registry/hasura/test/metadata.json"}},
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "/overview/latest_version"}},
+			}},
+		},
+		{
+			RuleID:  ConnectorPackagingVersionRule,
+			Level:   "warning",
+			Message: sarifMessage{Text: "invalid version"},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: "registry/hasura/test/releases/v1.0.0/connector-packaging.json"}},
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "/version"}},
+			}},
+		},
+	}, log.Runs[0].Results)
+}
+
+func TestReportFormat(t *testing.T) {
+	for _, format := range []ReportFormat{TextReportFormat, JSONReportFormat, JUnitReportFormat, SARIFReportFormat} {
+		assert.True(t, format.IsValid())
+	}
+	assert.False(t, ReportFormat("xml").IsValid())
+	assert.Error(t, WriteReport(&bytes.Buffer{}, Report{}, ReportFormat("xml")))
+}
@@ -0,0 +1,434 @@
+package validate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+)
+
+// TargetKind is the kind of the targets that a rule checks
+type TargetKind string
+
+const (
+	// The content of a metadata.json file
+	MetadataFileTarget TargetKind = "metadata-file"
+	// The content of a connector-packaging.json file
+	ConnectorPackagingFileTarget TargetKind = "connector-packaging-file"
+	// The content of a test-config.json file
+	TestConfigFileTarget TargetKind = "test-config-file"
+	// A metadata.json file, along with the releases of its connector
+	MetadataTarget TargetKind = "metadata"
+	// A connector-packaging.json file
+	ConnectorPackagingTarget TargetKind = "connector-packaging"
+	// A test-config.json file
+	TestConfigTarget TargetKind = "test-config"
+	// The connector package of a connector-packaging.json file, which is downloaded
+	ConnectorPackageTarget TargetKind = "connector-package"
+	// The packaging spec of the connector package of a connector-packaging.json file
+	PackagingSpecTarget TargetKind = "packaging-spec"
+	// The metadata.json file of an aliased connector, along with the metadata.json file of its parent
+	AliasedConnectorTarget TargetKind = "aliased-connector"
+	// The title of the metadata.json file of a connector or of an aliased connector, along with the titles of the
+	// other connectors of the hub
+	ConnectorTitleTarget TargetKind = "connector-title"
+	// The CLI plugin of the packaging spec of a connector package, whose binaries are downloaded
+	CliPluginTarget TargetKind = "cli-plugin"
+	// The docker images of the packaging spec of a connector package, which are resolved against a registry
+	DockerImageTarget TargetKind = "docker-image"
+)
+
+// Target is what a rule checks, only the fields of the kind of the target are set
+type Target struct {
+	Kind TargetKind
+	// Path is the file that the findings are reported on
+	Path string
+	// Content is the content of the file of the MetadataFile, ConnectorPackagingFile and TestConfigFile targets
+	Content []byte
+
+	// Metadata and Releases are set for the Metadata targets
+	Metadata *ndchub.ConnectorMetadata
+	Releases []ndchub.ConnectorPackaging
+	// ConnectorPackaging is set for the ConnectorPackaging, ConnectorPackage and PackagingSpec targets
+	ConnectorPackaging *ndchub.ConnectorPackaging
+	// TestConfig is set for the TestConfig targets
+	TestConfig *ndchub.TestConfig
+	// PackagingSpec and PackagingSpecYAML are set for the PackagingSpec targets, PackagingSpec is also set for the
+	// CliPlugin and DockerImage targets
+	PackagingSpec     *ndchub.ConnectorMetadataDefinition
+	PackagingSpecYAML []byte
+	// CliPluginManifest is the manifest of the CLI plugins index of the CliPlugin targets, it is nil unless the CLI
+	// plugin is an external binary
+	CliPluginManifest *ndchub.PluginManifest
+	// ImageResolver resolves the docker images of the DockerImage targets
+	ImageResolver ImageResolver
+	// AliasedConnector and Parent are set for the AliasedConnector targets, Parent is nil if the parent connector has
+	// no metadata.json
+	AliasedConnector *ndchub.AliasedConnector
+	Parent           *ndchub.ConnectorMetadata
+	// Title and OtherTitles are set for the ConnectorTitle targets
+	Title       string
+	OtherTitles []ConnectorTitle
+}
+
+// Rule is a check of the registry folder, identified by its ID in the findings and in the .hub-validate.yaml files
+type Rule interface {
+	ID() string
+	Description() string
+	// DefaultSeverity is the severity of the findings of the rule, unless the connector overrides it
+	DefaultSeverity() Severity
+	// Target is the kind of the targets that the rule checks
+	Target() TargetKind
+	// Check returns the findings of the rule for the target
+	Check(target Target) []Finding
+}
+
+// checkRule is a Rule implemented by a check function that returns an error. The schema violations and the invalid
+// fields of a packaging spec are reported one finding each.
+type checkRule struct {
+	id          string
+	description string
+	severity    Severity
+	target      TargetKind
+	// pointer is the JSON pointer of the findings that the check doesn't report a path for
+	pointer string
+	// pointerFile is the file of the JSON pointers reported by the check, when it isn't the file of the target. The
+	// pointers are then moved to the messages of the findings.
+	pointerFile string
+	check       func(target Target) error
+}
+
+func (r *checkRule) ID() string                { return r.id }
+func (r *checkRule) Description() string       { return r.description }
+func (r *checkRule) DefaultSeverity() Severity { return r.severity }
+func (r *checkRule) Target() TargetKind        { return r.target }
+
+func (r *checkRule) Check(target Target) []Finding {
+	findings := errorFindings(target.Path, r.id, r.check(target))
+	for i := range findings {
+		findings[i].Severity = r.severity
+		if r.pointerFile != "" && findings[i].Path != "" {
+			findings[i].Message = fmt.Sprintf("%s (%s in %s)", findings[i].Message, findings[i].Path, r.pointerFile)
+			findings[i].Path = ""
+		}
+		if findings[i].Path == "" {
+			findings[i].Path = r.pointer
+		}
+	}
+	return findings
+}
+
+// RuleRegistry holds the rules that the registry folder is validated with
+type RuleRegistry struct {
+	rules []Rule
+	byID  map[string]Rule
+}
+
+// NewRuleRegistry returns a registry of the rules, the IDs of the rules must be unique
+func NewRuleRegistry(rules ...Rule) (*RuleRegistry, error) {
+	registry := &RuleRegistry{byID: make(map[string]Rule)}
+	for _, rule := range rules {
+		if err := registry.Register(rule); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// Register adds a rule to the registry
+func (r *RuleRegistry) Register(rule Rule) error {
+	if rule.ID() == "" {
+		return fmt.Errorf("the ID of the rule is required")
+	}
+	if _, ok := r.byID[rule.ID()]; ok {
+		return fmt.Errorf("a rule with the ID %q is already registered", rule.ID())
+	}
+	r.rules = append(r.rules, rule)
+	r.byID[rule.ID()] = rule
+	return nil
+}
+
+// Rule returns the rule with the ID
+func (r *RuleRegistry) Rule(id string) (Rule, bool) {
+	rule, ok := r.byID[id]
+	return rule, ok
+}
+
+// Rules returns the rules sorted by ID
+func (r *RuleRegistry) Rules() []Rule {
+	rules := append([]Rule(nil), r.rules...)
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID() < rules[j].ID()
+	})
+	return rules
+}
+
+// rulesFor returns the rules that check the kind of target, in the order they were registered
+func (r *RuleRegistry) rulesFor(kind TargetKind) []Rule {
+	var rules []Rule
+	for _, rule := range r.rules {
+		if rule.Target() == kind {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// The IDs of the default rules
+const (
+	MetadataSchemaRule                    = "metadata-schema"
+	MetadataLatestVersionRule             = "metadata-latest-version"
+	MetadataSourceCodeVersionRule         = "metadata-source-code-version"
+	ConnectorPackagingSchemaRule          = "connector-packaging-schema"
+	ConnectorPackagingVersionRule         = "connector-packaging-version"
+	ConnectorTarballRule                  = "connector-tarball"
+	TestConfigSchemaRule                  = "test-config-schema"
+	TestConfigRule                        = "test-config"
+	PackagingSpecSchemaRule               = "packaging-spec-schema"
+	PackagingSpecRule                     = "packaging-spec"
+	AliasedConnectorParentRule            = "aliased-connector-parent"
+	AliasedConnectorLatestVersionRule     = "aliased-connector-latest-version"
+	AliasedConnectorSourceCodeVersionRule = "aliased-connector-source-code-version"
+	AliasedConnectorFilesRule             = "aliased-connector-files"
+	ConnectorTitleRule                    = "connector-title"
+	CliPluginManifestRule                 = "cli-plugin-manifest"
+	CliPluginBinaryRule                   = "cli-plugin-binary"
+	DockerImageReferenceRule              = "docker-image-reference"
+	DockerImageTagRule                    = "docker-image-tag"
+	ConnectorImageVersionRule             = "connector-image-version"
+	DockerImageDigestRule                 = "docker-image-digest"
+	DockerImagePlatformsRule              = "docker-image-platforms"
+)
+
+// DefaultRules returns the rules that the validate command checks
+func DefaultRules() []Rule {
+	return []Rule{
+		&checkRule{
+			id:          MetadataSchemaRule,
+			description: "metadata.json matches its JSON schema",
+			severity:    ErrorSeverity,
+			target:      MetadataFileTarget,
+			check: func(target Target) error {
+				return MetadataFile(target.Content)
+			},
+		},
+		&checkRule{
+			id:          MetadataLatestVersionRule,
+			description: "The latest_version of metadata.json is the latest stable release of the connector",
+			severity:    ErrorSeverity,
+			target:      MetadataTarget,
+			pointer:     "/overview/latest_version",
+			check: func(target Target) error {
+				var connectorVersions []string
+				for _, release := range target.Releases {
+					connectorVersions = append(connectorVersions, release.Version)
+				}
+				return validateLatestVersion(target.Metadata.Overview.LatestVersion, connectorVersions)
+			},
+		},
+		&checkRule{
+			id:          MetadataSourceCodeVersionRule,
+			description: "The source_code.version entries of metadata.json match the releases of the connector",
+			severity:    WarningSeverity,
+			target:      MetadataTarget,
+			pointer:     "/source_code/version",
+			check: func(target Target) error {
+				return validateSourceCodeVersions(target.Metadata.SourceCode.Version, target.Releases)
+			},
+		},
+		&checkRule{
+			id:          ConnectorPackagingSchemaRule,
+			description: "connector-packaging.json matches its JSON schema",
+			severity:    ErrorSeverity,
+			target:      ConnectorPackagingFileTarget,
+			check: func(target Target) error {
+				return ConnectorPackagingFile(target.Content)
+			},
+		},
+		&checkRule{
+			id:          ConnectorPackagingVersionRule,
+			description: "The version of connector-packaging.json is a semantic version prefixed with v",
+			severity:    ErrorSeverity,
+			target:      ConnectorPackagingTarget,
+			pointer:     "/version",
+			check: func(target Target) error {
+				return checkVersion(target.ConnectorPackaging.Version)
+			},
+		},
+		&checkRule{
+			id:          ConnectorTarballRule,
+			description: "The connector package can be downloaded and matches the checksum of connector-packaging.json",
+			severity:    ErrorSeverity,
+			target:      ConnectorPackageTarget,
+			pointer:     "/checksum",
+			check: func(target Target) error {
+				return checkConnectorTarball(target.ConnectorPackaging)
+			},
+		},
+		&checkRule{
+			id:          TestConfigSchemaRule,
+			description: "test-config.json matches its JSON schema",
+			severity:    ErrorSeverity,
+			target:      TestConfigFileTarget,
+			check: func(target Target) error {
+				return TestConfigFile(target.Content)
+			},
+		},
+		&checkRule{
+			id:          TestConfigRule,
+			description: "The snapshots and the setup compose file of test-config.json exist",
+			severity:    ErrorSeverity,
+			target:      TestConfigTarget,
+			check: func(target Target) error {
+				return TestConfig(target.TestConfig)
+			},
+		},
+		&checkRule{
+			id:          PackagingSpecSchemaRule,
+			pointerFile: pkg.ConnectorMetadataPath,
+			description: "The packaging spec of the connector package matches the JSON schema of connector-metadata.yaml",
+			severity:    ErrorSeverity,
+			target:      PackagingSpecTarget,
+			check: func(target Target) error {
+				return PackagingSpecSchema(target.PackagingSpecYAML)
+			},
+		},
+		&checkRule{
+			id:          PackagingSpecRule,
+			pointerFile: pkg.ConnectorMetadataPath,
+			description: "The packaging spec of the connector package is valid",
+			severity:    ErrorSeverity,
+			target:      PackagingSpecTarget,
+			check: func(target Target) error {
+				return target.PackagingSpec.Validate()
+			},
+		},
+		&checkRule{
+			id:          AliasedConnectorParentRule,
+			description: "The aliased connector is in the aliased_connectors folder of a connector of the hub",
+			severity:    ErrorSeverity,
+			target:      AliasedConnectorTarget,
+			check: func(target Target) error {
+				return validateAliasParent(target.Parent)
+			},
+		},
+		&checkRule{
+			id:          AliasedConnectorLatestVersionRule,
+			description: "The latest_version of the aliased connector is the latest_version of its parent",
+			severity:    ErrorSeverity,
+			target:      AliasedConnectorTarget,
+			pointer:     "/overview/latest_version",
+			check: func(target Target) error {
+				return validateAliasLatestVersion(target.AliasedConnector, target.Parent)
+			},
+		},
+		&checkRule{
+			id:          AliasedConnectorSourceCodeVersionRule,
+			description: "The source_code.version entries of the aliased connector are the entries of its parent",
+			severity:    ErrorSeverity,
+			target:      AliasedConnectorTarget,
+			pointer:     "/source_code/version",
+			check: func(target Target) error {
+				return validateAliasSourceCodeVersions(target.AliasedConnector, target.Parent)
+			},
+		},
+		&checkRule{
+			id:          AliasedConnectorFilesRule,
+			description: "The aliased connector has its own logo and README",
+			severity:    ErrorSeverity,
+			target:      AliasedConnectorTarget,
+			check: func(target Target) error {
+				return validateAliasFiles(target.AliasedConnector)
+			},
+		},
+		&checkRule{
+			id:          ConnectorTitleRule,
+			description: "The title of the connector is not the title of another connector or aliased connector of the hub",
+			severity:    ErrorSeverity,
+			target:      ConnectorTitleTarget,
+			pointer:     "/overview/title",
+			check: func(target Target) error {
+				return validateUniqueTitle(target.Title, target.OtherTitles)
+			},
+		},
+		&checkRule{
+			id:          DockerImageReferenceRule,
+			pointerFile: pkg.ConnectorMetadataPath,
+			description: "The docker images of the packaging spec are valid image references",
+			severity:    ErrorSeverity,
+			target:      PackagingSpecTarget,
+			check: func(target Target) error {
+				return validateDockerImageReferences(target.PackagingSpec)
+			},
+		},
+		&checkRule{
+			id:          DockerImageTagRule,
+			pointerFile: pkg.ConnectorMetadataPath,
+			description: "The docker images of the packaging spec are not referenced by a mutable tag like latest",
+			severity:    WarningSeverity,
+			target:      PackagingSpecTarget,
+			check: func(target Target) error {
+				return validateDockerImageTags(target.PackagingSpec)
+			},
+		},
+		&checkRule{
+			id:          ConnectorImageVersionRule,
+			pointerFile: pkg.ConnectorMetadataPath,
+			description: "The tag of the docker image of the connector is the version of the release",
+			severity:    ErrorSeverity,
+			target:      PackagingSpecTarget,
+			check: func(target Target) error {
+				return validateConnectorImageVersion(target.PackagingSpec, target.ConnectorPackaging.Version)
+			},
+		},
+		&checkRule{
+			id:          DockerImageDigestRule,
+			pointerFile: pkg.ConnectorMetadataPath,
+			description: "The docker images of the packaging spec can be resolved to a digest in their registry",
+			severity:    ErrorSeverity,
+			target:      DockerImageTarget,
+			check: func(target Target) error {
+				return validateDockerImageDigests(target.PackagingSpec, target.ImageResolver)
+			},
+		},
+		&checkRule{
+			id:          DockerImagePlatformsRule,
+			pointerFile: pkg.ConnectorMetadataPath,
+			description: "The docker images of the packaging spec are available for linux/amd64 and linux/arm64",
+			severity:    ErrorSeverity,
+			target:      DockerImageTarget,
+			check: func(target Target) error {
+				return validateDockerImagePlatforms(target.PackagingSpec, target.ImageResolver)
+			},
+		},
+		&checkRule{
+			id:          CliPluginManifestRule,
+			pointerFile: "the manifest of the CLI plugins index",
+			description: "The manifest of the external CLI plugin matches the packaging spec and provides a binary for every platform",
+			severity:    ErrorSeverity,
+			target:      CliPluginTarget,
+			check: func(target Target) error {
+				return validateCliPluginManifest(target.PackagingSpec, target.CliPluginManifest)
+			},
+		},
+		&checkRule{
+			id:          CliPluginBinaryRule,
+			description: "The binaries of the CLI plugin match their sha256, and contain their bin and files entries",
+			severity:    ErrorSeverity,
+			target:      CliPluginTarget,
+			check: func(target Target) error {
+				return validateCliPluginBinaries(cliPluginBinaries(target.PackagingSpec, target.CliPluginManifest))
+			},
+		},
+	}
+}
+
+// DefaultRuleRegistry returns the registry of the default rules
+func DefaultRuleRegistry() *RuleRegistry {
+	registry, err := NewRuleRegistry(DefaultRules()...)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default rules: %v", err))
+	}
+	return registry
+}
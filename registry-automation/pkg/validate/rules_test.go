@@ -0,0 +1,77 @@
+package validate
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleRegistry(t *testing.T) {
+	registry := DefaultRuleRegistry()
+	rule, ok := registry.Rule(MetadataLatestVersionRule)
+	assert.True(t, ok)
+	assert.Equal(t, MetadataTarget, rule.Target())
+	assert.Len(t, registry.Rules(), len(DefaultRules()))
+
+	// The IDs of the rules are unique
+	err := registry.Register(&checkRule{id: MetadataLatestVersionRule})
+	assert.ErrorContains(t, err, "already registered")
+	_, err = NewRuleRegistry(&checkRule{})
+	assert.Error(t, err)
+}
+
+func TestValidatorWithCustomRule(t *testing.T) {
+	// A custom rule is checked along with the default rules of its kind of target
+	rules := DefaultRuleRegistry()
+	assert.NoError(t, rules.Register(&checkRule{
+		id:          "connector-packaging-github-release",
+		description: "The connector package is a GitHub release asset",
+		severity:    WarningSeverity,
+		target:      ConnectorPackagingTarget,
+		pointer:     "/uri",
+		check: func(target Target) error {
+			return fmt.Errorf("%s is not a GitHub release asset", target.ConnectorPackaging.URI)
+		},
+	}))
+
+	validator := NewValidator(rules)
+	cp := &ndchub.ConnectorPackaging{Namespace: "hasura", Name: "test", Path: "connector-packaging.json", Version: "1.0.0", URI: "https://example.com/package.tar.gz"}
+	findings := validator.CheckConnectorPackaging(cp, false)
+	assert.Equal(t, []Finding{
+		{File: "connector-packaging.json", Path: "/version", RuleID: ConnectorPackagingVersionRule, Severity: ErrorSeverity, Message: "version must start with 'v': but got 1.0.0", Connector: "hasura/test", Version: "1.0.0"},
+		{File: "connector-packaging.json", Path: "/uri", RuleID: "connector-packaging-github-release", Severity: WarningSeverity, Message: "https://example.com/package.tar.gz is not a GitHub release asset", Connector: "hasura/test", Version: "1.0.0"},
+	}, findings)
+}
+
+func TestValidatorWithConnectorConfig(t *testing.T) {
+	validator := NewValidator(DefaultRuleRegistry())
+	validator.LoadConnectorConfig("hasura/test", writeConnectorConfig(t, `overrides:
+  - rule: connector-packaging-version
+    severity: warning
+    versions: [1.0.0]
+    reason: Published before the versions were prefixed with v
+`))
+	validator.LoadConnectorConfig("hasura/invalid", writeConnectorConfig(t, `overrides:
+  - rule: unknown-rule
+    severity: "off"
+    reason: Invalid config
+`))
+
+	validator.CheckConnectorPackaging(&ndchub.ConnectorPackaging{Namespace: "hasura", Name: "test", Path: "test.json", Version: "1.0.0"}, false)
+	validator.CheckConnectorPackaging(&ndchub.ConnectorPackaging{Namespace: "hasura", Name: "test", Path: "test.json", Version: "1.1.0"}, false)
+	validator.CheckConnectorPackaging(&ndchub.ConnectorPackaging{Namespace: "hasura", Name: "invalid", Path: "invalid.json", Version: "1.0.0"}, false)
+
+	report := validator.Report()
+	var severities []string
+	for _, finding := range report.Findings {
+		severities = append(severities, fmt.Sprintf("%s %s %s %s", finding.Connector, finding.Version, finding.RuleID, finding.Severity))
+	}
+	assert.Equal(t, []string{
+		"hasura/invalid  connector-config error",
+		"hasura/test 1.0.0 connector-packaging-version warning",
+		"hasura/test 1.1.0 connector-packaging-version error",
+		"hasura/invalid 1.0.0 connector-packaging-version error",
+	}, severities)
+}
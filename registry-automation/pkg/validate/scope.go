@@ -0,0 +1,86 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope restricts the validation to some connectors of the registry folder, e.g. the connectors changed by a PR.
+// The checks of a connector that span several files, like latest_version, run with every release of the connector,
+// but only the connector packages of the releases in scope are downloaded. A nil Scope includes every connector.
+type Scope struct {
+	connectors map[string]*connectorScope
+}
+
+type connectorScope struct {
+	allVersions bool
+	versions    map[string]bool
+}
+
+// NewScope returns an empty scope, that includes no connector
+func NewScope() *Scope {
+	return &Scope{connectors: make(map[string]*connectorScope)}
+}
+
+func (s *Scope) connector(connector string) *connectorScope {
+	cs, ok := s.connectors[connector]
+	if !ok {
+		cs = &connectorScope{versions: make(map[string]bool)}
+		s.connectors[connector] = cs
+	}
+	return cs
+}
+
+// IncludeConnector includes the files of the connector (namespace/name), without any of its releases
+func (s *Scope) IncludeConnector(connector string) {
+	s.connector(connector)
+}
+
+// IncludeVersion includes the files of the connector and the release of the connector
+func (s *Scope) IncludeVersion(connector, version string) {
+	s.connector(connector).versions[version] = true
+}
+
+// IncludeAllVersions includes the files of the connector and every release of the connector
+func (s *Scope) IncludeAllVersions(connector string) {
+	s.connector(connector).allVersions = true
+}
+
+// IncludesConnector reports whether the files of the connector are validated
+func (s *Scope) IncludesConnector(connector string) bool {
+	if s == nil {
+		return true
+	}
+	_, ok := s.connectors[connector]
+	return ok
+}
+
+// IncludesVersion reports whether the connector package of the release of the connector is validated
+func (s *Scope) IncludesVersion(connector, version string) bool {
+	if s == nil {
+		return true
+	}
+	cs, ok := s.connectors[connector]
+	return ok && (cs.allVersions || cs.versions[version])
+}
+
+// Connectors returns the number of connectors in scope
+func (s *Scope) Connectors() int {
+	return len(s.connectors)
+}
+
+// IncludeConnectorRef includes a connector reference of the form namespace/name, with every release of the
+// connector, or namespace/name@version with a single release
+func (s *Scope) IncludeConnectorRef(ref string) error {
+	connector, version, hasVersion := strings.Cut(ref, "@")
+	namespace, name, ok := strings.Cut(connector, "/")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, "/") || (hasVersion && version == "") {
+		return fmt.Errorf("invalid connector %q, expected namespace/name or namespace/name@version", ref)
+	}
+	if hasVersion {
+		s.IncludeVersion(connector, version)
+	} else {
+		s.IncludeAllVersions(connector)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScope(t *testing.T) {
+	// A nil scope includes every connector and every release
+	var all *Scope
+	assert.True(t, all.IncludesConnector("hasura/postgres"))
+	assert.True(t, all.IncludesVersion("hasura/postgres", "v1.0.0"))
+
+	scope := NewScope()
+	scope.IncludeConnector("hasura/mysql")
+	scope.IncludeVersion("hasura/postgres", "v1.0.0")
+	scope.IncludeAllVersions("hasura/mongodb")
+
+	assert.Equal(t, 3, scope.Connectors())
+	assert.True(t, scope.IncludesConnector("hasura/mysql"))
+	assert.False(t, scope.IncludesVersion("hasura/mysql", "v1.0.0"))
+	assert.True(t, scope.IncludesVersion("hasura/postgres", "v1.0.0"))
+	assert.False(t, scope.IncludesVersion("hasura/postgres", "v1.1.0"))
+	assert.True(t, scope.IncludesVersion("hasura/mongodb", "v1.1.0"))
+	assert.False(t, scope.IncludesConnector("hasura/turso"))
+	assert.False(t, scope.IncludesVersion("hasura/turso", "v1.0.0"))
+}
+
+func TestScopeIncludeConnectorRef(t *testing.T) {
+	testCases := []struct {
+		ref         string
+		wantErr     bool
+		connector   string
+		version     string
+		allVersions bool
+	}{
+		{ref: "hasura/postgres", connector: "hasura/postgres", version: "v1.0.0", allVersions: true},
+		{ref: "hasura/postgres@v1.0.0", connector: "hasura/postgres", version: "v1.0.0"},
+		{ref: "postgres", wantErr: true},
+		{ref: "hasura/", wantErr: true},
+		{ref: "/postgres", wantErr: true},
+		{ref: "hasura/postgres/v1.0.0", wantErr: true},
+		{ref: "hasura/postgres@", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ref, func(t *testing.T) {
+			scope := NewScope()
+			err := scope.IncludeConnectorRef(tc.ref)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, 0, scope.Connectors())
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, scope.IncludesConnector(tc.connector))
+			assert.True(t, scope.IncludesVersion(tc.connector, tc.version))
+			assert.Equal(t, tc.allVersions, scope.IncludesVersion(tc.connector, "v9.9.9"))
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package validate
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg"
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+)
+
+// Validator checks the files of the registry folder with the rules of a registry, and records the findings in a
+// report. The severity of the findings is overridden by the .hub-validate.yaml files of the connectors.
+type Validator struct {
+	rules   *RuleRegistry
+	configs map[string]*ConnectorConfig
+	report  Report
+	// images are the inspections of the docker images, by reference, so that each image is inspected once
+	images map[string]imageInspection
+}
+
+// NewValidator returns a validator of the rules
+func NewValidator(rules *RuleRegistry) *Validator {
+	return &Validator{
+		rules:   rules,
+		configs: make(map[string]*ConnectorConfig),
+		report:  Report{rules: rules},
+		images:  make(map[string]imageInspection),
+	}
+}
+
+// Report returns the findings recorded so far
+func (v *Validator) Report() Report {
+	return v.report
+}
+
+// AddConnector records a validated connector (namespace/name)
+func (v *Validator) AddConnector(connector string) {
+	v.report.AddConnector(connector)
+}
+
+// LoadConnectorConfig reads the .hub-validate.yaml file of the connector, an invalid file is reported as an error
+// finding and none of its overrides apply
+func (v *Validator) LoadConnectorConfig(connector, path string) {
+	config, err := LoadConnectorConfig(path, v.rules)
+	if err != nil {
+		findings := errorFindings(path, ConnectorConfigRule, err)
+		for i := range findings {
+			findings[i].Connector = connector
+		}
+		v.report.Add(findings...)
+		return
+	}
+	v.configs[connector] = config
+}
+
+// Check runs the rules of the kind of the target, and records their findings with the connector and the release
+// of the target. The findings are returned with their overridden severity, the suppressed findings are not returned.
+func (v *Validator) Check(target Target, connector, version string) []Finding {
+	var findings []Finding
+	for _, rule := range v.rules.rulesFor(target.Kind) {
+		findings = append(findings, rule.Check(target)...)
+	}
+	return v.record(findings, connector, version)
+}
+
+// record applies the overrides of the connector to the findings, and records them
+func (v *Validator) record(findings []Finding, connector, version string) []Finding {
+	var recorded []Finding
+	for _, finding := range findings {
+		finding.Connector = connector
+		finding.Version = version
+		if !v.configs[connector].apply(&finding) {
+			v.report.addSuppressed(finding)
+			continue
+		}
+		recorded = append(recorded, finding)
+	}
+	v.report.Add(recorded...)
+	return recorded
+}
+
+// CheckMetadataFile checks the content of a metadata.json file
+func (v *Validator) CheckMetadataFile(path string, content []byte, connector string) []Finding {
+	return v.Check(Target{Kind: MetadataFileTarget, Path: path, Content: content}, connector, "")
+}
+
+// CheckConnectorPackagingFile checks the content of a connector-packaging.json file
+func (v *Validator) CheckConnectorPackagingFile(path string, content []byte, connector, version string) []Finding {
+	return v.Check(Target{Kind: ConnectorPackagingFileTarget, Path: path, Content: content}, connector, version)
+}
+
+// CheckMetadata checks the metadata.json file at path against the releases of its connector
+func (v *Validator) CheckMetadata(path string, cm *ndchub.ConnectorMetadata, connPkgs []ndchub.ConnectorPackaging) []Finding {
+	target := Target{Kind: MetadataTarget, Path: path, Metadata: cm, Releases: connectorReleases(cm, connPkgs)}
+	return v.Check(target, cm.Overview.Namespace+"/"+cm.Overview.Name, "")
+}
+
+// CheckAliasedConnector checks an aliased connector against the metadata.json of its parent, which is nil if the
+// parent connector has no metadata.json
+func (v *Validator) CheckAliasedConnector(ac *ndchub.AliasedConnector, parent *ndchub.ConnectorMetadata) []Finding {
+	target := Target{Kind: AliasedConnectorTarget, Path: ac.Path, AliasedConnector: ac, Parent: parent}
+	return v.Check(target, ac.Namespace+"/"+ac.Name, "")
+}
+
+// CheckTitle checks that the title of the metadata.json file at path is not the title of another connector of the hub
+func (v *Validator) CheckTitle(path, connector, title string, titles []ConnectorTitle) []Finding {
+	var otherTitles []ConnectorTitle
+	for _, other := range titles {
+		if other.Connector != connector {
+			otherTitles = append(otherTitles, other)
+		}
+	}
+	return v.Check(Target{Kind: ConnectorTitleTarget, Path: path, Title: title, OtherTitles: otherTitles}, connector, "")
+}
+
+// CheckConnectorPackaging checks a connector-packaging.json file and its test config, and its connector package if
+// downloadPackage is set
+func (v *Validator) CheckConnectorPackaging(cp *ndchub.ConnectorPackaging, downloadPackage bool) []Finding {
+	connector := cp.Namespace + "/" + cp.Name
+	findings := v.Check(Target{Kind: ConnectorPackagingTarget, Path: cp.Path, ConnectorPackaging: cp}, connector, cp.Version)
+	if downloadPackage {
+		findings = append(findings, v.Check(Target{Kind: ConnectorPackageTarget, Path: cp.Path, ConnectorPackaging: cp}, connector, cp.Version)...)
+	}
+	// The test config is usually shared by the releases of the connector, its findings are reported on the connector
+	if cp.Test.TestConfigPath != "" {
+		findings = append(findings, v.checkTestConfig(cp.GetTestConfigPath(), connector)...)
+	}
+	return findings
+}
+
+func (v *Validator) checkTestConfig(testConfigPath, connector string) []Finding {
+	testConfigContent, err := os.ReadFile(testConfigPath)
+	if err != nil {
+		return v.recordError(testConfigPath, TestConfigRule, err, connector, "")
+	}
+	if findings := v.Check(Target{Kind: TestConfigFileTarget, Path: testConfigPath, Content: testConfigContent}, connector, ""); len(findings) > 0 {
+		return findings
+	}
+	testConfig, err := ndchub.GetTestConfig(testConfigPath)
+	if err != nil {
+		return v.recordError(testConfigPath, TestConfigRule, err, connector, "")
+	}
+	return v.Check(Target{Kind: TestConfigTarget, Path: testConfigPath, TestConfig: testConfig}, connector, "")
+}
+
+// PackagingSpecOptions are the optional checks of the packaging specs, which download more than the connector package
+type PackagingSpecOptions struct {
+	// CliPlugin downloads and checks the binaries of the CLI plugins
+	CliPlugin bool
+	// ImageResolver resolves the docker images to digests and checks their platforms, the docker images are not
+	// resolved if nil
+	ImageResolver ImageResolver
+}
+
+// CheckPackagingSpec downloads the connector package of a connector-packaging.json file, and checks its packaging
+// spec along with the optional checks. The findings are reported on connector-packaging.json, the file that
+// references the connector package.
+func (v *Validator) CheckPackagingSpec(cp *ndchub.ConnectorPackaging, options PackagingSpecOptions) []Finding {
+	connector := cp.Namespace + "/" + cp.Name
+	packagingSpec, tgzPath, _, err := ndchub.GetPackagingSpec(cp.URI, cp.Checksum, cp.Namespace, cp.Name, cp.Version)
+	if err != nil {
+		return v.recordError(cp.Path, PackagingSpecRule, fmt.Errorf("error getting packaging spec for %s: %w", cp.URI, err), connector, cp.Version)
+	}
+	// The packaging spec is read from the archive, a symlink of the extracted package could point outside of it
+	connectorMetadataYAML, err := pkg.ReadFileFromTarGz(tgzPath, pkg.ConnectorMetadataPath, pkg.DefaultExtractLimits)
+	if err != nil {
+		return v.recordError(cp.Path, PackagingSpecSchemaRule, fmt.Errorf("error reading packaging spec: %w", err), connector, cp.Version)
+	}
+
+	target := Target{Kind: PackagingSpecTarget, Path: cp.Path, ConnectorPackaging: cp, PackagingSpec: packagingSpec, PackagingSpecYAML: connectorMetadataYAML}
+	findings := v.Check(target, connector, cp.Version)
+	if options.ImageResolver != nil {
+		findings = append(findings, v.CheckDockerImages(cp, packagingSpec, options.ImageResolver)...)
+	}
+	if options.CliPlugin {
+		findings = append(findings, v.CheckCliPlugin(cp, packagingSpec)...)
+	}
+	return findings
+}
+
+// CheckDockerImages inspects the docker images of a packaging spec with the resolver, and checks that they are
+// available for the required platforms. Each image is inspected once per validator.
+func (v *Validator) CheckDockerImages(cp *ndchub.ConnectorPackaging, packagingSpec *ndchub.ConnectorMetadataDefinition, resolver ImageResolver) []Finding {
+	resolver = cachedImageResolver{resolver: resolver, inspections: v.images}
+	target := Target{Kind: DockerImageTarget, Path: cp.Path, ConnectorPackaging: cp, PackagingSpec: packagingSpec, ImageResolver: resolver}
+	return v.Check(target, cp.Namespace+"/"+cp.Name, cp.Version)
+}
+
+// CheckCliPlugin downloads the binaries of the CLI plugin of a packaging spec, along with the manifest of the CLI
+// plugins index of an external binary, and checks them. The Docker CLI plugins are not checked.
+func (v *Validator) CheckCliPlugin(cp *ndchub.ConnectorPackaging, packagingSpec *ndchub.ConnectorMetadataDefinition) []Finding {
+	if packagingSpec.CliPlugin == nil {
+		return nil
+	}
+	connector := cp.Namespace + "/" + cp.Name
+	target := Target{Kind: CliPluginTarget, Path: cp.Path, ConnectorPackaging: cp, PackagingSpec: packagingSpec}
+	switch packagingSpec.CliPlugin.Kind() {
+	case ndchub.BinaryPluginType:
+		manifest, err := ndchub.DownloadPluginsManifest(ndchub.WithConnectorMetadata(packagingSpec))
+		if err != nil {
+			return v.recordError(cp.Path, CliPluginManifestRule, err, connector, cp.Version)
+		}
+		target.CliPluginManifest = manifest
+	case ndchub.BinaryInlinePluginType:
+	default:
+		return nil
+	}
+	return v.Check(target, connector, cp.Version)
+}
+
+// recordError records the error of a target that can't be checked, as a finding of the rule that would check it
+func (v *Validator) recordError(path, ruleID string, err error, connector, version string) []Finding {
+	findings := errorFindings(path, ruleID, err)
+	if rule, ok := v.rules.Rule(ruleID); ok {
+		for i := range findings {
+			findings[i].Severity = rule.DefaultSeverity()
+		}
+	}
+	return v.record(findings, connector, version)
+}
@@ -0,0 +1,106 @@
+package vulnerabilityscan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GrypeScanner scans the artifacts with a locally installed grype binary. Grype only reports vulnerabilities.
+type GrypeScanner struct {
+	// Binary is the path of the grype binary, grype from the PATH if empty
+	Binary string
+	// Severities are the severities of the reported findings, DefaultSeverities if empty
+	Severities []Severity
+
+	run commandRunner
+}
+
+func (s *GrypeScanner) Name() string {
+	return GrypeScannerName
+}
+
+func (s *GrypeScanner) ScanImage(ctx context.Context, image string) (Result, error) {
+	return s.scan(ctx, ImageTarget, image, image)
+}
+
+func (s *GrypeScanner) ScanDir(ctx context.Context, dir string) (Result, error) {
+	return s.scan(ctx, DirTarget, dir, "dir:"+dir)
+}
+
+func (s *GrypeScanner) scan(ctx context.Context, kind TargetKind, target string, source string) (Result, error) {
+	binary := s.Binary
+	if binary == "" {
+		binary = "grype"
+	}
+	run := s.run
+	if run == nil {
+		run = runCommand
+	}
+
+	result := Result{Scanner: s.Name(), Kind: kind, Target: target}
+	out, err := run(ctx, binary, source, "--output", "json", "--quiet")
+	if err != nil {
+		return result, err
+	}
+	findings, err := parseGrypeReport(out)
+	if err != nil {
+		return result, err
+	}
+	result.Findings = filterSeverities(findings, s.Severities)
+	return result, nil
+}
+
+// grypeReport is the JSON report of Grype, with the fields of the findings that are reported
+type grypeReport struct {
+	Matches []struct {
+		Vulnerability struct {
+			ID          string `json:"id"`
+			Severity    string `json:"severity"`
+			Description string `json:"description"`
+			Fix         struct {
+				Versions []string `json:"versions"`
+			} `json:"fix"`
+		} `json:"vulnerability"`
+		Artifact struct {
+			Name      string `json:"name"`
+			Version   string `json:"version"`
+			Locations []struct {
+				Path string `json:"path"`
+			} `json:"locations"`
+		} `json:"artifact"`
+	} `json:"matches"`
+}
+
+func parseGrypeReport(out []byte) ([]Finding, error) {
+	var report grypeReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("error decoding the grype report: %w", err)
+	}
+
+	findings := make([]Finding, 0, len(report.Matches))
+	for _, match := range report.Matches {
+		finding := Finding{
+			Class:            "vulnerability",
+			ID:               match.Vulnerability.ID,
+			Severity:         normalizeSeverity(match.Vulnerability.Severity),
+			Package:          match.Artifact.Name,
+			InstalledVersion: match.Artifact.Version,
+			FixedVersion:     strings.Join(match.Vulnerability.Fix.Versions, ", "),
+			Title:            match.Vulnerability.Description,
+		}
+		// Grype reports the severities in title case, with a Negligible severity below Low
+		switch finding.Severity {
+		case "NEGLIGIBLE":
+			finding.Severity = LowSeverity
+		case "":
+			finding.Severity = UnknownSeverity
+		}
+		if len(match.Artifact.Locations) > 0 {
+			finding.Location = match.Artifact.Locations[0].Path
+		}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
@@ -0,0 +1,289 @@
+// Package vulnerabilityscan scans the artifacts of the connectors, their docker images and the binaries of their CLI
+// plugins, for vulnerabilities with a pluggable scanner: a locally installed Trivy, the dockerised Trivy, or Grype.
+package vulnerabilityscan
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+)
+
+// Severity is the severity of a finding, normalized to the upper case severities of Trivy
+type Severity string
+
+const (
+	CriticalSeverity Severity = "CRITICAL"
+	HighSeverity     Severity = "HIGH"
+	MediumSeverity   Severity = "MEDIUM"
+	LowSeverity      Severity = "LOW"
+	UnknownSeverity  Severity = "UNKNOWN"
+)
+
+// DefaultSeverities are the severities of the findings that are reported by default
+var DefaultSeverities = []Severity{CriticalSeverity, HighSeverity}
+
+// ParseSeverities parses a comma separated list of severities, e.g. CRITICAL,HIGH
+func ParseSeverities(s string) ([]Severity, error) {
+	var severities []Severity
+	for _, part := range strings.Split(s, ",") {
+		severity := normalizeSeverity(part)
+		switch severity {
+		case CriticalSeverity, HighSeverity, MediumSeverity, LowSeverity, UnknownSeverity:
+			severities = append(severities, severity)
+		default:
+			return nil, fmt.Errorf("invalid severity %q", strings.TrimSpace(part))
+		}
+	}
+	return severities, nil
+}
+
+func normalizeSeverity(s string) Severity {
+	return Severity(strings.ToUpper(strings.TrimSpace(s)))
+}
+
+// TargetKind is the kind of artifact that is scanned
+type TargetKind string
+
+const (
+	// ImageTarget is a docker image, scanned from its registry or the local docker daemon
+	ImageTarget TargetKind = "image"
+	// DirTarget is a directory of the local filesystem, e.g. the downloaded binaries of a CLI plugin
+	DirTarget TargetKind = "dir"
+)
+
+// Finding is an issue found in a scanned artifact, a vulnerability for every scanner, or a misconfiguration, a secret
+// or a license for Trivy
+type Finding struct {
+	// Class is the kind of issue, e.g. vulnerability or secret
+	Class    string   `json:"class"`
+	ID       string   `json:"id"`
+	Severity Severity `json:"severity"`
+	// Package, InstalledVersion and FixedVersion are set for the vulnerabilities, FixedVersion is empty if there is
+	// no fix
+	Package          string `json:"package,omitempty"`
+	InstalledVersion string `json:"installed_version,omitempty"`
+	FixedVersion     string `json:"fixed_version,omitempty"`
+	Title            string `json:"title,omitempty"`
+	// Location is where the issue is in the artifact, e.g. the file or the OS packages of an image
+	Location string `json:"location,omitempty"`
+}
+
+// Result is the result of scanning an artifact
+type Result struct {
+	Scanner string     `json:"scanner"`
+	Kind    TargetKind `json:"kind"`
+	Target  string     `json:"target"`
+	// Connector and Version are the connector release of the artifact, they are set by ScanArtifacts
+	Connector string    `json:"connector,omitempty"`
+	Version   string    `json:"version,omitempty"`
+	Findings  []Finding `json:"findings"`
+}
+
+// Scanner scans the artifacts of the connectors. The findings are returned, a scanner only fails when the artifact
+// can't be scanned.
+type Scanner interface {
+	// Name is the name of the scanner, as selected with NewScanner
+	Name() string
+	ScanImage(ctx context.Context, image string) (Result, error)
+	ScanDir(ctx context.Context, dir string) (Result, error)
+}
+
+const (
+	TrivyScannerName       = "trivy"
+	DockerTrivyScannerName = "trivy-docker"
+	GrypeScannerName       = "grype"
+)
+
+// ScannerNames are the names of the scanners of NewScanner
+var ScannerNames = []string{TrivyScannerName, DockerTrivyScannerName, GrypeScannerName}
+
+// NewScanner returns the scanner with the name, which reports the findings of the severities, DefaultSeverities if
+// empty
+func NewScanner(name string, severities []Severity) (Scanner, error) {
+	switch name {
+	case TrivyScannerName:
+		return &TrivyScanner{Severities: severities}, nil
+	case DockerTrivyScannerName:
+		return &DockerTrivyScanner{Severities: severities}, nil
+	case GrypeScannerName:
+		return &GrypeScanner{Severities: severities}, nil
+	default:
+		return nil, fmt.Errorf("unknown scanner %q, expected one of %s", name, strings.Join(ScannerNames, ", "))
+	}
+}
+
+// ScanError is the failure of scanning an artifact
+type ScanError struct {
+	Kind      TargetKind
+	Target    string
+	Connector string
+	Version   string
+	Err       error
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("%s %s: error scanning the %s %s: %v", e.Connector, e.Version, e.Kind, e.Target, e.Err)
+}
+
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+// Report is the result of scanning the artifacts of several connectors with a scanner
+type Report struct {
+	Scanner string
+	Results []Result
+	// Errors are the artifacts that couldn't be scanned
+	Errors []*ScanError
+}
+
+// HasFindings returns true if a finding was reported in any of the artifacts
+func (r *Report) HasFindings() bool {
+	for _, result := range r.Results {
+		if len(result.Findings) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Failed returns true if a finding was reported, or if an artifact couldn't be scanned
+func (r *Report) Failed() bool {
+	return r.HasFindings() || len(r.Errors) > 0
+}
+
+// ScanArtifacts scans the docker images and the artifacts directory of each connector release. Every artifact is
+// scanned, the artifacts that can't be scanned are reported in the errors of the report.
+func ScanArtifacts(ctx context.Context, scanner Scanner, artifacts []*ndchub.ConnectorArtifacts) *Report {
+	report := &Report{Scanner: scanner.Name()}
+	for _, artifact := range artifacts {
+		if artifact == nil {
+			continue
+		}
+		for _, dockerImage := range artifact.DockerImages {
+			if dockerImage == "" {
+				continue
+			}
+			log.Printf("Scanning the docker image %s of %s %s with %s\n", dockerImage, artifact.Connector, artifact.Version, scanner.Name())
+			result, err := scanner.ScanImage(ctx, dockerImage)
+			report.add(artifact, ImageTarget, dockerImage, result, err)
+		}
+		if artifact.ArtifactsDirPath != "" {
+			log.Printf("Scanning the artifacts directory %s of %s %s with %s\n", artifact.ArtifactsDirPath, artifact.Connector, artifact.Version, scanner.Name())
+			result, err := scanner.ScanDir(ctx, artifact.ArtifactsDirPath)
+			report.add(artifact, DirTarget, artifact.ArtifactsDirPath, result, err)
+		}
+	}
+	return report
+}
+
+func (r *Report) add(artifact *ndchub.ConnectorArtifacts, kind TargetKind, target string, result Result, err error) {
+	if err != nil {
+		r.Errors = append(r.Errors, &ScanError{Kind: kind, Target: target, Connector: artifact.Connector, Version: artifact.Version, Err: err})
+		return
+	}
+	result.Connector = artifact.Connector
+	result.Version = artifact.Version
+	r.Results = append(r.Results, result)
+}
+
+// WriteReport writes the findings of the report grouped by artifact, followed by the artifacts that couldn't be
+// scanned
+func WriteReport(w io.Writer, report *Report) error {
+	var sb strings.Builder
+	for _, result := range report.Results {
+		fmt.Fprintf(&sb, "%s %s: %s %s: %d finding(s)\n", result.Connector, result.Version, result.Kind, result.Target, len(result.Findings))
+		for _, finding := range sortedFindings(result.Findings) {
+			fmt.Fprintf(&sb, "  - [%s] %s %s", finding.Severity, finding.Class, finding.ID)
+			if finding.Package != "" {
+				fmt.Fprintf(&sb, " in %s %s", finding.Package, finding.InstalledVersion)
+				if finding.FixedVersion != "" {
+					fmt.Fprintf(&sb, " (fixed in %s)", finding.FixedVersion)
+				}
+			}
+			if finding.Location != "" {
+				fmt.Fprintf(&sb, " at %s", finding.Location)
+			}
+			if finding.Title != "" {
+				fmt.Fprintf(&sb, ": %s", finding.Title)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	for _, err := range report.Errors {
+		fmt.Fprintf(&sb, "%s\n", err.Error())
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// severityRanks orders the findings from the most severe
+var severityRanks = map[Severity]int{
+	CriticalSeverity: 0,
+	HighSeverity:     1,
+	MediumSeverity:   2,
+	LowSeverity:      3,
+	UnknownSeverity:  4,
+}
+
+func sortedFindings(findings []Finding) []Finding {
+	sorted := make([]Finding, len(findings))
+	copy(sorted, findings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if severityRanks[sorted[i].Severity] != severityRanks[sorted[j].Severity] {
+			return severityRanks[sorted[i].Severity] < severityRanks[sorted[j].Severity]
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+// filterSeverities returns the findings of the severities, DefaultSeverities if empty
+func filterSeverities(findings []Finding, severities []Severity) []Finding {
+	if len(severities) == 0 {
+		severities = DefaultSeverities
+	}
+	filtered := make([]Finding, 0, len(findings))
+	for _, finding := range findings {
+		for _, severity := range severities {
+			if finding.Severity == severity {
+				filtered = append(filtered, finding)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func joinSeverities(severities []Severity) string {
+	if len(severities) == 0 {
+		severities = DefaultSeverities
+	}
+	names := make([]string, len(severities))
+	for i, severity := range severities {
+		names[i] = string(severity)
+	}
+	return strings.Join(names, ",")
+}
+
+// commandRunner runs a scanner command and returns its standard output, it is replaced in the tests
+type commandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// runCommand runs the command, its standard error is only shown if it fails
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,155 @@
+package vulnerabilityscan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"github.com/stretchr/testify/assert"
+)
+
+const trivyOutput = `{
+  "SchemaVersion": 2,
+  "ArtifactName": "ghcr.io/hasura/ndc-test:v1.0.0",
+  "Results": [
+    {
+      "Target": "ghcr.io/hasura/ndc-test:v1.0.0 (debian 12.5)",
+      "Vulnerabilities": [
+        {"VulnerabilityID": "CVE-2024-0001", "PkgName": "openssl", "InstalledVersion": "3.0.11", "FixedVersion": "3.0.13", "Severity": "HIGH", "Title": "openssl: a vulnerability"},
+        {"VulnerabilityID": "CVE-2024-0002", "PkgName": "zlib", "InstalledVersion": "1.2.13", "Severity": "LOW"}
+      ]
+    },
+    {
+      "Target": "app/.env",
+      "Secrets": [{"RuleID": "aws-access-key-id", "Title": "AWS Access Key ID", "Severity": "CRITICAL"}]
+    }
+  ]
+}`
+
+const grypeOutput = `{
+  "matches": [
+    {
+      "vulnerability": {"id": "GHSA-0001", "severity": "Critical", "description": "a vulnerability", "fix": {"versions": ["1.2.3"]}},
+      "artifact": {"name": "golang.org/x/net", "version": "0.1.0", "locations": [{"path": "/ndc-test-cli"}]}
+    },
+    {
+      "vulnerability": {"id": "CVE-2024-0003", "severity": "Negligible", "fix": {"versions": []}},
+      "artifact": {"name": "libc6", "version": "2.36"}
+    }
+  ]
+}`
+
+// fakeRunner records the commands that it runs, and returns the output of the command
+type fakeRunner struct {
+	output   string
+	err      error
+	commands []string
+}
+
+func (r *fakeRunner) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	r.commands = append(r.commands, name+" "+strings.Join(args, " "))
+	return []byte(r.output), r.err
+}
+
+func TestTrivyScanners(t *testing.T) {
+	runner := &fakeRunner{output: trivyOutput}
+	scanner := &TrivyScanner{run: runner.run}
+	result, err := scanner.ScanImage(context.Background(), "ghcr.io/hasura/ndc-test:v1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"trivy image --format json --quiet --no-progress --severity CRITICAL,HIGH ghcr.io/hasura/ndc-test:v1.0.0"}, runner.commands)
+	assert.Equal(t, Result{Scanner: "trivy", Kind: ImageTarget, Target: "ghcr.io/hasura/ndc-test:v1.0.0", Findings: []Finding{
+		{Class: "vulnerability", ID: "CVE-2024-0001", Severity: HighSeverity, Package: "openssl", InstalledVersion: "3.0.11", FixedVersion: "3.0.13", Title: "openssl: a vulnerability", Location: "ghcr.io/hasura/ndc-test:v1.0.0 (debian 12.5)"},
+		{Class: "secret", ID: "aws-access-key-id", Severity: CriticalSeverity, Title: "AWS Access Key ID", Location: "app/.env"},
+	}}, result)
+
+	runner = &fakeRunner{output: trivyOutput}
+	dockerScanner := &DockerTrivyScanner{Severities: []Severity{LowSeverity}, run: runner.run}
+	result, err = dockerScanner.ScanDir(context.Background(), "/tmp/artifacts")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"docker run --rm -v /tmp/artifacts:/target aquasec/trivy:latest fs --scanners vuln,misconfig,secret,license --format json --quiet --no-progress --severity LOW /target"}, runner.commands)
+	assert.Equal(t, "/tmp/artifacts", result.Target)
+	if assert.Len(t, result.Findings, 1) {
+		assert.Equal(t, "CVE-2024-0002", result.Findings[0].ID)
+	}
+
+	runner = &fakeRunner{err: errors.New("docker failed: exit status 125")}
+	dockerScanner = &DockerTrivyScanner{Image: "aquasec/trivy:0.50.0", run: runner.run}
+	_, err = dockerScanner.ScanImage(context.Background(), "ghcr.io/hasura/ndc-test:v1.0.0")
+	assert.EqualError(t, err, "docker failed: exit status 125")
+	assert.Equal(t, []string{"docker run --rm -v /var/run/docker.sock:/var/run/docker.sock aquasec/trivy:0.50.0 image --format json --quiet --no-progress --severity CRITICAL,HIGH ghcr.io/hasura/ndc-test:v1.0.0"}, runner.commands)
+}
+
+func TestGrypeScanner(t *testing.T) {
+	runner := &fakeRunner{output: grypeOutput}
+	scanner := &GrypeScanner{Severities: []Severity{CriticalSeverity, LowSeverity}, run: runner.run}
+	result, err := scanner.ScanDir(context.Background(), "/tmp/artifacts")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"grype dir:/tmp/artifacts --output json --quiet"}, runner.commands)
+	assert.Equal(t, []Finding{
+		{Class: "vulnerability", ID: "GHSA-0001", Severity: CriticalSeverity, Package: "golang.org/x/net", InstalledVersion: "0.1.0", FixedVersion: "1.2.3", Title: "a vulnerability", Location: "/ndc-test-cli"},
+		// The negligible vulnerabilities are low ones
+		{Class: "vulnerability", ID: "CVE-2024-0003", Severity: LowSeverity, Package: "libc6", InstalledVersion: "2.36"},
+	}, result.Findings)
+
+	_, err = (&GrypeScanner{run: (&fakeRunner{output: "not json"}).run}).ScanImage(context.Background(), "ghcr.io/hasura/ndc-test:v1.0.0")
+	assert.ErrorContains(t, err, "error decoding the grype report")
+}
+
+// fakeScanner reports a finding in every image, and fails to scan the directories
+type fakeScanner struct{}
+
+func (fakeScanner) Name() string { return "fake" }
+
+func (fakeScanner) ScanImage(ctx context.Context, image string) (Result, error) {
+	return Result{Scanner: "fake", Kind: ImageTarget, Target: image, Findings: []Finding{{Class: "vulnerability", ID: "CVE-2024-0001", Severity: HighSeverity, Package: "openssl", InstalledVersion: "3.0.11"}}}, nil
+}
+
+func (fakeScanner) ScanDir(ctx context.Context, dir string) (Result, error) {
+	return Result{}, errors.New("no space left on device")
+}
+
+func TestScanArtifacts(t *testing.T) {
+	artifacts := []*ndchub.ConnectorArtifacts{
+		{Connector: "hasura/test", Version: "v1.0.0", DockerImages: []string{"ghcr.io/hasura/ndc-test:v1.0.0"}},
+		{Connector: "hasura/other", Version: "v2.0.0", ArtifactsDirPath: "/tmp/artifacts"},
+	}
+
+	// Every connector is scanned, the failures are reported along with the findings
+	report := ScanArtifacts(context.Background(), fakeScanner{}, artifacts)
+	assert.True(t, report.HasFindings())
+	assert.True(t, report.Failed())
+	if assert.Len(t, report.Results, 1) {
+		assert.Equal(t, "hasura/test", report.Results[0].Connector)
+		assert.Equal(t, "v1.0.0", report.Results[0].Version)
+	}
+	if assert.Len(t, report.Errors, 1) {
+		assert.Equal(t, "hasura/other", report.Errors[0].Connector)
+		assert.Equal(t, DirTarget, report.Errors[0].Kind)
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, WriteReport(&out, report))
+	assert.Equal(t, `hasura/test v1.0.0: image ghcr.io/hasura/ndc-test:v1.0.0: 1 finding(s)
+  - [HIGH] vulnerability CVE-2024-0001 in openssl 3.0.11
+hasura/other v2.0.0: error scanning the dir /tmp/artifacts: no space left on device
+`, out.String())
+}
+
+func TestNewScanner(t *testing.T) {
+	for _, name := range ScannerNames {
+		scanner, err := NewScanner(name, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, name, scanner.Name())
+	}
+	_, err := NewScanner("clair", nil)
+	assert.EqualError(t, err, `unknown scanner "clair", expected one of trivy, trivy-docker, grype`)
+
+	severities, err := ParseSeverities("critical, HIGH")
+	assert.NoError(t, err)
+	assert.Equal(t, []Severity{CriticalSeverity, HighSeverity}, severities)
+	_, err = ParseSeverities("HIGH,SEVERE")
+	assert.EqualError(t, err, `invalid severity "SEVERE"`)
+}
@@ -1,107 +1,169 @@
 package vulnerabilityscan
 
 import (
-    "os/exec"
-    "fmt"
-    "os"
-    "syscall"
-	"bytes"
-	"log"
-
-	"github.com/hasura/ndc-hub/registry-automation/pkg/ndchub"
+	"context"
+	"encoding/json"
+	"fmt"
 )
 
-func ScanArtifacts(artifacts *ndchub.ConnectorArtifacts) {
-	for _, dockerImage := range artifacts.DockerImages {
-		err := ScanImageWithTrivyDocker(dockerImage)
-		if err != nil {
-			fmt.Printf("error when scanning image %s: %s", dockerImage, err)
-			os.Exit(1)
-		}
-	}
+// DefaultTrivyImage is the image of the dockerised Trivy
+const DefaultTrivyImage = "aquasec/trivy:latest"
 
-	err := ScanDirWithTrivyDocker(artifacts.ArtifactsDirPath)
-	if err != nil {
-		fmt.Printf("error when scanning directory %s: %s", artifacts.ArtifactsDirPath, err)
-		os.Exit(1)
-	}
+// trivyDirScanners are the scanners of Trivy that the artifacts directories are scanned with
+const trivyDirScanners = "vuln,misconfig,secret,license"
+
+// TrivyScanner scans the artifacts with a locally installed trivy binary
+type TrivyScanner struct {
+	// Binary is the path of the trivy binary, trivy from the PATH if empty
+	Binary string
+	// Severities are the severities of the reported findings, DefaultSeverities if empty
+	Severities []Severity
+
+	run commandRunner
 }
 
-func ScanImageWithTrivyDocker(dockerImage string) error {
-	if dockerImage == "" {
-		log.Printf("Docker image is empty, skipping Trivy scan for image.\n")
-		return nil
-	}
+func (s *TrivyScanner) Name() string {
+	return TrivyScannerName
+}
 
-    log.Printf("Scanning docker image with trivy-docker: %s\n", dockerImage)
-    args := []string{
-			"run", "--rm",
-			"-v", "/var/run/docker.sock:/var/run/docker.sock", // Needed to access Docker daemon
-			"aquasec/trivy:latest",
-			"image", 
-			"--severity", "CRITICAL,HIGH",
-            "--exit-code", "1", 
-            "--no-progress", 
-            "--format", "table", 
-            dockerImage,
-		}
+func (s *TrivyScanner) ScanImage(ctx context.Context, image string) (Result, error) {
+	return s.scan(ctx, ImageTarget, image, trivyArgs(ImageTarget, image, s.Severities))
+}
 
-	return scan(args...)
+func (s *TrivyScanner) ScanDir(ctx context.Context, dir string) (Result, error) {
+	return s.scan(ctx, DirTarget, dir, trivyArgs(DirTarget, dir, s.Severities))
 }
 
-func ScanDirWithTrivyDocker(dirPath string) error {
-	if dirPath == "" {
-		log.Printf("Directory path is empty, skipping Trivy scan for directory.\n")
-		return nil
+func (s *TrivyScanner) scan(ctx context.Context, kind TargetKind, target string, args []string) (Result, error) {
+	binary := s.Binary
+	if binary == "" {
+		binary = "trivy"
 	}
+	return runTrivy(ctx, s.run, s.Name(), kind, target, s.Severities, binary, args...)
+}
 
-	log.Printf("Scanning file directory with trivy-docker: %s\n", dirPath)
-
-	args := []string{
-		"run", "--rm",
-		"-v", fmt.Sprintf("%s:/target", dirPath), // Bind-mount the dir into /target inside the container
-		"aquasec/trivy:latest",
-		"fs", "/target",                          // Tell Trivy to scan the /target path
-		"--exit-code", "1",                       // Exit with code 1 if any vuln is found
-		"--no-progress",
-		"--format", "table",
-        "--scanners", "vuln,misconfig,secret,license",
-		"--severity", "CRITICAL,HIGH",
-	}
+// DockerTrivyScanner scans the artifacts with Trivy run in a docker container. The docker socket is mounted in the
+// container to scan the images of the local docker daemon, and the directories are mounted in /target.
+type DockerTrivyScanner struct {
+	// Image is the image of Trivy, DefaultTrivyImage if empty
+	Image string
+	// Severities are the severities of the reported findings, DefaultSeverities if empty
+	Severities []Severity
 
-	return scan(args...)
+	run commandRunner
 }
 
-func scan(args ...string) error {
-    cmd := exec.Command("docker", args...)
+func (s *DockerTrivyScanner) Name() string {
+	return DockerTrivyScannerName
+}
 
-    // Uncomment the following lines to capture output in a buffer
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &out
+func (s *DockerTrivyScanner) ScanImage(ctx context.Context, image string) (Result, error) {
+	args := append([]string{"run", "--rm", "-v", "/var/run/docker.sock:/var/run/docker.sock", s.image()}, trivyArgs(ImageTarget, image, s.Severities)...)
+	return runTrivy(ctx, s.run, s.Name(), ImageTarget, image, s.Severities, "docker", args...)
+}
 
-	// Uncomment the following lines to capture output in os.stdout and os.stderr (terminal)
-    // cmd.Stdout = os.Stdout
-	// cmd.Stderr = os.Stderr
+func (s *DockerTrivyScanner) ScanDir(ctx context.Context, dir string) (Result, error) {
+	args := append([]string{"run", "--rm", "-v", fmt.Sprintf("%s:/target", dir), s.image()}, trivyArgs(DirTarget, "/target", s.Severities)...)
+	return runTrivy(ctx, s.run, s.Name(), DirTarget, dir, s.Severities, "docker", args...)
+}
+
+func (s *DockerTrivyScanner) image() string {
+	if s.Image == "" {
+		return DefaultTrivyImage
+	}
+	return s.Image
+}
 
-	log.Println("Running Trivy scan...")
-	err := cmd.Run()
+// trivyArgs returns the arguments of trivy to scan the target, the findings are printed as JSON
+func trivyArgs(kind TargetKind, target string, severities []Severity) []string {
+	args := []string{"image"}
+	if kind == DirTarget {
+		args = []string{"fs", "--scanners", trivyDirScanners}
+	}
+	return append(args, "--format", "json", "--quiet", "--no-progress", "--severity", joinSeverities(severities), target)
+}
 
+func runTrivy(ctx context.Context, run commandRunner, scanner string, kind TargetKind, target string, severities []Severity, name string, args ...string) (Result, error) {
+	if run == nil {
+		run = runCommand
+	}
+	result := Result{Scanner: scanner, Kind: kind, Target: target}
+	out, err := run(ctx, name, args...)
 	if err != nil {
-		// we'll only print the output if there was an error
-		fmt.Println(out.String())
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			code := exitErr.Sys().(syscall.WaitStatus).ExitStatus()
-			fmt.Printf("Trivy exited with code: %d\n", code)
-			if code == 1 {
-				return fmt.Errorf("vulnerabilities found")
-			}
-			return fmt.Errorf("trivy failed with exit code %d", code)
-		}
-		return fmt.Errorf("trivy execution failed: %w", err)
+		return result, err
 	}
+	findings, err := parseTrivyReport(out)
+	if err != nil {
+		return result, err
+	}
+	result.Findings = filterSeverities(findings, severities)
+	return result, nil
+}
+
+// trivyReport is the JSON report of Trivy, with the fields of the findings that are reported
+type trivyReport struct {
+	Results []struct {
+		Target          string `json:"Target"`
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Severity         string `json:"Severity"`
+			Title            string `json:"Title"`
+		} `json:"Vulnerabilities"`
+		Misconfigurations []struct {
+			ID       string `json:"ID"`
+			Title    string `json:"Title"`
+			Severity string `json:"Severity"`
+		} `json:"Misconfigurations"`
+		Secrets []struct {
+			RuleID   string `json:"RuleID"`
+			Title    string `json:"Title"`
+			Severity string `json:"Severity"`
+		} `json:"Secrets"`
+		Licenses []struct {
+			Name     string `json:"Name"`
+			PkgName  string `json:"PkgName"`
+			FilePath string `json:"FilePath"`
+			Severity string `json:"Severity"`
+		} `json:"Licenses"`
+	} `json:"Results"`
+}
 
-	log.Printf("Trivy scan completed successfully. No vulnerabilities found\n")
+func parseTrivyReport(out []byte) ([]Finding, error) {
+	var report trivyReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("error decoding the trivy report: %w", err)
+	}
 
-	return nil
-}
\ No newline at end of file
+	findings := make([]Finding, 0)
+	for _, result := range report.Results {
+		for _, vulnerability := range result.Vulnerabilities {
+			findings = append(findings, Finding{
+				Class:            "vulnerability",
+				ID:               vulnerability.VulnerabilityID,
+				Severity:         normalizeSeverity(vulnerability.Severity),
+				Package:          vulnerability.PkgName,
+				InstalledVersion: vulnerability.InstalledVersion,
+				FixedVersion:     vulnerability.FixedVersion,
+				Title:            vulnerability.Title,
+				Location:         result.Target,
+			})
+		}
+		for _, misconfiguration := range result.Misconfigurations {
+			findings = append(findings, Finding{Class: "misconfiguration", ID: misconfiguration.ID, Severity: normalizeSeverity(misconfiguration.Severity), Title: misconfiguration.Title, Location: result.Target})
+		}
+		for _, secret := range result.Secrets {
+			findings = append(findings, Finding{Class: "secret", ID: secret.RuleID, Severity: normalizeSeverity(secret.Severity), Title: secret.Title, Location: result.Target})
+		}
+		for _, license := range result.Licenses {
+			location := license.FilePath
+			if location == "" {
+				location = result.Target
+			}
+			findings = append(findings, Finding{Class: "license", ID: license.Name, Severity: normalizeSeverity(license.Severity), Package: license.PkgName, Location: location})
+		}
+	}
+	return findings, nil
+}